@@ -0,0 +1,171 @@
+package calendar
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Calendar is the trading calendar of an exchange. Dates are compared by year, month and day, regardless of their time and location.
+type Calendar struct {
+	name string
+	// rules returns the holidays and early closes of a year
+	rules func(year int) (holidays map[time.Time]string, earlyCloses map[time.Time]time.Duration)
+	// closeTime returns the regular close of a session as time since midnight in exchange time
+	closeTime func(d time.Time) time.Duration
+
+	mu    sync.Mutex
+	years map[int]*yearDays
+}
+
+type yearDays struct {
+	holidays    map[time.Time]string
+	earlyCloses map[time.Time]time.Duration
+}
+
+// ForName returns the calendar of an exchange by name, either nyse or jpx
+func ForName(name string) (*Calendar, error) {
+	switch strings.ToLower(name) {
+	case "nyse":
+		return NYSE(), nil
+	case "jpx":
+		return JPX(), nil
+	default:
+		return nil, errors.Errorf("Unsupported calendar %+v, expected nyse or jpx", name)
+	}
+}
+
+func newCalendar(
+	name string,
+	rules func(year int) (map[time.Time]string, map[time.Time]time.Duration),
+	closeTime func(d time.Time) time.Duration,
+) *Calendar {
+	return &Calendar{
+		name:      name,
+		rules:     rules,
+		closeTime: closeTime,
+		years:     make(map[int]*yearDays),
+	}
+}
+
+// Name returns the name of the exchange
+func (c *Calendar) Name() string {
+	return c.name
+}
+
+// day truncates t to its date in UTC, which is how quote and expiry dates are parsed
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (c *Calendar) year(y int) *yearDays {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if days, ok := c.years[y]; ok {
+		return days
+	}
+	holidays, earlyCloses := c.rules(y)
+	days := &yearDays{holidays: holidays, earlyCloses: earlyCloses}
+	c.years[y] = days
+	return days
+}
+
+// Holiday returns the name of the holiday if the exchange is closed on t for a holiday
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	d := day(t)
+	name, ok := c.year(d.Year()).holidays[d]
+	return name, ok
+}
+
+// IsTradingDay returns true if the exchange is open on t
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := c.Holiday(t)
+	return !holiday
+}
+
+// IsEarlyClose returns true if t is a trading day that closes before the regular close
+func (c *Calendar) IsEarlyClose(t time.Time) bool {
+	d := day(t)
+	_, ok := c.year(d.Year()).earlyCloses[d]
+	return ok && c.IsTradingDay(d)
+}
+
+// CloseTime returns the close of the session on t as time since midnight in exchange time
+func (c *Calendar) CloseTime(t time.Time) time.Duration {
+	d := day(t)
+	if early, ok := c.year(d.Year()).earlyCloses[d]; ok {
+		return early
+	}
+	return c.closeTime(d)
+}
+
+// NextTradingDay returns the first trading day after t
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	d := day(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// PrevTradingDay returns the last trading day before t
+func (c *Calendar) PrevTradingDay(t time.Time) time.Time {
+	d := day(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// LastTradingDay returns t if it is a trading day, otherwise the last trading day before t
+func (c *Calendar) LastTradingDay(t time.Time) time.Time {
+	if c.IsTradingDay(t) {
+		return day(t)
+	}
+	return c.PrevTradingDay(t)
+}
+
+// AddTradingDays returns the date n trading days after t, or before t if n is negative
+func (c *Calendar) AddTradingDays(t time.Time, n int) time.Time {
+	d := day(t)
+	for ; n > 0; n-- {
+		d = c.NextTradingDay(d)
+	}
+	for ; n < 0; n++ {
+		d = c.PrevTradingDay(d)
+	}
+	return d
+}
+
+// TradingDaysBetween returns the number of trading days after from and up to and including to, which is the number of sessions left until to. It is negative if to is before from.
+func (c *Calendar) TradingDaysBetween(from, to time.Time) int {
+	from, to = day(from), day(to)
+	sign := 1
+	if to.Before(from) {
+		from, to = to, from
+		sign = -1
+	}
+	n := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			n++
+		}
+	}
+	return n * sign
+}
+
+// TradingDays returns the trading days from from up to and including to
+func (c *Calendar) TradingDays(from, to time.Time) []time.Time {
+	days := make([]time.Time, 0)
+	for d := day(from); !d.After(day(to)); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHolidays(t *testing.T) {
+	tt := []struct {
+		cal      *Calendar
+		year     int
+		holidays []string
+	}{
+		{
+			cal:      NYSE(),
+			year:     2020,
+			holidays: []string{"2020-01-01", "2020-01-20", "2020-02-17", "2020-04-10", "2020-05-25", "2020-07-03", "2020-09-07", "2020-11-26", "2020-12-25"},
+		},
+		{
+			// New Year's Day on Saturday is not observed
+			cal:      NYSE(),
+			year:     2022,
+			holidays: []string{"2022-01-17", "2022-02-21", "2022-04-15", "2022-05-30", "2022-06-20", "2022-07-04", "2022-09-05", "2022-11-24", "2022-12-26"},
+		},
+		{
+			cal:  JPX(),
+			year: 2019,
+			holidays: []string{"2019-01-01", "2019-01-02", "2019-01-03", "2019-01-14", "2019-02-11", "2019-03-21", "2019-04-29", "2019-04-30",
+				"2019-05-01", "2019-05-02", "2019-05-03", "2019-05-06", "2019-07-15", "2019-08-12", "2019-09-16", "2019-09-23",
+				"2019-10-14", "2019-10-22", "2019-11-04", "2019-12-31"},
+		},
+	}
+	for idx, tab := range tt {
+		expected := make(map[string]bool)
+		for _, h := range tab.holidays {
+			expected[h] = true
+		}
+		for d := date(tab.year, time.January, 1); d.Year() == tab.year; d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+				continue
+			}
+			if name, ok := tab.cal.Holiday(d); ok != expected[d.Format("2006-01-02")] {
+				t.Errorf("Expected holiday on %+v to be %+v but got %+v (%+v) at idx: %d", d.Format("2006-01-02"), expected[d.Format("2006-01-02")], ok, name, idx)
+			}
+		}
+	}
+}
+
+func TestTradingDays(t *testing.T) {
+	nyse := NYSE()
+	parse := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tt := []struct {
+		from string
+		n    int
+		to   string
+	}{
+		{from: "2020-07-02", n: 1, to: "2020-07-06"},
+		{from: "2020-07-06", n: -1, to: "2020-07-02"},
+		{from: "2020-12-31", n: 5, to: "2021-01-08"},
+		{from: "2020-04-09", n: 0, to: "2020-04-09"},
+	}
+	for idx, tab := range tt {
+		to := nyse.AddTradingDays(parse(tab.from), tab.n)
+		if !to.Equal(parse(tab.to)) {
+			t.Errorf("Expected %+v but got %+v at idx: %d", tab.to, to, idx)
+		}
+		if n := nyse.TradingDaysBetween(parse(tab.from), to); n != tab.n {
+			t.Errorf("Expected %d trading days but got %d at idx: %d", tab.n, n, idx)
+		}
+	}
+
+	if d := nyse.LastTradingDay(parse("2020-04-11")); !d.Equal(parse("2020-04-09")) {
+		t.Errorf("Expected the Saturday after Good Friday to settle on 2020-04-09 but got %+v", d)
+	}
+	if !nyse.IsEarlyClose(parse("2019-11-29")) || nyse.CloseTime(parse("2019-12-24")) != 13*time.Hour {
+		t.Errorf("Expected early closes after Thanksgiving and on Christmas Eve")
+	}
+	if nyse.IsEarlyClose(parse("2020-07-03")) {
+		t.Errorf("Expected no early close on the observed Independence Day")
+	}
+	if n := len(nyse.TradingDays(parse("2020-11-23"), parse("2020-11-29"))); n != 4 {
+		t.Errorf("Expected 4 trading days in Thanksgiving week but got %d", n)
+	}
+}
@@ -0,0 +1,135 @@
+package calendar
+
+import (
+	"math"
+	"time"
+)
+
+// jpxSpecialHolidays are national holidays that do not follow a holiday rule
+var jpxSpecialHolidays = map[time.Time]string{
+	date(1990, time.November, 12): "Enthronement Ceremony",
+	date(1993, time.June, 9):      "Imperial Wedding",
+	date(2019, time.May, 1):       "Enthronement Day",
+	date(2019, time.October, 22):  "Enthronement Ceremony",
+}
+
+// JPX returns the calendar of the Japan Exchange Group. Half day sessions are not supported, so there are no early closes.
+func JPX() *Calendar {
+	return newCalendar("jpx", jpxYear, func(d time.Time) time.Duration {
+		// the cash equity close was extended to 15:30 on 2024-11-05
+		if d.Before(date(2024, time.November, 5)) {
+			return 15 * time.Hour
+		}
+		return 15*time.Hour + 30*time.Minute
+	})
+}
+
+// equinox returns the vernal (base 20.8431) or autumnal (base 23.2488) equinox day, valid from 1980 to 2099
+func equinox(year int, month time.Month, base float64) time.Time {
+	y := float64(year - 1980)
+	return date(year, month, int(math.Floor(base+0.242194*y-math.Floor(y/4))))
+}
+
+// jpxNationalHolidays returns the national holidays by law before substitute and citizen's holidays
+func jpxNationalHolidays(year int) map[time.Time]string {
+	holidays := map[time.Time]string{
+		date(year, time.January, 1):            "New Year's Day",
+		date(year, time.February, 11):          "National Foundation Day",
+		equinox(year, time.March, 20.8431):     "Vernal Equinox Day",
+		date(year, time.April, 29):             "Showa Day",
+		date(year, time.May, 3):                "Constitution Memorial Day",
+		date(year, time.May, 5):                "Children's Day",
+		equinox(year, time.September, 23.2488): "Autumnal Equinox Day",
+		date(year, time.November, 3):           "Culture Day",
+		date(year, time.November, 23):          "Labor Thanksgiving Day",
+	}
+	if year >= 2000 {
+		holidays[nthWeekday(year, time.January, time.Monday, 2)] = "Coming of Age Day"
+	} else {
+		holidays[date(year, time.January, 15)] = "Coming of Age Day"
+	}
+	if year >= 2020 {
+		holidays[date(year, time.February, 23)] = "Emperor's Birthday"
+	} else if year >= 1989 && year <= 2018 {
+		holidays[date(year, time.December, 23)] = "Emperor's Birthday"
+	}
+	if year >= 2007 {
+		holidays[date(year, time.May, 4)] = "Greenery Day"
+	}
+
+	// the holidays around the Tokyo Olympics were moved by law
+	switch {
+	case year == 2020:
+		holidays[date(year, time.July, 23)] = "Marine Day"
+		holidays[date(year, time.July, 24)] = "Sports Day"
+		holidays[date(year, time.August, 10)] = "Mountain Day"
+	case year == 2021:
+		holidays[date(year, time.July, 22)] = "Marine Day"
+		holidays[date(year, time.July, 23)] = "Sports Day"
+		holidays[date(year, time.August, 8)] = "Mountain Day"
+	default:
+		if year >= 2003 {
+			holidays[nthWeekday(year, time.July, time.Monday, 3)] = "Marine Day"
+		} else if year >= 1996 {
+			holidays[date(year, time.July, 20)] = "Marine Day"
+		}
+		if year >= 2000 {
+			holidays[nthWeekday(year, time.October, time.Monday, 2)] = "Sports Day"
+		} else {
+			holidays[date(year, time.October, 10)] = "Sports Day"
+		}
+		if year >= 2016 {
+			holidays[date(year, time.August, 11)] = "Mountain Day"
+		}
+	}
+	if year >= 2003 {
+		holidays[nthWeekday(year, time.September, time.Monday, 3)] = "Respect for the Aged Day"
+	} else {
+		holidays[date(year, time.September, 15)] = "Respect for the Aged Day"
+	}
+	for d, name := range jpxSpecialHolidays {
+		if d.Year() == year {
+			holidays[d] = name
+		}
+	}
+	return holidays
+}
+
+func jpxYear(year int) (map[time.Time]string, map[time.Time]time.Duration) {
+	national := jpxNationalHolidays(year)
+	holidays := make(map[time.Time]string, len(national)+8)
+	for d, name := range national {
+		holidays[d] = name
+	}
+	// a holiday on Sunday moves to the next day that is not a holiday
+	for d := range national {
+		if d.Weekday() != time.Sunday {
+			continue
+		}
+		sub := d.AddDate(0, 0, 1)
+		for {
+			if _, ok := national[sub]; !ok {
+				break
+			}
+			sub = sub.AddDate(0, 0, 1)
+		}
+		holidays[sub] = "Substitute Holiday"
+	}
+	// a day between two national holidays is a citizen's holiday
+	for d := range national {
+		between := d.AddDate(0, 0, 1)
+		if _, ok := national[between.AddDate(0, 0, 1)]; !ok {
+			continue
+		}
+		if _, ok := holidays[between]; !ok && between.Weekday() != time.Sunday {
+			holidays[between] = "Citizen's Holiday"
+		}
+	}
+	// the exchange closes for the new year holidays
+	for _, d := range []time.Time{date(year, time.January, 2), date(year, time.January, 3), date(year, time.December, 31)} {
+		if _, ok := holidays[d]; !ok {
+			holidays[d] = "Exchange Holiday"
+		}
+	}
+	return holidays, map[time.Time]time.Duration{}
+}
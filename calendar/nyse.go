@@ -0,0 +1,74 @@
+package calendar
+
+import "time"
+
+// nyseSpecialClosures are closures that do not follow a holiday rule
+var nyseSpecialClosures = map[time.Time]string{
+	date(1994, time.April, 27):     "Nixon Funeral",
+	date(2001, time.September, 11): "September 11",
+	date(2001, time.September, 12): "September 11",
+	date(2001, time.September, 13): "September 11",
+	date(2001, time.September, 14): "September 11",
+	date(2004, time.June, 11):      "Reagan Funeral",
+	date(2007, time.January, 2):    "Ford Funeral",
+	date(2012, time.October, 29):   "Hurricane Sandy",
+	date(2012, time.October, 30):   "Hurricane Sandy",
+	date(2018, time.December, 5):   "Bush Funeral",
+	date(2025, time.January, 9):    "Carter Funeral",
+}
+
+// NYSE returns the calendar of the New York Stock Exchange, which the US option exchanges follow
+func NYSE() *Calendar {
+	return newCalendar("nyse", nyseYear, func(time.Time) time.Duration {
+		return 16 * time.Hour
+	})
+}
+
+// nyseObserved moves a holiday on Saturday to Friday and on Sunday to Monday
+func nyseObserved(d time.Time) time.Time {
+	switch d.Weekday() {
+	case time.Saturday:
+		return d.AddDate(0, 0, -1)
+	case time.Sunday:
+		return d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func nyseYear(year int) (map[time.Time]string, map[time.Time]time.Duration) {
+	holidays := make(map[time.Time]string)
+	// New Year's Day on Saturday is not observed on the Friday before, which ends the previous year
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays[nyseObserved(newYear)] = "New Year's Day"
+	}
+	if year >= 1998 {
+		holidays[nthWeekday(year, time.January, time.Monday, 3)] = "Martin Luther King Jr. Day"
+	}
+	holidays[nthWeekday(year, time.February, time.Monday, 3)] = "Washington's Birthday"
+	holidays[easter(year).AddDate(0, 0, -2)] = "Good Friday"
+	holidays[lastWeekday(year, time.May, time.Monday)] = "Memorial Day"
+	if year >= 2022 {
+		holidays[nyseObserved(date(year, time.June, 19))] = "Juneteenth"
+	}
+	holidays[nyseObserved(date(year, time.July, 4))] = "Independence Day"
+	holidays[nthWeekday(year, time.September, time.Monday, 1)] = "Labor Day"
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	holidays[thanksgiving] = "Thanksgiving Day"
+	holidays[nyseObserved(date(year, time.December, 25))] = "Christmas Day"
+	for d, name := range nyseSpecialClosures {
+		if d.Year() == year {
+			holidays[d] = name
+		}
+	}
+
+	early := 13 * time.Hour
+	earlyCloses := map[time.Time]time.Duration{
+		thanksgiving.AddDate(0, 0, 1): early,
+	}
+	for _, d := range []time.Time{date(year, time.July, 3), date(year, time.December, 24)} {
+		if _, holiday := holidays[d]; !holiday && d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			earlyCloses[d] = early
+		}
+	}
+	return holidays, earlyCloses
+}
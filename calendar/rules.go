@@ -0,0 +1,41 @@
+package calendar
+
+import "time"
+
+// date returns a date in UTC
+func date(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday returns the nth weekday of the month, e.g. the 3rd Monday of January
+func nthWeekday(year int, month time.Month, wd time.Weekday, n int) time.Time {
+	d := date(year, month, 1)
+	offset := (int(wd) - int(d.Weekday()) + 7) % 7
+	return d.AddDate(0, 0, offset+(n-1)*7)
+}
+
+// lastWeekday returns the last weekday of the month, e.g. the last Monday of May
+func lastWeekday(year int, month time.Month, wd time.Weekday) time.Time {
+	d := date(year, month+1, 1).AddDate(0, 0, -1)
+	offset := (int(d.Weekday()) - int(wd) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday of the Gregorian calendar
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	dd := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), dd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"backtest-options/util"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "convert writes a binary copy of every normalized file in the data dir",
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		files, err := util.PartitionFiles(dataDir, "", time.Time{}, time.Time{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		converted := 0
+		for _, file := range files {
+			if _, ok := util.FreshBinaryPath(file); ok && !force {
+				continue
+			}
+			path, err := util.ConvertCSVToBinary(file)
+			if err != nil {
+				log.Fatal(errors.Wrapf(err, "Error converting %+v", file))
+			}
+			log.Debugf("Converted %+v into %+v", file, path)
+			converted++
+		}
+		log.Infof("Successfully converted %d of %d files", converted, len(files))
+	},
+}
+
+func init() {
+	convertCmd.Flags().Bool("force", false, "Convert files even if their binary copy is up to date")
+}
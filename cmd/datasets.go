@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"backtest-options/util"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var datasetsCmd = &cobra.Command{
+	Use:   "datasets",
+	Short: "datasets manages the catalog of named data directories",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires a datasets command")
+		}
+		return nil
+	},
+}
+
+var datasetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list lists the datasets of the catalog with the quote dates covered for each symbol",
+	Run: func(cmd *cobra.Command, args []string) {
+		catalog, err := loadCatalog(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data := [][]string{}
+		for _, d := range catalog.Datasets {
+			dir, _ := catalog.DataDir(d.Name)
+			coverage, err := util.DatasetCoverage(dir)
+			if err != nil {
+				log.Fatal(errors.Wrapf(err, "Error reading coverage of dataset %+v", d.Name))
+			}
+			if len(coverage) == 0 {
+				data = append(data, []string{d.Name, dir, d.Description, "", "", "", "0"})
+			}
+			for _, c := range coverage {
+				data = append(data, []string{d.Name, dir, d.Description, c.Symbol, c.FirstQuoteDate, c.LastQuoteDate, strconv.Itoa(c.Files)})
+			}
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Path", "Description", "Symbol", "First Quote Date", "Last Quote Date", "Files"})
+		table.SetAutoMergeCells(true)
+		table.AppendBulk(data)
+		table.Render()
+	},
+}
+
+var datasetsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add adds a dataset to the catalog, or updates the dataset with the same name",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("requires dataset name and path arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		catalog, err := loadCatalog(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := catalog.Put(util.Dataset{
+			Name:        args[0],
+			Path:        args[1],
+			Description: cmd.Flag("description").Value.String(),
+		}); err != nil {
+			log.Fatal(err)
+		}
+		if err := catalog.Save(); err != nil {
+			log.Fatal(errors.Wrap(err, "Error saving catalog"))
+		}
+		log.Infof("Successfully added dataset %s at %+v", args[0], args[1])
+	},
+}
+
+// loadCatalog loads the catalog of the catalog flag
+func loadCatalog(cmd *cobra.Command) (*util.Catalog, error) {
+	return util.LoadCatalog(cmd.Flag("catalog").Value.String())
+}
+
+// getDataDir returns the data directory of the dataset flag if it is set, and the dataDir flag otherwise
+func getDataDir(cmd *cobra.Command) (string, error) {
+	name := cmd.Flag("dataset").Value.String()
+	if name == "" {
+		return cmd.Flag("dataDir").Value.String(), nil
+	}
+	catalog, err := loadCatalog(cmd)
+	if err != nil {
+		return "", err
+	}
+	return catalog.DataDir(name)
+}
+
+func init() {
+	datasetsAddCmd.Flags().String("description", "", "Description of the data, e.g. SPY end of day quotes from LiveVol")
+	datasetsCmd.AddCommand(datasetsListCmd)
+	datasetsCmd.AddCommand(datasetsAddCmd)
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"backtest-options/model"
+	"backtest-options/synth"
+	"backtest-options/util"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "generate generates synthetic option data of a simulated underlying into the data dir",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := getGenerateConfig(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if output := cmd.Flag("output").Value.String(); output != "" {
+			dataDir, err := getDataDir(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			rows, err := generateToFile(cfg, output, dataDir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Infof("Successfully generated %d rows into %+v", rows, output)
+			return
+		}
+
+		outputDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+			log.Fatal(errors.Wrapf(err, "Error making dir at %+v", outputDir))
+		}
+		tmpDir, err := ioutil.TempDir("", "generate")
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Error creating temp dir"))
+		}
+		defer os.RemoveAll(tmpDir)
+		// the source name identifies the generated data in the import manifest, so generating the same range again replaces it
+		source := filepath.Join(tmpDir, strings.Join([]string{
+			"synthetic",
+			cfg.Symbol,
+			cfg.Start.Format(model.DateLayout),
+			cfg.End.Format(model.DateLayout),
+		}, "_")+".csv")
+		if _, err := generateToFile(cfg, source, outputDir); err != nil {
+			log.Fatal(err)
+		}
+
+		manifest, err := util.LoadManifest(filepath.Join(outputDir, util.ManifestFileName))
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Error loading import manifest"))
+		}
+		newImporter := func() util.Importer {
+			imp, _ := util.NewImporter("normalized", nil)
+			return imp
+		}
+		action, stats, err := util.ImportIncremental(manifest, newImporter, source, outputDir, true)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "Error importing generated data"))
+		}
+		if err := manifest.Save(); err != nil {
+			log.Fatal(errors.Wrap(err, "Error saving import manifest"))
+		}
+		log.Infof("Successfully generated %d rows of %s into %+v (%s)", stats.Rows, cfg.Symbol, outputDir, action)
+	},
+}
+
+// generateToFile generates the option data of cfg into a normalized csv file and saves the simulated underlying prices into dataDir. It returns the number of rows written
+func generateToFile(cfg synth.Config, output, dataDir string) (int, error) {
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %+v", output)
+	}
+	defer f.Close()
+
+	w := util.NewNormalizedWriter(f)
+	rows := 0
+	bars, err := synth.Generate(cfg, func(o model.OHLCV) error {
+		rows++
+		return w.Write(o)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Error generating option data")
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	series, err := model.NewUnderlyingSeries(cfg.Symbol, bars)
+	if err != nil {
+		return 0, err
+	}
+	if err := util.SaveUnderlying(dataDir, series); err != nil {
+		return 0, errors.Wrapf(err, "Error saving underlying prices of %s", cfg.Symbol)
+	}
+	return rows, nil
+}
+
+// getGenerateConfig builds the generator config from the flags
+func getGenerateConfig(cmd *cobra.Command) (synth.Config, error) {
+	flags := cmd.Flags()
+	cfg := synth.Config{
+		Root:   cmd.Flag("root").Value.String(),
+		Symbol: cmd.Flag("symbol").Value.String(),
+	}
+	var err error
+	if cfg.Calendar, err = getCalendar(cmd); err != nil {
+		return cfg, err
+	}
+	if cfg.Start, err = getDate(cmd, "start"); err != nil {
+		return cfg, err
+	}
+	if cfg.End, err = getDate(cmd, "end"); err != nil {
+		return cfg, err
+	}
+	if cfg.End.IsZero() {
+		cfg.End = cfg.Start.AddDate(1, 0, 0)
+	}
+	cfg.DividendYield, _ = flags.GetFloat64("dividendYield")
+	cfg.MinSpread, _ = flags.GetFloat64("minSpread")
+	cfg.Monthlies, _ = flags.GetInt("monthlies")
+	cfg.Rate, _ = flags.GetFloat64("rate")
+	cfg.Seed, _ = flags.GetInt64("seed")
+	cfg.SpreadPct, _ = flags.GetFloat64("spread")
+	cfg.StrikeRange, _ = flags.GetFloat64("strikeRange")
+	cfg.StrikeStep, _ = flags.GetFloat64("strikeStep")
+	cfg.Tick, _ = flags.GetFloat64("tick")
+	cfg.Weeklies, _ = flags.GetInt("weeklies")
+	cfg.Skew.ATMVol, _ = flags.GetFloat64("atmVol")
+	cfg.Skew.Curvature, _ = flags.GetFloat64("smile")
+	cfg.Skew.FollowRealized, _ = flags.GetBool("followRealized")
+	cfg.Skew.Slope, _ = flags.GetFloat64("skew")
+	cfg.Skew.TermSlope, _ = flags.GetFloat64("termSlope")
+
+	shocks, _ := flags.GetStringSlice("shock")
+	for _, v := range shocks {
+		shock, err := parseShock(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Shocks = append(cfg.Shocks, shock)
+	}
+
+	if cfg.Path, err = getPath(cmd); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// getPath builds the simulated underlying path of the model flag
+func getPath(cmd *cobra.Command) (synth.Path, error) {
+	flags := cmd.Flags()
+	spot, _ := flags.GetFloat64("spot")
+	drift, _ := flags.GetFloat64("drift")
+	vol, _ := flags.GetFloat64("vol")
+	switch m := cmd.Flag("model").Value.String(); m {
+	case "gbm":
+		return synth.NewGBMPath(spot, drift, vol)
+	case "jump":
+		intensity, _ := flags.GetFloat64("jumpIntensity")
+		jumpMean, _ := flags.GetFloat64("jumpMean")
+		jumpVol, _ := flags.GetFloat64("jumpVol")
+		return synth.NewJumpDiffusionPath(spot, drift, vol, intensity, jumpMean, jumpVol)
+	case "replay":
+		path := cmd.Flag("replay").Value.String()
+		if path == "" {
+			return nil, errors.New("requires the replay flag for the replay model")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error opening %+v", path)
+		}
+		defer f.Close()
+		bars, err := util.ReadUnderlyingCSV(csv.NewReader(f))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading prices to replay from %+v", path)
+		}
+		closes := make([]float64, 0, len(bars))
+		for _, b := range bars {
+			c, _ := b.Close.Float64()
+			closes = append(closes, c)
+		}
+		return synth.NewReplayPath(closes)
+	default:
+		return nil, errors.Errorf("Unsupported model %+v, expected gbm, jump or replay", m)
+	}
+}
+
+// parseShock parses a shock of the form date:return, e.g. 2020-03-16:-0.12
+func parseShock(v string) (synth.Shock, error) {
+	parts := strings.SplitN(v, ":", 2)
+	if len(parts) != 2 {
+		return synth.Shock{}, errors.Errorf("Error parsing shock %+v, expected date:return", v)
+	}
+	d, err := time.Parse(model.DateLayout, parts[0])
+	if err != nil {
+		return synth.Shock{}, errors.Wrapf(err, "Error parsing shock date: %+v", parts[0])
+	}
+	ret, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return synth.Shock{}, errors.Wrapf(err, "Error parsing shock return: %+v", parts[1])
+	}
+	return synth.Shock{Date: d, Return: ret}, nil
+}
+
+func init() {
+	generateCmd.Flags().String("symbol", "SYN", "Underlying symbol of the generated data")
+	generateCmd.Flags().String("root", "", "Option root of the generated data (Default: the symbol)")
+	generateCmd.Flags().String("start", "2020-01-02", "First quote date (YYYY-MM-DD)")
+	generateCmd.Flags().String("end", "", "Last quote date (YYYY-MM-DD) (Default: a year after start)")
+	generateCmd.Flags().String("calendar", "nyse", "Trading calendar of quote dates and expiries, one of nyse or jpx")
+	generateCmd.Flags().Int64("seed", 1, "Seed of the simulation, the same flags always generate the same data")
+	generateCmd.Flags().String("output", "", "Write the option data into this single file instead of importing it into the data dir")
+
+	generateCmd.Flags().String("model", "gbm", "Model of the underlying, one of gbm, jump or replay")
+	generateCmd.Flags().Float64("spot", 100, "Underlying price on the start date")
+	generateCmd.Flags().Float64("drift", 0.05, "Annual drift of the underlying")
+	generateCmd.Flags().Float64("vol", 0.2, "Annual volatility of the underlying")
+	generateCmd.Flags().Float64("jumpIntensity", 1, "Average number of jumps a year of the jump model")
+	generateCmd.Flags().Float64("jumpMean", -0.05, "Mean log return of a jump of the jump model")
+	generateCmd.Flags().Float64("jumpVol", 0.1, "Volatility of the log return of a jump of the jump model")
+	generateCmd.Flags().String("replay", "", "Underlying price csv whose closes the replay model replays, in the format of import-underlying")
+	generateCmd.Flags().StringSlice("shock", nil, "One day return applied on a date, e.g. 2020-03-16:-0.12. Can be repeated")
+
+	generateCmd.Flags().Float64("atmVol", 0.2, "Implied volatility of 30 day at the money options")
+	generateCmd.Flags().Float64("skew", -0.1, "Change of implied volatility per unit of moneyness ln(K/F)/sqrt(T)")
+	generateCmd.Flags().Float64("smile", 0.05, "Change of implied volatility per squared unit of moneyness")
+	generateCmd.Flags().Float64("termSlope", 0, "Change of at the money implied volatility per year of maturity")
+	generateCmd.Flags().Bool("followRealized", true, "Raise at the money implied volatility to the realized volatility of the last 20 days when that is higher")
+	generateCmd.Flags().Float64("rate", 0.02, "Annual risk-free rate options are priced with")
+	generateCmd.Flags().Float64("dividendYield", 0, "Annual dividend yield options are priced with")
+
+	generateCmd.Flags().Float64("strikeStep", 1, "Distance between listed strikes")
+	generateCmd.Flags().Float64("strikeRange", 0.2, "Range of listed strikes around the underlying price as a fraction")
+	generateCmd.Flags().Int("weeklies", 4, "Number of weekly expiries listed on every quote date")
+	generateCmd.Flags().Int("monthlies", 6, "Number of monthly expiries listed on every quote date")
+	generateCmd.Flags().Float64("spread", 0.05, "Bid ask spread as a fraction of the option price")
+	generateCmd.Flags().Float64("minSpread", 0.02, "Narrowest bid ask spread")
+	generateCmd.Flags().Float64("tick", 0.01, "Price increment of option quotes")
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"backtest-options/util"
 	"encoding/csv"
+	"io/ioutil"
 	"os"
-	"time"
+	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -17,27 +19,138 @@ var importCmd = &cobra.Command{
 	Short: "import imports external data source into a normalized file",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
-			return errors.New("requires import dir argument")
+			return errors.New("requires import dir or file argument")
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		log.Debugf("Running import on livevol data from dir: %+v", args[0])
-		importDir := args[0]
-		outputDir := "./data"
+		importPath := args[0]
+		info, err := os.Stat(importPath)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "Error reading %+v", importPath))
+		}
+
+		vendor := cmd.Flag("vendor").Value.String()
+		if _, err := util.NewImporter(vendor, nil); err != nil {
+			log.Fatal(err)
+		}
+
+		if output := cmd.Flag("output").Value.String(); output != "" {
+			importToFile(vendor, importPath, info.IsDir(), output)
+			return
+		}
+
+		outputDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
 		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
-			log.Fatal(errors.Wrapf(err, "Error making dir at %+v", importDir))
+			log.Fatal(errors.Wrapf(err, "Error making dir at %+v", outputDir))
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		bin, _ := cmd.Flags().GetBool("binary")
+
+		sources := []string{importPath}
+		if info.IsDir() {
+			files, err := ioutil.ReadDir(importPath)
+			if err != nil {
+				log.Fatal(errors.Wrapf(err, "Error reading dir %+v", importPath))
+			}
+			sources = make([]string, 0, len(files))
+			for _, file := range files {
+				if !file.IsDir() {
+					sources = append(sources, filepath.Join(importPath, file.Name()))
+				}
+			}
+		}
+
+		manifest, err := util.LoadManifest(filepath.Join(outputDir, util.ManifestFileName))
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Error loading import manifest"))
+		}
+		newImporter := func() util.Importer {
+			imp, _ := util.NewImporter(vendor, nil)
+			return imp
+		}
+		counts := make(map[util.ImportAction]int)
+		// sources overlapping with imported ones are skipped, and the import fails after the others are imported
+		overlaps := make([]string, 0)
+		for _, source := range sources {
+			action, stats, err := util.ImportIncremental(manifest, newImporter, source, outputDir, force)
+			if _, ok := errors.Cause(err).(*util.OverlapError); ok {
+				log.Warn(err)
+				overlaps = append(overlaps, filepath.Base(source))
+				continue
+			}
+			if err != nil {
+				// keep the manifest consistent with what has been imported so far
+				saveManifest(manifest)
+				log.Fatal(errors.Wrapf(err, "Error importing %+v", source))
+			}
+			counts[action]++
+			log.Debugf("%s %+v: %d rows, skipped %d rows", action, source, stats.Rows, stats.Skipped)
+			if bin && action != util.ImportSkipped {
+				for _, o := range manifest.Find(filepath.Base(source)).Outputs {
+					if _, err := util.ConvertCSVToBinary(filepath.Join(outputDir, o)); err != nil {
+						saveManifest(manifest)
+						log.Fatal(errors.Wrapf(err, "Error converting %+v to binary", o))
+					}
+				}
+			}
+		}
+		saveManifest(manifest)
+		if len(overlaps) > 0 {
+			log.Fatal(errors.Errorf("Imported %d added and %d replaced sources, but not %s whose quote dates overlap with imported sources, use force to import them anyway",
+				counts[util.ImportAdded], counts[util.ImportReplaced], strings.Join(overlaps, ", ")))
 		}
-		now := time.Now().Format(time.RFC3339)
-		file, err := os.OpenFile(outputDir+"/"+now+".csv", os.O_WRONLY|os.O_CREATE, 0666)
+		log.Infof("Successfully finished importing: %d added, %d replaced, %d skipped",
+			counts[util.ImportAdded],
+			counts[util.ImportReplaced],
+			counts[util.ImportSkipped])
+	},
+}
+
+// saveManifest saves the manifest and exits if it fails
+func saveManifest(m *util.Manifest) {
+	if err := m.Save(); err != nil {
+		log.Fatal(errors.Wrap(err, "Error saving import manifest"))
+	}
+}
+
+// importToFile imports everything into a single output file without consulting the manifest
+func importToFile(vendor, importPath string, isDir bool, output string) {
+	var imp util.Importer
+	if isDir {
+		log.Debugf("Running import on %s data from dir: %+v", vendor, importPath)
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 		if err != nil {
-			log.Fatal(errors.Wrap(err, "Error opening options.csv"))
+			log.Fatal(errors.Wrapf(err, "Error opening %+v", output))
 		}
+		defer file.Close()
 		w := csv.NewWriter(file)
 		defer w.Flush()
-		if err := util.NewLiveVolImporter(w).ImportFolder(importDir); err != nil {
-			log.Fatal(errors.Wrapf(err, "Error importing from folder %+v", importDir))
+		imp, _ = util.NewImporter(vendor, w)
+		if err := imp.ImportFolder(importPath); err != nil {
+			log.Fatal(errors.Wrapf(err, "Error importing from folder %+v", importPath))
 		}
-		log.Info("Successfully finished importing")
-	},
+	} else {
+		log.Debugf("Running import on %s data from file: %+v", vendor, importPath)
+		imp, _ = util.NewImporter(vendor, nil)
+		if err := imp.ImportFile(importPath, output); err != nil {
+			log.Fatal(errors.Wrapf(err, "Error importing from file %+v", importPath))
+		}
+	}
+	stats := imp.Stats()
+	log.Infof("Successfully finished importing %d rows from %d files into %+v, skipped %d rows",
+		stats.Rows,
+		stats.Files,
+		output,
+		stats.Skipped)
+}
+
+func init() {
+	importCmd.Flags().String("vendor", "livevol", "Data vendor of the source files, one of livevol, cboe, jpx or normalized (Default: livevol)")
+	importCmd.Flags().String("output", "", "Write everything into this single file instead of the data dir, without using the import manifest")
+	importCmd.Flags().Bool("force", false, "Re-import sources even if the import manifest shows they were already imported, and import sources whose quote dates overlap with other imported sources of the same symbol")
+	importCmd.Flags().Bool("binary", false, "Also write a binary copy of every imported partition, which the strategy command loads faster")
 }
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"backtest-options/util"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var importActionsCmd = &cobra.Command{
+	Use:   "import-actions",
+	Short: "import-actions imports a dividend and split csv of an underlying into the data dir",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires import file argument")
+		}
+		if cmd.Flag("symbol").Value.String() == "" {
+			return errors.New("requires the symbol flag")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		symbol := cmd.Flag("symbol").Value.String()
+		n, err := util.ImportCorporateActions(args[0], dataDir, symbol)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "Error importing %+v", args[0]))
+		}
+		log.Infof("Successfully imported %d corporate actions of %s into %+v", n, symbol, util.CorporateActionsPath(dataDir, symbol))
+	},
+}
+
+func init() {
+	importActionsCmd.Flags().String("symbol", "", "Underlying symbol of the dividends and splits, matching the underlying symbol of the option data")
+}
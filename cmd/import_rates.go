@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"backtest-options/util"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var importRatesCmd = &cobra.Command{
+	Use:   "import-rates",
+	Short: "import-rates imports a daily risk-free rate curve csv, e.g. Treasury yields by tenor, into the data dir",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires import file argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		n, err := util.ImportRates(args[0], dataDir)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "Error importing %+v", args[0]))
+		}
+		log.Infof("Successfully imported %d rates into %+v", n, util.RatesPath(dataDir))
+	},
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"backtest-options/util"
 	"fmt"
 	"os"
 
@@ -18,6 +19,18 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("dataDir", "./data", "Data directory to import into and read from (Default: ./data)")
+	rootCmd.PersistentFlags().String("dataset", "", "Name of a dataset of the catalog to use as the data directory instead of dataDir")
+	rootCmd.PersistentFlags().String("catalog", util.CatalogFileName, "Dataset catalog file (Default: datasets.json)")
+
 	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(importUnderlyingCmd)
+	rootCmd.AddCommand(importActionsCmd)
+	rootCmd.AddCommand(importRatesCmd)
+	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(datasetsCmd)
+	rootCmd.AddCommand(volSurfaceCmd)
 	rootCmd.AddCommand(getStrategyCmd())
 }
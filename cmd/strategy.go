@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"backtest-options/calendar"
 	"backtest-options/model"
 	"backtest-options/strategy"
 	"backtest-options/util"
+	"bufio"
 	"encoding/csv"
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -40,15 +42,50 @@ func getStrategyCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			log.Infof("Starting Covered call strategy")
 
-			chain, err := loadOHLCV()
+			snapshot, err := getSnapshot(cmd)
 			if err != nil {
-				log.Fatal("Failed to make option chain")
+				log.Fatal(err)
+			}
+			settlement, err := getSettlement(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			tradingDays, _ := cmd.Flags().GetBool("tradingDays")
+			start, end, err := getWindow(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			callStrike, err := getStrikeTarget(cmd, "call")
+			if err != nil {
+				log.Fatal(err)
+			}
+			entryIV, tenor, lookback, err := getIVOpts(cmd)
+			if err != nil {
+				log.Fatal(err)
 			}
 
 			opts := model.StrategyOpts{
-				ExecMethod: model.ExecMethodCrossSpread,
-				MinExpDays: 28,
-				StartDate:  time.Time{},
+				Calendar:      cal,
+				CallStrike:    callStrike,
+				EndDate:       end,
+				EntryIV:       entryIV,
+				ExecMethod:    model.ExecMethodCrossSpread,
+				IVLookback:    lookback,
+				IVTenorDays:   tenor,
+				MinExpDays:    28,
+				Settlement:    settlement,
+				Snapshot:      snapshot,
+				StartDate:     start,
+				TradingDayDTE: tradingDays,
+			}
+
+			chain, err := loadOHLCV(cmd, opts)
+			if err != nil {
+				log.Fatal(errors.Wrap(err, "Failed to make option chain"))
 			}
 
 			cc(chain, opts)
@@ -75,10 +112,52 @@ func getStrategyCmd() *cobra.Command {
 				log.Fatal(errors.Wrapf(err, "Error parsing minPutDTE: %+v", pexpd.Value.String()))
 			}
 
+			snapshot, err := getSnapshot(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			settlement, err := getSettlement(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			tradingDays, _ := cmd.Flags().GetBool("tradingDays")
+			start, end, err := getWindow(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			callStrike, err := getStrikeTarget(cmd, "call")
+			if err != nil {
+				log.Fatal(err)
+			}
+			entryIV, tenor, lookback, err := getIVOpts(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			putStrike, err := getStrikeTarget(cmd, "put")
+			if err != nil {
+				log.Fatal(err)
+			}
+			surfacePricing, _ := cmd.Flags().GetBool("surfacePricing")
+
 			opts := model.StrategyOpts{
-				ExecMethod: model.ExecMethodCrossSpread,
-				MinExpDays: 28,
-				StartDate:  time.Time{},
+				Calendar:       cal,
+				CallStrike:     callStrike,
+				EndDate:        end,
+				EntryIV:        entryIV,
+				ExecMethod:     model.ExecMethodCrossSpread,
+				IVLookback:     lookback,
+				IVTenorDays:    tenor,
+				MinExpDays:     28,
+				PutStrike:      putStrike,
+				Settlement:     settlement,
+				Snapshot:       snapshot,
+				StartDate:      start,
+				SurfacePricing: surfacePricing,
+				TradingDayDTE:  tradingDays,
 				PipOpts: &model.PipOpts{
 					MinCallExpDTE: int(cexp.IntPart()),
 					MinPutExpDTE:  int(pexp.IntPart()),
@@ -87,9 +166,9 @@ func getStrategyCmd() *cobra.Command {
 				},
 			}
 
-			chain, err := loadOHLCV()
+			chain, err := loadOHLCV(cmd, opts)
 			if err != nil {
-				log.Fatal("Failed to make option chain")
+				log.Fatal(errors.Wrap(err, "Failed to make option chain"))
 			}
 
 			pip(chain, opts)
@@ -99,6 +178,23 @@ func getStrategyCmd() *cobra.Command {
 	}
 	pipCmd.Flags().String("minCallDTE", "4", "Minimum number of DTE for the call option (Default 4)")
 	pipCmd.Flags().String("minPutDTE", "150", "Minimum number of DTE for the put option (Default: 150)")
+	pipCmd.Flags().String("putDelta", "", "Buy the put with the absolute delta nearest to this, e.g. 0.1 for the 10 delta put (Default: the put at the underlying price)")
+	pipCmd.Flags().String("putPremium", "", "Buy the put with the mid price nearest to this")
+	pipCmd.Flags().Bool("surfacePricing", false, "Close the put from the volatility surface of the quote date if its strike or expiry is missing, instead of at the nearest listed put (Default: false)")
+	pipCmd.Flags().String("putOTM", "", "Buy the put nearest to this fraction below the underlying price, e.g. 0.1 for 10% out of the money")
+
+	addChainFlags(strategyCmd, true)
+	strategyCmd.PersistentFlags().String("calendar", "nyse", "Trading calendar of the exchange, either nyse or jpx, used to report quotes missing on its trading days (Default: nyse)")
+	strategyCmd.PersistentFlags().Bool("tradingDays", false, "Count DTE flags in trading days of the calendar instead of calendar days (Default: false)")
+	strategyCmd.PersistentFlags().String("settlement", "auto", "Settlement of expiring options, either am at the opening price of the underlying price history, pm at the close, or auto to settle AM settled index roots such as SPX at the open (Default: auto)")
+	strategyCmd.PersistentFlags().String("callDelta", "", "Sell the call with the delta nearest to this, e.g. 0.3 for the 30 delta call (Default: the call at the underlying price)")
+	strategyCmd.PersistentFlags().String("callPremium", "", "Sell the call with the mid price nearest to this")
+	strategyCmd.PersistentFlags().String("callOTM", "", "Sell the call nearest to this fraction above the underlying price, e.g. 0.05 for 5% out of the money")
+	strategyCmd.PersistentFlags().Int("ivTenor", 30, "Constant maturity in calendar days of the at the money implied volatility that IV rank and percentile are computed from (Default: 30)")
+	strategyCmd.PersistentFlags().String("minIVRank", "", "Open positions only on quote dates whose IV rank is at least this, from 0 to 100")
+	strategyCmd.PersistentFlags().String("maxIVRank", "", "Open positions only on quote dates whose IV rank is at most this, from 0 to 100")
+	strategyCmd.PersistentFlags().String("minIVPercentile", "", "Open positions only on quote dates whose IV percentile is at least this, from 0 to 100")
+	strategyCmd.PersistentFlags().String("maxIVPercentile", "", "Open positions only on quote dates whose IV percentile is at most this, from 0 to 100")
 
 	strategyCmd.AddCommand(pipCmd)
 	strategyCmd.AddCommand(ccCmd)
@@ -106,43 +202,312 @@ func getStrategyCmd() *cobra.Command {
 	return strategyCmd
 }
 
-func loadOHLCV() (*model.OptChainList, error) {
-	dataDir := "./data"
-	files, err := ioutil.ReadDir(dataDir)
+// addChainFlags registers the flags that the option chain is loaded with on cmd, as persistent flags for its subcommands if persistent is true
+func addChainFlags(cmd *cobra.Command, persistent bool) {
+	flags := cmd.Flags()
+	if persistent {
+		flags = cmd.PersistentFlags()
+	}
+	flags.String("symbol", "", "Underlying symbol to run on. Required if the data has more than one underlying")
+	flags.String("root", "", "Option root to run on, e.g. SPXW. Required if the underlying has more than one root")
+	flags.String("start", "", "First quote date to open positions on or report, formatted as 2006-01-02. Data quoted before it is not loaded, except for the IV lookback where it is needed (Default: the first quote date)")
+	flags.String("end", "", "Last quote date to open positions on or report, formatted as 2006-01-02. Data quoted after it is only loaded as far as positions open on it need to settle (Default: the last quote date)")
+	flags.Bool("quarantine", false, "Drop rows with zero or crossed quotes and duplicate rows while loading (Default: false)")
+	flags.Bool("cache", true, "Load the option chain from a cache in .cache of the data dir, which is rebuilt whenever the data changes (Default: true)")
+	flags.String("riskFreeRate", "0", "Constant annual risk-free rate as a fraction, e.g. 0.02, used if no rate curve was imported or before its first date (Default: 0)")
+	flags.Int("ivLookback", 252, "Number of quote dates IV rank and percentile are over (Default: 252)")
+	flags.String("snapshot", "eod", "Quote snapshot used for fills, implied volatilities and the underlying price, either eod or 1545 (Default: eod)")
+}
+
+// getSnapshot parses the snapshot flag
+func getSnapshot(cmd *cobra.Command) (model.Snapshot, error) {
+	v := cmd.Flag("snapshot").Value.String()
+	snapshot, err := model.ParseSnapshot(v)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error reading dir: %+v", dataDir)
+		return snapshot, errors.Wrapf(err, "Error parsing snapshot: %+v", v)
 	}
-	log.Infof("Reading files from %+v", dataDir)
-	ohlcvs := make([]model.OHLCV, 0)
-	for _, file := range files {
-		f, err := os.Open(dataDir + "/" + file.Name())
+	return snapshot, nil
+}
+
+// getSettlement parses the settlement flag
+func getSettlement(cmd *cobra.Command) (model.Settlement, error) {
+	v := cmd.Flag("settlement").Value.String()
+	settlement, err := model.ParseSettlement(v)
+	if err != nil {
+		return settlement, errors.Wrapf(err, "Error parsing settlement: %+v", v)
+	}
+	return settlement, nil
+}
+
+// getCalendar returns the trading calendar of the calendar flag
+func getCalendar(cmd *cobra.Command) (*calendar.Calendar, error) {
+	v := cmd.Flag("calendar").Value.String()
+	cal, err := calendar.ForName(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing calendar: %+v", v)
+	}
+	return cal, nil
+}
+
+// getStrikeTarget parses the delta, premium and OTM flags of the call or put leg
+func getStrikeTarget(cmd *cobra.Command, leg string) (model.StrikeTarget, error) {
+	target := model.StrikeTarget{}
+	values := []struct {
+		name string
+		dst  *decimal.Decimal
+	}{
+		{leg + "Delta", &target.Delta},
+		{leg + "Premium", &target.Premium},
+		{leg + "OTM", &target.OTMPct},
+	}
+	for _, v := range values {
+		s := cmd.Flag(v.name).Value.String()
+		if s == "" {
+			continue
+		}
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return target, errors.Wrapf(err, "Error parsing %s: %+v", v.name, s)
+		}
+		*v.dst = d
+	}
+	if err := target.Validate(); err != nil {
+		return target, errors.Wrapf(err, "Error validating %s strike flags", leg)
+	}
+	return target, nil
+}
+
+// getIVOpts parses the IV entry range and the tenor and lookback of IV rank and percentile
+func getIVOpts(cmd *cobra.Command) (model.IVRange, int, int, error) {
+	r := model.IVRange{}
+	// an explicit maximum of 0 is kept, unlike an unset one
+	values := []struct {
+		name string
+		dst  *decimal.Decimal
+		set  *bool
+	}{
+		{"minIVRank", &r.MinRank, nil},
+		{"maxIVRank", &r.MaxRank, &r.HasMaxRank},
+		{"minIVPercentile", &r.MinPercentile, nil},
+		{"maxIVPercentile", &r.MaxPercentile, &r.HasMaxPercentile},
+	}
+	for _, v := range values {
+		s := cmd.Flag(v.name).Value.String()
+		if !cmd.Flags().Changed(v.name) || s == "" {
+			continue
+		}
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return r, 0, 0, errors.Wrapf(err, "Error parsing %s: %+v", v.name, s)
+		}
+		*v.dst = d
+		if v.set != nil {
+			*v.set = true
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return r, 0, 0, errors.Wrap(err, "Error validating IV entry flags")
+	}
+	tenor, _ := cmd.Flags().GetInt("ivTenor")
+	lookback, _ := cmd.Flags().GetInt("ivLookback")
+	if tenor < 1 || lookback < 1 {
+		return r, 0, 0, errors.Errorf("Expected ivTenor and ivLookback to be positive but got %d and %d", tenor, lookback)
+	}
+	return r, tenor, lookback, nil
+}
+
+// getDate parses an optional date flag
+func getDate(cmd *cobra.Command, name string) (time.Time, error) {
+	v := cmd.Flag(name).Value.String()
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(model.DateLayout, v)
+	if err != nil {
+		return t, errors.Wrapf(err, "Error parsing %s: %+v", name, v)
+	}
+	return t, nil
+}
+
+// getWindow parses the start and end flags, and checks that start is not after end
+func getWindow(cmd *cobra.Command) (time.Time, time.Time, error) {
+	start, err := getDate(cmd, "start")
+	if err != nil {
+		return start, time.Time{}, err
+	}
+	end, err := getDate(cmd, "end")
+	if err != nil {
+		return start, end, err
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return start, end, errors.Errorf("Expected start %+v to be on or before end %+v", start.Format(model.DateLayout), end.Format(model.DateLayout))
+	}
+	return start, end, nil
+}
+
+// loadOHLCV builds the option chain of the symbol and root flags from the data quoted between the IV history before the start date and the lookahead after the end date of opts, or loads it from the cache if that data has not changed
+func loadOHLCV(cmd *cobra.Command, opts model.StrategyOpts) (*model.OptChainList, error) {
+	dataDir, err := getDataDir(cmd)
+	if err != nil {
+		return nil, err
+	}
+	start := opts.StartDate
+	if !start.IsZero() {
+		start = start.AddDate(0, 0, -opts.HistoryDays())
+	}
+	end := opts.EndDate
+	if !end.IsZero() {
+		end = end.AddDate(0, 0, opts.LookaheadDays())
+	}
+	riskFree, err := decimal.NewFromString(cmd.Flag("riskFreeRate").Value.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing riskFreeRate: %+v", cmd.Flag("riskFreeRate").Value.String())
+	}
+	symbol := cmd.Flag("symbol").Value.String()
+	root := cmd.Flag("root").Value.String()
+	files, err := util.PartitionFiles(dataDir, symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+	useCache, _ := cmd.Flags().GetBool("cache")
+	var key string
+	if useCache {
+		manifest, err := util.LoadManifest(filepath.Join(dataDir, util.ManifestFileName))
 		if err != nil {
-			return nil, errors.Wrap(err, "Error opening options.csv")
+			return nil, err
 		}
-		csvr := csv.NewReader(f)
-		ohlcv, err := util.NewFileReader().ReadNormalizedCSVFile(csvr)
+		key, err = util.ChainCacheKey(manifest, dataDir, files,
+			"symbol:"+symbol,
+			"root:"+root,
+			"quarantine:"+cmd.Flag("quarantine").Value.String(),
+			"start:"+start.Format(model.DateLayout),
+			"end:"+end.Format(model.DateLayout))
 		if err != nil {
-			return nil, errors.Wrap(err, "Error opening options.csv")
+			return nil, err
+		}
+		chain, ok, err := util.LoadChainCache(dataDir, key)
+		if err != nil {
+			log.Warn(errors.Wrap(err, "Ignoring option chain cache"))
+		} else if ok {
+			log.Infof("Loaded option chain of %d files from cache", len(files))
+			return withReferenceData(dataDir, chain, riskFree, opts)
 		}
-		ohlcvs = append(ohlcvs, ohlcv...)
 	}
-	if len(ohlcvs) == 0 {
-		return nil, errors.Wrap(err, "Could not find any valid data")
+	log.Infof("Reading %d files from %+v", len(files), dataDir)
+	var quarantine *util.Quarantine
+	if q, _ := cmd.Flags().GetBool("quarantine"); q {
+		quarantine = util.NewQuarantine()
+	}
+	builder := model.NewOptionChainBuilder()
+	rows := 0
+	for _, file := range files {
+		if err := streamOHLCVFile(file, func(ohlcv model.OHLCV) error {
+			if (symbol != "" && ohlcv.UndSym != symbol) || (root != "" && model.NewChainKey(ohlcv.UndSym, ohlcv.Root).Root != root) {
+				return nil
+			}
+			if ohlcv.QuoteDate.Before(start) || (!end.IsZero() && ohlcv.QuoteDate.After(end)) {
+				return nil
+			}
+			if quarantine != nil && !quarantine.Allow(ohlcv) {
+				return nil
+			}
+			builder.Add(ohlcv)
+			rows++
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if rows == 0 {
+		return nil, errors.New("Could not find any valid data")
+	}
+	log.Infof("Generated options chain from %d rows", rows)
+	if quarantine != nil {
+		log.Infof("Quarantined rows: %+v", quarantine.Dropped)
 	}
-	log.Infof("Generated options chain")
 
-	chain, err := model.NewOptionChain(ohlcvs)
+	set, err := builder.BuildSet()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to make option chain")
 	}
+	chain, err := set.Select(symbol, root)
+	if err != nil {
+		return nil, err
+	}
+	if useCache {
+		if err := util.SaveChainCache(dataDir, key, chain); err != nil {
+			log.Warn(errors.Wrap(err, "Error saving option chain cache"))
+		}
+	}
+	return withReferenceData(dataDir, chain, riskFree, opts)
+}
+
+// withReferenceData attaches the imported price history and corporate actions of the chain's underlying, if there are any, and the risk-free rate curve, and then computes implied volatilities, greeks and IV stats at the snapshot of opts. riskFree is the rate used if no curve was imported, or before its first date.
+func withReferenceData(dataDir string, chain *model.OptChainList, riskFree decimal.Decimal, opts model.StrategyOpts) (*model.OptChainList, error) {
+	series, ok, err := util.LoadUnderlying(dataDir, chain.Key().UndSym)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		log.Infof("Loaded %d underlying bars of %s", len(series.Bars()), series.Symbol)
+		chain.SetUnderlying(series)
+	}
+	actions, ok, err := util.LoadCorporateActions(dataDir, chain.Key().UndSym)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		log.Infof("Loaded %d corporate actions of %s", len(actions.Actions()), actions.Symbol)
+		chain.SetCorporateActions(actions)
+	}
+	rates, ok, err := util.LoadRates(dataDir, riskFree)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		log.Infof("Loaded %d risk-free rates", len(rates.Points()))
+	} else if rates, err = model.NewRateCurve(nil, riskFree); err != nil {
+		return nil, err
+	}
+	chain.SetRateCurve(rates)
+	chain.ComputeGreeks(opts.Snapshot)
+	chain.ComputeIVStats(opts.Snapshot, opts.IVTenorDays, opts.IVLookback)
 	return chain, nil
 }
 
+// streamOHLCVFile reads a normalized csv file one row at a time. Its binary copy is read instead if it is up to date.
+func streamOHLCVFile(path string, fn func(model.OHLCV) error) error {
+	if bin, ok := util.FreshBinaryPath(path); ok {
+		f, err := os.Open(bin)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening %+v", bin)
+		}
+		defer f.Close()
+		if err := util.StreamBinaryFile(f, fn); err != nil {
+			return errors.Wrapf(err, "Error reading %+v", bin)
+		}
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening %+v", path)
+	}
+	defer f.Close()
+	csvr := csv.NewReader(bufio.NewReader(f))
+	if err := util.NewFileReader().StreamNormalizedCSVFile(csvr, fn); err != nil {
+		return errors.Wrapf(err, "Error reading %+v", path)
+	}
+	return nil
+}
+
 func cc(chain *model.OptChainList, opts model.StrategyOpts) {
 	s, err := strategy.NewCoveredCallStrategy(chain)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Error creating new strategy"))
 	}
+	if err := s.Validate(opts); err != nil {
+		log.Fatal(errors.Wrap(err, "Error validating strategy options"))
+	}
 	log.Infof("Starting strategy with opts %+v", opts)
 	result, err := s.Run(opts)
 	if err != nil {
@@ -158,6 +523,9 @@ func pip(chain *model.OptChainList, opts model.StrategyOpts) {
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Error creating new strategy"))
 	}
+	if err := s.Validate(opts); err != nil {
+		log.Fatal(errors.Wrap(err, "Error validating strategy options"))
+	}
 	log.Infof("Starting strategy with opts %+v", opts)
 	result, err := s.Run(opts)
 	if err != nil {
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"backtest-options/model"
+	"backtest-options/util"
+	"os"
+
+	"github.com/shopspring/decimal"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pkg/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate-data",
+	Short: "validate-data reports data quality problems in the data dir",
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, err := getDataDir(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start, err := getDate(cmd, "start")
+		if err != nil {
+			log.Fatal(err)
+		}
+		end, err := getDate(cmd, "end")
+		if err != nil {
+			log.Fatal(err)
+		}
+		jumpv := cmd.Flag("maxJump").Value.String()
+		maxJump, err := decimal.NewFromString(jumpv)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "Error parsing maxJump: %+v", jumpv))
+		}
+		symbol := cmd.Flag("symbol").Value.String()
+
+		files, err := util.PartitionFiles(dataDir, symbol, start, end)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cal, err := getCalendar(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		v := util.NewValidator(maxJump, cal)
+		for _, file := range files {
+			if err := streamOHLCVFile(file, func(ohlcv model.OHLCV) error {
+				if symbol != "" && ohlcv.UndSym != symbol {
+					return nil
+				}
+				if (!start.IsZero() && ohlcv.QuoteDate.Before(start)) || (!end.IsZero() && ohlcv.QuoteDate.After(end)) {
+					return nil
+				}
+				v.Add(ohlcv)
+				return nil
+			}); err != nil {
+				log.Fatal(err)
+			}
+		}
+		report := v.Report()
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			if err := report.OutputJSON(os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		report.OutputTable(os.Stdout, limit)
+	},
+}
+
+func init() {
+	validateCmd.Flags().String("symbol", "", "Only validate data of this underlying symbol (Default: all symbols)")
+	validateCmd.Flags().String("start", "", "Only validate data quoted on or after this date, formatted as 2006-01-02 (Default: all data)")
+	validateCmd.Flags().String("end", "", "Only validate data quoted on or before this date, formatted as 2006-01-02 (Default: all data)")
+	validateCmd.Flags().String("maxJump", "0.2", "Largest day over day underlying price change, as a fraction, that is not reported (Default: 0.2)")
+	validateCmd.Flags().String("calendar", "nyse", "Trading calendar used to tell missing days from holidays, either nyse or jpx (Default: nyse)")
+	validateCmd.Flags().Bool("json", false, "Write the full report as JSON instead of tables")
+	validateCmd.Flags().Int("limit", 50, "Number of issues listed in the table, -1 for all of them (Default: 50)")
+}
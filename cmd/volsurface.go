@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"backtest-options/model"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	cobra "github.com/spf13/cobra"
+)
+
+var volSurfaceCmd = &cobra.Command{
+	Use:   "volsurface",
+	Short: "volsurface reports the at the money implied volatility, IV rank, skew and term structure of every quote date, or the volatility surface of a single quote date",
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := getSnapshot(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start, end, err := getWindow(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		date, err := getDate(cmd, "date")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !date.IsZero() {
+			start, end = date, date
+		}
+		flags := cmd.Flags()
+		tenor, _ := flags.GetInt("tenor")
+		termTenor, _ := flags.GetInt("termTenor")
+		lookback, _ := flags.GetInt("ivLookback")
+		if tenor < 1 || termTenor < 1 || lookback < 1 {
+			log.Fatal(errors.Errorf("Expected tenor, termTenor and ivLookback to be positive but got %d, %d and %d", tenor, termTenor, lookback))
+		}
+		skewWidth, err := strconv.ParseFloat(cmd.Flag("skewWidth").Value.String(), 64)
+		if err != nil || skewWidth <= 0 || skewWidth >= 1 {
+			log.Fatal(errors.Errorf("Expected skewWidth to be between 0 and 1 but got %+v", cmd.Flag("skewWidth").Value.String()))
+		}
+
+		// IV rank and percentile of the first quote dates are over the lookback before them
+		from := start
+		if !from.IsZero() {
+			from = from.AddDate(0, 0, -model.IVHistoryDays(lookback))
+		}
+		opts := model.StrategyOpts{
+			EndDate:     end,
+			IVLookback:  lookback,
+			IVTenorDays: tenor,
+			Snapshot:    snapshot,
+			StartDate:   from,
+		}
+		chain, err := loadOHLCV(cmd, opts)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Failed to make option chain"))
+		}
+		if err := chain.ValidateSnapshot(snapshot); err != nil {
+			log.Fatal(errors.Wrap(err, "Error validating the snapshot, use the eod snapshot for data without 15:45 quotes"))
+		}
+
+		if !date.IsZero() {
+			quote := chain.GetOptionChainForQuoteDate(date, true)
+			if quote == nil {
+				log.Fatal(errors.Errorf("No quotes on %+v", date.Format(model.DateLayout)))
+			}
+			moneyness, _ := flags.GetFloat64Slice("moneyness")
+			if err := outputVolSurface(quote, snapshot, moneyness); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		data := [][]string{}
+		for _, m := range chain.VolMetrics(snapshot, tenor, termTenor, skewWidth) {
+			if m.QuoteDate.Before(start) || (!end.IsZero() && m.QuoteDate.After(end)) {
+				continue
+			}
+			quote := chain.GetOptionChainForQuoteDate(m.QuoteDate, true)
+			data = append(data, []string{
+				m.QuoteDate.Format(model.DateLayout),
+				quote.UndPxAt(snapshot).String(),
+				m.ATM.StringFixed(4),
+				quote.IV.Rank.StringFixed(2),
+				quote.IV.Percentile.StringFixed(2),
+				strconv.Itoa(quote.IV.Days),
+				m.Skew.StringFixed(4),
+				m.Term.StringFixed(4),
+			})
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{
+			"Quote Date",
+			"Underlying Price",
+			fmt.Sprintf("ATM IV %dD", tenor),
+			"IV Rank",
+			"IV Percentile",
+			"Rank Days",
+			fmt.Sprintf("Skew %s%%", decimal.NewFromFloat(skewWidth).Mul(decimal.NewFromInt(100)).String()),
+			fmt.Sprintf("Term %dD-%dD", termTenor, tenor),
+		})
+		table.AppendBulk(data)
+		table.Render()
+	},
+}
+
+// outputVolSurface writes the implied volatility of the volatility surface of the quote at each expiry and moneyness
+func outputVolSurface(quote *model.OptChain, snapshot model.Snapshot, moneyness []float64) error {
+	v := quote.VolSurface(snapshot)
+	if v == nil {
+		return errors.Errorf("Expected implied volatilities on %+v but got none", quote.QuoteDate.Format(model.DateLayout))
+	}
+	header := []string{"Expiry", "DTE"}
+	for _, m := range moneyness {
+		header = append(header, decimal.NewFromFloat(m).Mul(decimal.NewFromInt(100)).String()+"%")
+	}
+	data := [][]string{}
+	for _, exp := range v.Expiries() {
+		days := int(exp.Sub(quote.QuoteDate).Hours() / 24)
+		row := []string{exp.Format(model.DateLayout), strconv.Itoa(days)}
+		for _, m := range moneyness {
+			row = append(row, strconv.FormatFloat(v.MoneynessIV(m, days), 'f', 4, 64))
+		}
+		data = append(data, row)
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func init() {
+	addChainFlags(volSurfaceCmd, false)
+	volSurfaceCmd.Flags().String("date", "", "Quote date to write the volatility surface of instead of the metrics of every quote date, formatted as 2006-01-02")
+	volSurfaceCmd.Flags().Float64Slice("moneyness", []float64{0.8, 0.9, 0.95, 1, 1.05, 1.1, 1.2}, "Strikes over the underlying price that the volatility surface of date is written at")
+	volSurfaceCmd.Flags().Int("tenor", 30, "Calendar days to expiry of the at the money implied volatility, IV rank and skew")
+	volSurfaceCmd.Flags().Int("termTenor", 90, "Calendar days to expiry of the implied volatility the term structure compares with the tenor")
+	volSurfaceCmd.Flags().String("skewWidth", "0.1", "Distance of the skew strikes from the money as a fraction of the underlying price, e.g. 0.1 for the 90% strike minus the 110% strike")
+}
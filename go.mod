@@ -8,5 +8,6 @@ require (
 	github.com/shopspring/decimal v1.2.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.1.3
+	golang.org/x/text v0.3.7
 	google.golang.org/appengine v1.6.1
 )
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// CorporateActionType is the type of a corporate action
+type CorporateActionType string
+
+const (
+	// ActionDividend is a cash dividend
+	ActionDividend CorporateActionType = "dividend"
+	// ActionSplit is a stock split or reverse split
+	ActionSplit CorporateActionType = "split"
+)
+
+// CorporateAction is a dividend or split of an underlying
+type CorporateAction struct {
+	// ExDate is the first date the underlying trades without the dividend, or at the split adjusted price
+	ExDate time.Time
+	// Type is the type of action
+	Type CorporateActionType
+	// Value is the cash paid per share for a dividend, or the number of new shares per old share for a split, e.g. 2 for a 2:1 split and 0.1 for a 1:10 reverse split
+	Value decimal.Decimal
+}
+
+// CorporateActions is the dividend and split calendar of an underlying
+type CorporateActions struct {
+	// Symbol is the underlying symbol
+	Symbol  string
+	actions []CorporateAction
+}
+
+// NewCorporateActions creates a calendar from actions in any order. If two actions have the same ex-date and type, the later one wins
+func NewCorporateActions(symbol string, actions []CorporateAction) (*CorporateActions, error) {
+	type key struct {
+		date time.Time
+		typ  CorporateActionType
+	}
+	byKey := make(map[key]CorporateAction, len(actions))
+	for _, a := range actions {
+		if a.Type != ActionDividend && a.Type != ActionSplit {
+			return nil, errors.Errorf("Unsupported corporate action type %+v", a.Type)
+		}
+		if !a.Value.IsPositive() {
+			return nil, errors.Errorf("Expected corporate action value to be positive but got %+v", a)
+		}
+		byKey[key{a.ExDate, a.Type}] = a
+	}
+	c := &CorporateActions{
+		Symbol:  symbol,
+		actions: make([]CorporateAction, 0, len(byKey)),
+	}
+	for _, a := range byKey {
+		c.actions = append(c.actions, a)
+	}
+	sort.Slice(c.actions, func(i, j int) bool {
+		if !c.actions[i].ExDate.Equal(c.actions[j].ExDate) {
+			return c.actions[i].ExDate.Before(c.actions[j].ExDate)
+		}
+		return c.actions[i].Type < c.actions[j].Type
+	})
+	return c, nil
+}
+
+// Actions returns every action in ascending order of ex-date
+func (c *CorporateActions) Actions() []CorporateAction {
+	return c.actions
+}
+
+// SplitFactor returns the number of shares held at close for every share held at open, from splits with an ex-date after open and on or before close. It is 1 if there were no splits.
+func (c *CorporateActions) SplitFactor(open, close time.Time) decimal.Decimal {
+	factor := decimal.NewFromInt(1)
+	for _, a := range c.actions {
+		if a.Type == ActionSplit && a.ExDate.After(open) && !a.ExDate.After(close) {
+			factor = factor.Mul(a.Value)
+		}
+	}
+	return factor
+}
+
+// Dividends returns the cash received for every share held at open, from dividends with an ex-date after open and on or before close. Dividends after a split are paid on the split adjusted number of shares.
+func (c *CorporateActions) Dividends(open, close time.Time) decimal.Decimal {
+	factor := decimal.NewFromInt(1)
+	total := decimal.Zero
+	for _, a := range c.actions {
+		if !a.ExDate.After(open) || a.ExDate.After(close) {
+			continue
+		}
+		switch a.Type {
+		case ActionSplit:
+			factor = factor.Mul(a.Value)
+		case ActionDividend:
+			total = total.Add(a.Value.Mul(factor))
+		}
+	}
+	return total
+}
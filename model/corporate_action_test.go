@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestCorporateActions(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(DateLayout, s)
+		return d
+	}
+	c, err := NewCorporateActions("AAPL", []CorporateAction{
+		{ExDate: parse("2020-08-31"), Type: ActionSplit, Value: decimal.NewFromInt(4)},
+		{ExDate: parse("2020-08-07"), Type: ActionDividend, Value: decimal.NewFromFloat(0.82)},
+		{ExDate: parse("2020-11-06"), Type: ActionDividend, Value: decimal.NewFromFloat(0.205)},
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating corporate actions"))
+	}
+	if len(c.Actions()) != 3 || !c.Actions()[0].ExDate.Equal(parse("2020-08-07")) {
+		t.Errorf("Expected actions sorted by ex-date but got %+v", c.Actions())
+	}
+
+	tests := []struct {
+		open      string
+		close     string
+		split     string
+		dividends string
+	}{
+		{"2020-08-01", "2020-08-06", "1", "0"},
+		// the ex-date of the open date is not received
+		{"2020-08-07", "2020-08-30", "1", "0"},
+		{"2020-08-06", "2020-08-07", "1", "0.82"},
+		// dividends after the split are paid on 4 times the shares
+		{"2020-08-01", "2020-12-01", "4", "1.64"},
+		{"2020-09-01", "2020-12-01", "1", "0.205"},
+	}
+	for idx, test := range tests {
+		split := c.SplitFactor(parse(test.open), parse(test.close))
+		if !split.Equal(decimal.RequireFromString(test.split)) {
+			t.Errorf("Expected split factor to be %+v but got %+v at idx: %d", test.split, split, idx)
+		}
+		div := c.Dividends(parse(test.open), parse(test.close))
+		if !div.Equal(decimal.RequireFromString(test.dividends)) {
+			t.Errorf("Expected dividends to be %+v but got %+v at idx: %d", test.dividends, div, idx)
+		}
+	}
+
+	if _, err := NewCorporateActions("AAPL", []CorporateAction{{ExDate: parse("2020-08-31"), Type: ActionSplit}}); err == nil {
+		t.Errorf("Expected an error for a split without a ratio")
+	}
+}
@@ -30,3 +30,12 @@ func (l ExecLegs) GetProfit() (decimal.Decimal, error) {
 	}
 	return tot, nil
 }
+
+// PositionGreeks returns the sum of the greeks of the legs at open
+func (l ExecLegs) PositionGreeks() Greeks {
+	tot := Greeks{}
+	for _, v := range l.Leg {
+		tot = tot.Add(v.PositionGreeks())
+	}
+	return tot
+}
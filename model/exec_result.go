@@ -26,10 +26,16 @@ const (
 
 // ExecOpenClose is open and close exec
 type ExecOpenClose struct {
-	Close   Exec
-	Name    string
-	Open    Exec
-	Product ProductType
+	Close Exec
+	// Dividend is the cash received per opened share of a stock held over ex-dates. It is paid by a short stock position
+	Dividend decimal.Decimal
+	// Greeks are the greeks of one unit of the underlying at open, which are the option's greeks for an option and a delta of 1 for a stock
+	Greeks Greeks
+	// Multiplier is the option contract multiplier. If it is zero, DefaultMultiplier is used
+	Multiplier decimal.Decimal
+	Name       string
+	Open       Exec
+	Product    ProductType
 }
 
 // Exec is a result of execution
@@ -49,8 +55,13 @@ func NewOpenExec(
 	side Side,
 	name string,
 ) *ExecOpenClose {
+	greeks := Greeks{}
+	if product == Stock {
+		greeks.Delta = decimal.NewFromInt(1)
+	}
 	return &ExecOpenClose{
-		Name: name,
+		Greeks: greeks,
+		Name:   name,
 		Open: Exec{
 			Date: date,
 			Px:   px,
@@ -74,6 +85,22 @@ func (e *ExecOpenClose) CloseExec(date time.Time, px decimal.Decimal) {
 	}
 }
 
+// PositionGreeks returns the greeks of the position at open, which are the greeks of one unit scaled by the units held and negative for a short position
+func (e ExecOpenClose) PositionGreeks() Greeks {
+	units := e.Open.Qty
+	if e.Product == Option {
+		mul := e.Multiplier
+		if mul.IsZero() {
+			mul = decimal.NewFromInt(DefaultMultiplier)
+		}
+		units = units.Mul(mul)
+	}
+	if e.Open.Side == Sell {
+		units = units.Neg()
+	}
+	return e.Greeks.Mul(units)
+}
+
 // GetProfit returns profit for this execution
 func (e ExecOpenClose) GetProfit() (decimal.Decimal, error) {
 	diff := decimal.Decimal{}
@@ -91,9 +118,16 @@ func (e ExecOpenClose) GetProfit() (decimal.Decimal, error) {
 
 	switch e.Product {
 	case Stock:
-		return diff.Mul(e.Open.Qty), nil
+		if e.Open.Side == Sell {
+			return diff.Sub(e.Dividend).Mul(e.Open.Qty), nil
+		}
+		return diff.Add(e.Dividend).Mul(e.Open.Qty), nil
 	case Option:
-		return diff.Mul(decimal.NewFromInt(100)).Mul(e.Open.Qty), nil
+		mul := e.Multiplier
+		if mul.IsZero() {
+			mul = decimal.NewFromInt(DefaultMultiplier)
+		}
+		return diff.Mul(mul).Mul(e.Open.Qty), nil
 	default:
 		return diff, errors.Errorf("Unsupported product %+v", e.Product)
 	}
@@ -41,3 +41,88 @@ func TestNewOpenExec(t *testing.T) {
 		t.Error(errors.Errorf("Expected name to be %+v but got %+v", exec.Name, name))
 	}
 }
+
+func TestExecMultiplier(t *testing.T) {
+	tt := []struct {
+		multiplier decimal.Decimal
+		profit     string
+	}{
+		{multiplier: decimal.Decimal{}, profit: "150"},
+		{multiplier: decimal.NewFromInt(1000), profit: "1500"},
+	}
+	for idx, tab := range tt {
+		exec := NewOpenExec(Option, time.Now(), decimal.NewFromFloat(3.5), decimal.NewFromInt(1), Sell, "opt")
+		exec.Multiplier = tab.multiplier
+		exec.CloseExec(time.Now(), decimal.NewFromInt(2))
+		profit, err := exec.GetProfit()
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error getting profit"))
+		}
+		if profit.String() != tab.profit {
+			t.Errorf("Expected profit to be %+v but got %+v at idx: %d", tab.profit, profit, idx)
+		}
+	}
+}
+
+func TestExecDividend(t *testing.T) {
+	tt := []struct {
+		side   Side
+		profit string
+	}{
+		{side: Buy, profit: "250"},
+		{side: Sell, profit: "-250"},
+	}
+	for idx, tab := range tt {
+		exec := NewOpenExec(Stock, time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(100), tab.side, "stock")
+		exec.CloseExec(time.Now(), decimal.NewFromInt(102))
+		exec.Dividend = decimal.NewFromFloat(0.5)
+		profit, err := exec.GetProfit()
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error getting profit"))
+		}
+		if profit.String() != tab.profit {
+			t.Errorf("Expected profit to be %+v but got %+v at idx: %d", tab.profit, profit, idx)
+		}
+	}
+}
+
+func TestPositionGreeks(t *testing.T) {
+	// a covered call: long 100 shares and short one call with delta 0.4
+	stk := NewOpenExec(Stock, time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(100), Buy, "stock")
+	call := NewOpenExec(Option, time.Now(), decimal.NewFromFloat(2.5), decimal.NewFromInt(1), Sell, "call")
+	call.Greeks = Greeks{
+		Delta: decimal.NewFromFloat(0.4),
+		Gamma: decimal.NewFromFloat(0.05),
+		Theta: decimal.NewFromFloat(-0.03),
+		Vega:  decimal.NewFromFloat(0.12),
+		Rho:   decimal.NewFromFloat(0.04),
+	}
+	put := NewOpenExec(Option, time.Now(), decimal.NewFromFloat(1.5), decimal.NewFromInt(2), Buy, "put")
+	put.Multiplier = decimal.NewFromInt(1000)
+	put.Greeks = Greeks{Delta: decimal.NewFromFloat(-0.2)}
+
+	tt := []struct {
+		exec  *ExecOpenClose
+		delta string
+		theta string
+	}{
+		{exec: stk, delta: "100", theta: "0"},
+		{exec: call, delta: "-40", theta: "3"},
+		{exec: put, delta: "-400", theta: "0"},
+	}
+	for idx, tab := range tt {
+		g := tab.exec.PositionGreeks()
+		if g.Delta.String() != tab.delta || g.Theta.String() != tab.theta {
+			t.Errorf("Expected delta %+v and theta %+v but got %+v and %+v at idx: %d", tab.delta, tab.theta, g.Delta, g.Theta, idx)
+		}
+	}
+
+	legs, err := NewExecLegs(map[string]*ExecOpenClose{"stock": stk, "call": call})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating exec legs"))
+	}
+	g := legs.PositionGreeks()
+	if g.Delta.String() != "60" || g.Gamma.String() != "-5" || g.Vega.String() != "-12" || g.Rho.String() != "-4" {
+		t.Errorf("Expected delta 60, gamma -5, vega -12 and rho -4 but got %+v", g)
+	}
+}
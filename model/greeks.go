@@ -0,0 +1,125 @@
+package model
+
+import (
+	"backtest-options/pricing"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// daysPerYear is the number of calendar days a year used to measure the time to expiry
+const daysPerYear = 365
+
+// Greeks are the sensitivities of an option or position price. Theta is the change per calendar day, and vega and rho are the changes per percentage point of volatility and rate
+type Greeks struct {
+	Delta decimal.Decimal
+	Gamma decimal.Decimal
+	Theta decimal.Decimal
+	Vega  decimal.Decimal
+	Rho   decimal.Decimal
+}
+
+// Add returns the sum of the greeks
+func (g Greeks) Add(o Greeks) Greeks {
+	return Greeks{
+		Delta: g.Delta.Add(o.Delta),
+		Gamma: g.Gamma.Add(o.Gamma),
+		Theta: g.Theta.Add(o.Theta),
+		Vega:  g.Vega.Add(o.Vega),
+		Rho:   g.Rho.Add(o.Rho),
+	}
+}
+
+// Mul returns the greeks multiplied by m, e.g. the number of units held
+func (g Greeks) Mul(m decimal.Decimal) Greeks {
+	return Greeks{
+		Delta: g.Delta.Mul(m),
+		Gamma: g.Gamma.Mul(m),
+		Theta: g.Theta.Mul(m),
+		Vega:  g.Vega.Mul(m),
+		Rho:   g.Rho.Mul(m),
+	}
+}
+
+// Greeks returns the greeks of one unit of the underlying of the option, which are zero unless HasIV is true
+func (o OHLCV) Greeks() Greeks {
+	return Greeks{
+		Delta: o.Delta,
+		Gamma: o.Gamma,
+		Theta: o.Theta,
+		Vega:  o.Vega,
+		Rho:   o.Rho,
+	}
+}
+
+// pricingInputs are the inputs besides volatility that options of one quote date and expiry are priced with
+type pricingInputs struct {
+	// s is the underlying price
+	s float64
+	// t is the time to expiry in years
+	t float64
+	// r is the risk-free rate for the time to expiry
+	r float64
+	// q is the dividend yield of the dividends going ex before expiry
+	q float64
+}
+
+// pricingInputs returns the inputs of options of the quote that expire on exp, priced at the snapshot
+func (o *OptChainList) pricingInputs(quote *OptChain, exp time.Time, snapshot Snapshot) pricingInputs {
+	days := int(exp.Sub(quote.QuoteDate).Hours() / 24)
+	in := pricingInputs{t: float64(days) / daysPerYear}
+	in.s, _ = quote.UndPxAt(snapshot).Float64()
+	in.r, _ = o.rates.Rate(quote.QuoteDate, days).Float64()
+	if o.actions != nil && in.s > 0 && in.t > 0 {
+		// a continuous yield with the same value as the cash dividends
+		divs, _ := o.actions.Dividends(quote.QuoteDate, exp).Float64()
+		if divs > 0 && divs < in.s {
+			in.q = -math.Log(1-divs/in.s) / in.t
+		}
+	}
+	return in
+}
+
+// ComputeGreeks solves the implied volatility of every call and put from its mid price at the snapshot, and computes its greeks from it, with the rate curve and the dividends of the corporate actions attached to the chain. Options with source provided implied volatility and greeks, which are calculated at 15:45, keep them at Snapshot1545. Options that have expired on their quote date, or whose mid price no volatility gives, are left without them.
+func (o *OptChainList) ComputeGreeks(snapshot Snapshot) {
+	for _, d := range o.quotes {
+		quote := o.quoteMap[d]
+		for _, e := range quote.expiry {
+			exp := quote.expiryMap[e]
+			in := o.pricingInputs(quote, e, snapshot)
+			for _, strike := range exp.strikeMap {
+				k, _ := strike.S.Float64()
+				solveGreeks(&strike.Call, pricing.Call, k, in, snapshot)
+				solveGreeks(&strike.Put, pricing.Put, k, in, snapshot)
+			}
+		}
+	}
+}
+
+// solveGreeks sets the implied volatility and greeks of opt unless the source provided them for the snapshot. Source greeks are of 15:45, so they are replaced at the end of day
+func solveGreeks(opt *OHLCV, right pricing.Right, k float64, in pricingInputs, snapshot Snapshot) {
+	if opt.Type == "" || (opt.HasGreeks && snapshot == Snapshot1545) {
+		return
+	}
+	opt.HasGreeks = false
+	opt.HasIV = false
+	opt.IV = decimal.Zero
+	opt.Delta, opt.Gamma, opt.Theta, opt.Vega, opt.Rho = decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero
+	px, _ := opt.MidAt(snapshot).Float64()
+	if px <= 0 {
+		return
+	}
+	vol, err := pricing.ImpliedVol(right, px, in.s, k, in.t, in.r, in.q)
+	if err != nil {
+		return
+	}
+	g := pricing.BlackScholesGreeks(right, in.s, k, in.t, in.r, in.q, vol)
+	opt.IV = decimal.NewFromFloat(vol).Round(6)
+	opt.Delta = decimal.NewFromFloat(g.Delta).Round(6)
+	opt.Gamma = decimal.NewFromFloat(g.Gamma).Round(6)
+	opt.Theta = decimal.NewFromFloat(g.Theta).Round(6)
+	opt.Vega = decimal.NewFromFloat(g.Vega).Round(6)
+	opt.Rho = decimal.NewFromFloat(g.Rho).Round(6)
+	opt.HasIV = true
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"backtest-options/pricing"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestComputeGreeks(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	exp := quote.AddDate(0, 0, 73)
+	tm := 73.0 / 365
+	r := 0.02
+	// a 1 dollar dividend on a 100 dollar underlying
+	q := -math.Log(1-1.0/100) / tm
+
+	px := func(right pricing.Right, k float64) string {
+		return fmt.Sprintf("%.6f", pricing.BlackScholes(right, 100, k, tm, r, q, 0.25))
+	}
+	call, _ := NewOHLCV(quote, "SPY", exp, "105", Call, "0", "0", "0", "0", "0", px(pricing.Call, 105), px(pricing.Call, 105), "100", "100")
+	put, _ := NewOHLCV(quote, "SPY", exp, "95", Put, "0", "0", "0", "0", "0", px(pricing.Put, 95), px(pricing.Put, 95), "100", "100")
+	vendor, _ := NewOHLCV(quote, "SPY", exp, "95", Call, "0", "0", "0", "0", "0", px(pricing.Call, 95), px(pricing.Call, 95), "100", "100")
+	vendor, _ = vendor.WithGreeks("0.3", "0.6", "0.01", "-0.02", "0.15", "0.1")
+	// below the discounted intrinsic value
+	cheap, _ := NewOHLCV(quote, "SPY", exp, "80", Call, "0", "0", "0", "0", "0", "1", "1", "100", "100")
+	// expires on its quote date
+	expired, _ := NewOHLCV(quote, "SPY", quote, "100", Call, "0", "0", "0", "0", "0", "1", "1", "100", "100")
+
+	chain, err := NewOptionChain([]OHLCV{call, put, vendor, cheap, expired})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	rates, err := NewRateCurve(nil, decimal.NewFromFloat(r))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating rate curve"))
+	}
+	chain.SetRateCurve(rates)
+	actions, err := NewCorporateActions("SPY", []CorporateAction{
+		{ExDate: quote.AddDate(0, 0, 30), Type: ActionDividend, Value: decimal.NewFromInt(1)},
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating corporate actions"))
+	}
+	chain.SetCorporateActions(actions)
+	chain.ComputeGreeks(SnapshotEOD)
+
+	tt := []struct {
+		exp    time.Time
+		k      string
+		typ    OptType
+		hasIV  bool
+		iv     float64
+		greeks pricing.Greeks
+	}{
+		{exp: exp, k: "105", typ: Call, hasIV: true, iv: 0.25},
+		{exp: exp, k: "95", typ: Put, hasIV: true, iv: 0.25},
+		// the source provided volatility and greeks are of 15:45, so they are solved again at the end of day
+		{exp: exp, k: "95", typ: Call, hasIV: true, iv: 0.25},
+		{exp: exp, k: "80", typ: Call, hasIV: false},
+		{exp: quote, k: "100", typ: Call, hasIV: false},
+	}
+	optchain := chain.GetOptionChainForQuoteDate(quote, true)
+	for idx, tab := range tt {
+		k, _ := decimal.NewFromString(tab.k)
+		strike := optchain.GetOptionChainForExpiryDate(tab.exp, true).GetOptionChainForStrike(k, true)
+		opt := strike.Call
+		if tab.typ == Put {
+			opt = strike.Put
+		}
+		if opt.HasIV != tab.hasIV {
+			t.Errorf("Expected HasIV to be %+v but got %+v at idx: %d", tab.hasIV, opt.HasIV, idx)
+		}
+		if iv, _ := opt.IV.Float64(); math.Abs(iv-tab.iv) > 1e-4 {
+			t.Errorf("Expected IV to be %+v but got %+v at idx: %d", tab.iv, iv, idx)
+		}
+		if !tab.hasIV {
+			if !opt.Greeks().Delta.IsZero() {
+				t.Errorf("Expected no delta but got %+v at idx: %d", opt.Delta, idx)
+			}
+			continue
+		}
+		expected := tab.greeks
+		if expected == (pricing.Greeks{}) {
+			right := pricing.Call
+			if tab.typ == Put {
+				right = pricing.Put
+			}
+			kf, _ := k.Float64()
+			expected = pricing.BlackScholesGreeks(right, 100, kf, tm, r, q, tab.iv)
+		}
+		g := opt.Greeks()
+		for _, v := range []struct {
+			name     string
+			value    decimal.Decimal
+			expected float64
+		}{
+			{name: "delta", value: g.Delta, expected: expected.Delta},
+			{name: "gamma", value: g.Gamma, expected: expected.Gamma},
+			{name: "theta", value: g.Theta, expected: expected.Theta},
+			{name: "vega", value: g.Vega, expected: expected.Vega},
+			{name: "rho", value: g.Rho, expected: expected.Rho},
+		} {
+			if f, _ := v.value.Float64(); math.Abs(f-v.expected) > 1e-4 {
+				t.Errorf("Expected %s to be %+v but got %+v at idx: %d", v.name, v.expected, f, idx)
+			}
+		}
+	}
+}
+
+func TestComputeGreeksSource(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	exp := quote.AddDate(0, 0, 73)
+	tm := 73.0 / 365
+	eod := fmt.Sprintf("%.6f", pricing.BlackScholes(pricing.Call, 100, 95, tm, 0, 0, 0.25))
+	vendor, _ := NewOHLCV(quote, "SPY", exp, "95", Call, "0", "0", "0", "0", "0", eod, eod, "100", "100")
+	vendor, _ = vendor.WithSnapshot1545("1", "6", "1", "6.1", "99", "99")
+	vendor, _ = vendor.WithGreeks("0.3", "0.6", "0.01", "-0.02", "0.15", "0.1")
+
+	tt := []struct {
+		snapshot  Snapshot
+		hasGreeks bool
+		iv        float64
+		delta     float64
+	}{
+		// the source provided volatility and greeks are kept at 15:45
+		{snapshot: Snapshot1545, hasGreeks: true, iv: 0.3, delta: 0.6},
+		{snapshot: SnapshotEOD, hasGreeks: false, iv: 0.25, delta: pricing.BlackScholesGreeks(pricing.Call, 100, 95, tm, 0, 0, 0.25).Delta},
+	}
+	for idx, tab := range tt {
+		chain, err := NewOptionChain([]OHLCV{vendor})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+		}
+		chain.ComputeGreeks(tab.snapshot)
+		k, _ := decimal.NewFromString("95")
+		opt := chain.GetOptionChainForQuoteDate(quote, true).GetOptionChainForExpiryDate(exp, true).GetOptionChainForStrike(k, true).Call
+		if !opt.HasIV || opt.HasGreeks != tab.hasGreeks {
+			t.Errorf("Expected HasIV and HasGreeks to be true and %+v but got %+v and %+v at idx: %d", tab.hasGreeks, opt.HasIV, opt.HasGreeks, idx)
+		}
+		if iv, _ := opt.IV.Float64(); math.Abs(iv-tab.iv) > 1e-4 {
+			t.Errorf("Expected IV to be %+v but got %+v at idx: %d", tab.iv, iv, idx)
+		}
+		if delta, _ := opt.Delta.Float64(); math.Abs(delta-tab.delta) > 1e-4 {
+			t.Errorf("Expected delta to be %+v but got %+v at idx: %d", tab.delta, delta, idx)
+		}
+	}
+}
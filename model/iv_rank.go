@@ -0,0 +1,121 @@
+package model
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// IVStats are the at the money implied volatility of a quote date, and its IV rank and IV percentile among the quote dates of the lookback
+type IVStats struct {
+	// ATM is the at the money implied volatility interpolated to the constant maturity. It is zero unless HasATM is true
+	ATM    decimal.Decimal
+	HasATM bool
+	// Rank is where ATM lies between the lowest (0) and the highest (100) ATM of the lookback
+	Rank decimal.Decimal
+	// Percentile is the percentage of the other quote dates of the lookback whose ATM is below ATM
+	Percentile decimal.Decimal
+	// Days is the number of quote dates with ATM that Rank and Percentile are over, including this one. It is less than the lookback near the start of the data
+	Days int
+}
+
+// IVRange restricts the quote dates positions are opened on to those whose IV rank and IV percentile are within it, in percent. A zero maximum is unbounded unless HasMaxRank or HasMaxPercentile is true, so that a maximum of 0 enters only at the bottom of the range
+type IVRange struct {
+	MinRank          decimal.Decimal
+	MaxRank          decimal.Decimal
+	HasMaxRank       bool
+	MinPercentile    decimal.Decimal
+	MaxPercentile    decimal.Decimal
+	HasMaxPercentile bool
+}
+
+// IsZero returns true if the range allows any quote date
+func (r IVRange) IsZero() bool {
+	return r.MinRank.IsZero() && !r.boundedRank() && r.MinPercentile.IsZero() && !r.boundedPercentile()
+}
+
+// boundedRank returns true if MaxRank bounds the rank
+func (r IVRange) boundedRank() bool {
+	return r.HasMaxRank || !r.MaxRank.IsZero()
+}
+
+// boundedPercentile returns true if MaxPercentile bounds the percentile
+func (r IVRange) boundedPercentile() bool {
+	return r.HasMaxPercentile || !r.MaxPercentile.IsZero()
+}
+
+// Validate checks that the bounds are between 0 and 100 and that the minimums are not above the maximums
+func (r IVRange) Validate() error {
+	hundred := decimal.NewFromInt(100)
+	for _, v := range []decimal.Decimal{r.MinRank, r.MaxRank, r.MinPercentile, r.MaxPercentile} {
+		if v.IsNegative() || v.GreaterThan(hundred) {
+			return errors.Errorf("Expected IV rank and percentile bounds to be between 0 and 100 but got %+v", r)
+		}
+	}
+	if (r.boundedRank() && r.MinRank.GreaterThan(r.MaxRank)) || (r.boundedPercentile() && r.MinPercentile.GreaterThan(r.MaxPercentile)) {
+		return errors.Errorf("Expected IV rank and percentile minimums to be below the maximums but got %+v", r)
+	}
+	return nil
+}
+
+// Allows returns true if the stats are within the range. Quote dates without a rank, because they have no ATM or no earlier quote date to rank against, are only allowed by a zero range
+func (r IVRange) Allows(s IVStats) bool {
+	if r.IsZero() {
+		return true
+	}
+	if !s.HasATM || s.Days < 2 {
+		return false
+	}
+	if s.Rank.LessThan(r.MinRank) || (r.boundedRank() && s.Rank.GreaterThan(r.MaxRank)) {
+		return false
+	}
+	if s.Percentile.LessThan(r.MinPercentile) || (r.boundedPercentile() && s.Percentile.GreaterThan(r.MaxPercentile)) {
+		return false
+	}
+	return true
+}
+
+// ComputeIVStats sets the IV stats of every quote date. The ATM of a quote date is the implied volatility of its VolSurface at the money and tenorDays calendar days to expiry. Rank and percentile are over the last lookback quote dates with ATM. It uses the implied volatilities of ComputeGreeks, so it is called after it.
+func (o *OptChainList) ComputeIVStats(snapshot Snapshot, tenorDays, lookback int) {
+	var history []float64
+	for _, d := range o.quotes {
+		quote := o.quoteMap[d]
+		quote.IV = IVStats{}
+		v := quote.VolSurface(snapshot)
+		if v == nil {
+			continue
+		}
+		atm := v.MoneynessIV(1, tenorDays)
+		history = append(history, atm)
+		if len(history) > lookback {
+			history = history[len(history)-lookback:]
+		}
+		quote.IV = ivStats(atm, history)
+	}
+}
+
+// ivStats ranks atm, the last value of history, among history
+func ivStats(atm float64, history []float64) IVStats {
+	lo, hi := atm, atm
+	below := 0
+	for _, v := range history {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+		if v < atm {
+			below++
+		}
+	}
+	s := IVStats{
+		ATM:    decimal.NewFromFloat(atm).Round(6),
+		HasATM: true,
+		Days:   len(history),
+	}
+	if hi > lo {
+		s.Rank = decimal.NewFromFloat((atm - lo) / (hi - lo) * 100).Round(2)
+	}
+	if len(history) > 1 {
+		s.Percentile = decimal.NewFromFloat(float64(below) / float64(len(history)-1) * 100).Round(2)
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestComputeIVStatsATM(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	near := quote.AddDate(0, 0, 20)
+	far := quote.AddDate(0, 0, 40)
+	opt := func(exp time.Time, k string, typ OptType, iv string) OHLCV {
+		o, _ := NewOHLCV(quote, "SPY", exp, k, typ, "0", "0", "0", "0", "0", "1", "1", "99.5", "100.5")
+		o, _ = o.WithGreeks(iv, "0", "0", "0", "0", "0")
+		return o
+	}
+	chain, err := NewOptionChain([]OHLCV{
+		// the near smile is 0.2 at the money between the strikes, and the call and put of a strike average
+		opt(near, "95", Call, "0.21"),
+		opt(near, "95", Put, "0.23"),
+		opt(near, "105", Call, "0.18"),
+		opt(far, "90", Put, "0.3"),
+		opt(far, "110", Call, "0.3"),
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+
+	tt := []struct {
+		tenor    int
+		expected float64
+	}{
+		// flat before the first and after the last expiry
+		{tenor: 10, expected: 0.2},
+		{tenor: 20, expected: 0.2},
+		// half way in total variance between 0.2 for 20 days and 0.3 for 40 days
+		{tenor: 30, expected: math.Sqrt((0.2*0.2*20 + 0.3*0.3*40) / 2 / 30)},
+		{tenor: 60, expected: 0.3},
+	}
+	for idx, tab := range tt {
+		chain.ComputeIVStats(SnapshotEOD, tab.tenor, 252)
+		iv := chain.GetOptionChainForQuoteDate(quote, true).IV
+		if !iv.HasATM {
+			t.Fatalf("Expected an ATM implied volatility at idx: %d", idx)
+		}
+		if v, _ := iv.ATM.Float64(); math.Abs(v-tab.expected) > 1e-6 {
+			t.Errorf("Expected ATM to be %+v but got %+v at idx: %d", tab.expected, v, idx)
+		}
+	}
+}
+
+func TestComputeIVStatsRank(t *testing.T) {
+	start := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	ivs := []string{"0.2", "0.3", "", "0.1", "0.25", "0.15"}
+	data := make([]OHLCV, 0)
+	for i, iv := range ivs {
+		quote := start.AddDate(0, 0, i)
+		o, _ := NewOHLCV(quote, "SPY", start.AddDate(0, 1, 0), "100", Call, "0", "0", "0", "0", "0", "1", "1", "100", "100")
+		o, _ = o.WithGreeks(iv, "0", "0", "0", "0", "0")
+		data = append(data, o)
+	}
+	chain, err := NewOptionChain(data)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	chain.ComputeIVStats(SnapshotEOD, 30, 3)
+
+	tt := []struct {
+		hasATM     bool
+		rank       string
+		percentile string
+		days       int
+	}{
+		{hasATM: true, rank: "0", percentile: "0", days: 1},
+		{hasATM: true, rank: "100", percentile: "100", days: 2},
+		// quote dates without implied volatility are left out of the lookback
+		{hasATM: false, rank: "0", percentile: "0", days: 0},
+		{hasATM: true, rank: "0", percentile: "0", days: 3},
+		// 0.25 among 0.3, 0.1 and 0.25
+		{hasATM: true, rank: "75", percentile: "50", days: 3},
+		// 0.15 among 0.1, 0.25 and 0.15
+		{hasATM: true, rank: "33.33", percentile: "50", days: 3},
+	}
+	for idx, tab := range tt {
+		iv := chain.GetOptionChainForQuoteDate(start.AddDate(0, 0, idx), true).IV
+		rank, _ := decimal.NewFromString(tab.rank)
+		percentile, _ := decimal.NewFromString(tab.percentile)
+		if iv.HasATM != tab.hasATM || !iv.Rank.Equal(rank) || !iv.Percentile.Equal(percentile) || iv.Days != tab.days {
+			t.Errorf("Expected %+v but got %+v at idx: %d", fmt.Sprintf("%+v %s %s %d", tab.hasATM, tab.rank, tab.percentile, tab.days), iv, idx)
+		}
+	}
+}
+
+func TestIVRange(t *testing.T) {
+	stats := IVStats{ATM: decimal.NewFromFloat(0.2), HasATM: true, Rank: decimal.NewFromInt(40), Percentile: decimal.NewFromInt(70), Days: 252}
+	tt := []struct {
+		r       IVRange
+		stats   IVStats
+		allows  bool
+		invalid bool
+	}{
+		{r: IVRange{}, stats: IVStats{}, allows: true},
+		{r: IVRange{MinRank: decimal.NewFromInt(30)}, stats: stats, allows: true},
+		{r: IVRange{MinRank: decimal.NewFromInt(50)}, stats: stats, allows: false},
+		{r: IVRange{MaxRank: decimal.NewFromInt(30)}, stats: stats, allows: false},
+		// a maximum of 0 is only unbounded if it is not set
+		{r: IVRange{HasMaxRank: true}, stats: stats, allows: false},
+		{r: IVRange{HasMaxPercentile: true}, stats: stats, allows: false},
+		{r: IVRange{HasMaxRank: true}, stats: IVStats{ATM: decimal.NewFromFloat(0.2), HasATM: true, Days: 10, Percentile: decimal.NewFromInt(40)}, allows: true},
+		{r: IVRange{MinRank: decimal.NewFromInt(10), HasMaxRank: true}, invalid: true},
+		{r: IVRange{MinPercentile: decimal.NewFromInt(60), MaxPercentile: decimal.NewFromInt(80)}, stats: stats, allows: true},
+		{r: IVRange{MaxPercentile: decimal.NewFromInt(60)}, stats: stats, allows: false},
+		// quote dates without a rank are only allowed without a range
+		{r: IVRange{MaxRank: decimal.NewFromInt(100)}, stats: IVStats{ATM: decimal.NewFromFloat(0.2), HasATM: true, Days: 1}, allows: false},
+		{r: IVRange{MaxRank: decimal.NewFromInt(100)}, stats: IVStats{}, allows: false},
+		{r: IVRange{MinRank: decimal.NewFromInt(60), MaxRank: decimal.NewFromInt(50)}, invalid: true},
+		{r: IVRange{MaxPercentile: decimal.NewFromInt(101)}, invalid: true},
+		{r: IVRange{MinRank: decimal.NewFromInt(-1)}, invalid: true},
+	}
+	for idx, tab := range tt {
+		if err := tab.r.Validate(); (err != nil) != tab.invalid {
+			t.Errorf("Expected invalid %+v but got %+v at idx: %d", tab.invalid, err, idx)
+			continue
+		}
+		if tab.invalid {
+			continue
+		}
+		if allows := tab.r.Allows(tab.stats); allows != tab.allows {
+			t.Errorf("Expected Allows to be %+v but got %+v at idx: %d", tab.allows, allows, idx)
+		}
+	}
+}
@@ -14,6 +14,18 @@ type OptType string
 const (
 	// DateLayout is a default date layout
 	DateLayout = "2006-01-02"
+	// DefaultMultiplier is the contract multiplier used when the source does not specify one
+	DefaultMultiplier = 100
+)
+
+// Snapshot is the time of day at which bid and ask quotes were taken
+type Snapshot int
+
+const (
+	// SnapshotEOD is the end of day quote. This is the default
+	SnapshotEOD Snapshot = iota
+	// Snapshot1545 is the quote taken at 15:45, fifteen minutes before the close
+	Snapshot1545
 )
 
 const (
@@ -27,34 +39,76 @@ const (
 type OHLCV struct {
 	// Ask is the last contract's lowest sell price
 	Ask decimal.Decimal
+	// Ask1545 is the contract's lowest sell price at 15:45
+	Ask1545 decimal.Decimal
+	// AskSize is the number of contracts offered at the ask price
+	AskSize decimal.Decimal
+	// AskSize1545 is the number of contracts offered at the ask price at 15:45
+	AskSize1545 decimal.Decimal
 	// AskBidMid is the mid price of the ask and bid
 	AskBidMid decimal.Decimal
 	// Bid is the last contract's highest buy price
 	Bid decimal.Decimal
+	// Bid1545 is the contract's highest buy price at 15:45
+	Bid1545 decimal.Decimal
+	// BidSize is the number of contracts bid at the bid price
+	BidSize decimal.Decimal
+	// BidSize1545 is the number of contracts bid at the bid price at 15:45
+	BidSize1545 decimal.Decimal
 	// Close is the option contract's close price
 	Close decimal.Decimal
+	// Delta is the delta, provided by the source if HasGreeks is true and computed from IV by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	Delta decimal.Decimal
+	// DeliveryCode is the exchange's delivery code for non-standard deliverables. It is empty for standard contracts
+	DeliveryCode string
 	// Expiration is the option expiration price
 	Expiration time.Time
+	// Gamma is the gamma, provided by the source if HasGreeks is true and computed from IV by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	Gamma decimal.Decimal
+	// HasGreeks is true if the source provided implied volatility and greeks. They are calculated at 15:45, so OptChainList.ComputeGreeks only keeps them at Snapshot1545
+	HasGreeks bool
+	// HasIV is true if IV is set, either by the source or solved by OptChainList.ComputeGreeks
+	HasIV bool
 	// High is the option contract's low price
 	High decimal.Decimal
+	// IV is the implied volatility as a fraction, e.g. 0.2 for 20%. It is provided by the source if HasGreeks is true, and solved from the mid price by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	IV decimal.Decimal
 	// Low is the option contract's low price
 	Low decimal.Decimal
+	// Multiplier is the number of units of the underlying delivered per contract. It is 100 for US equity and index options
+	Multiplier decimal.Decimal
 	// Open is the option contract's open price
 	Open decimal.Decimal
+	// OpenInterest is the number of outstanding contracts
+	OpenInterest decimal.Decimal
 	// QuoteDate is the date in which this price was quoted
 	QuoteDate time.Time
+	// Rho is the rho, provided by the source if HasGreeks is true and computed from IV by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	Rho decimal.Decimal
+	// Root is the option root symbol, e.g. SPXW for SPX weeklies. It is empty if the source did not include it
+	Root string
 	// Strike is the strike price of the contract
 	Strike decimal.Decimal
+	// Theta is the theta, provided by the source if HasGreeks is true and computed from IV by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	Theta decimal.Decimal
 	// Type is the type of option contract. The value is either call or put
 	Type OptType
 	// UndAsk is underlying ask price
 	UndAsk decimal.Decimal
+	// UndAsk1545 is underlying ask price at 15:45
+	UndAsk1545 decimal.Decimal
 	// UndBid is an underlying bid price
 	UndBid decimal.Decimal
+	// UndBid1545 is an underlying bid price at 15:45
+	UndBid1545 decimal.Decimal
 	// UndSym is an underlying symbol
 	UndSym string
+	// Vega is the vega, provided by the source if HasGreeks is true and computed from IV by OptChainList.ComputeGreeks otherwise. It is only set if HasIV is true
+	Vega decimal.Decimal
 	// Volume is the volume traded of this contract within a specified timeframe; it's usually one day
 	Volume decimal.Decimal
+	// Vwap is the volume weighted average traded price of the contract
+	Vwap decimal.Decimal
 }
 
 // NewOHLCV Creates a new OHLCV
@@ -119,6 +173,7 @@ func NewOHLCV(d time.Time, sym string, exp time.Time, s string, typ OptType, o,
 		Expiration: exp,
 		High:       high,
 		Low:        low,
+		Multiplier: decimal.NewFromInt(DefaultMultiplier),
 		Open:       open,
 		QuoteDate:  d,
 		Strike:     strike,
@@ -129,3 +184,172 @@ func NewOHLCV(d time.Time, sym string, exp time.Time, s string, typ OptType, o,
 		Volume:     volume,
 	}, nil
 }
+
+// WithDetail returns a copy of the OHLCV with root, quote sizes, vwap, open interest and delivery code set. Empty numeric values are treated as zero.
+func (o OHLCV) WithDetail(root, bidsz, asksz, vwap, oi, delivcode string) (OHLCV, error) {
+	bs, err := decimalOrZero(bidsz)
+	if err != nil {
+		return OHLCV{}, errors.Wrapf(err, "Error parsing bid size: %+v", bidsz)
+	}
+	as, err := decimalOrZero(asksz)
+	if err != nil {
+		return OHLCV{}, errors.Wrapf(err, "Error parsing ask size: %+v", asksz)
+	}
+	vw, err := decimalOrZero(vwap)
+	if err != nil {
+		return OHLCV{}, errors.Wrapf(err, "Error parsing vwap: %+v", vwap)
+	}
+	openint, err := decimalOrZero(oi)
+	if err != nil {
+		return OHLCV{}, errors.Wrapf(err, "Error parsing open interest: %+v", oi)
+	}
+	o.Root = root
+	o.BidSize = bs
+	o.AskSize = as
+	o.Vwap = vw
+	o.OpenInterest = openint
+	o.DeliveryCode = delivcode
+	return o, nil
+}
+
+// decimalOrZero parses s as a decimal and returns zero if s is empty
+func decimalOrZero(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Decimal{}, nil
+	}
+	return decimal.NewFromString(s)
+}
+
+// WithSnapshot1545 returns a copy of the OHLCV with the 15:45 quote set. Empty values are treated as zero.
+func (o OHLCV) WithSnapshot1545(bidsz, bid, asksz, ask, undbid, undask string) (OHLCV, error) {
+	values := []struct {
+		name string
+		s    string
+		dst  *decimal.Decimal
+	}{
+		{"bid size", bidsz, &o.BidSize1545},
+		{"bid", bid, &o.Bid1545},
+		{"ask size", asksz, &o.AskSize1545},
+		{"ask", ask, &o.Ask1545},
+		{"underlying bid", undbid, &o.UndBid1545},
+		{"underlying ask", undask, &o.UndAsk1545},
+	}
+	for _, v := range values {
+		d, err := decimalOrZero(v.s)
+		if err != nil {
+			return OHLCV{}, errors.Wrapf(err, "Error parsing 15:45 %s: %+v", v.name, v.s)
+		}
+		*v.dst = d
+	}
+	return o, nil
+}
+
+// WithGreeks returns a copy of the OHLCV with vendor provided implied volatility and greeks set. If iv is empty the OHLCV is returned unchanged, since the source has no analytics for this row.
+func (o OHLCV) WithGreeks(iv, delta, gamma, theta, vega, rho string) (OHLCV, error) {
+	if iv == "" {
+		return o, nil
+	}
+	values := []struct {
+		name string
+		s    string
+		dst  *decimal.Decimal
+	}{
+		{"implied volatility", iv, &o.IV},
+		{"delta", delta, &o.Delta},
+		{"gamma", gamma, &o.Gamma},
+		{"theta", theta, &o.Theta},
+		{"vega", vega, &o.Vega},
+		{"rho", rho, &o.Rho},
+	}
+	for _, v := range values {
+		d, err := decimalOrZero(v.s)
+		if err != nil {
+			return OHLCV{}, errors.Wrapf(err, "Error parsing %s: %+v", v.name, v.s)
+		}
+		*v.dst = d
+	}
+	o.HasGreeks = true
+	o.HasIV = true
+	return o, nil
+}
+
+// WithMultiplier returns a copy of the OHLCV with the contract multiplier set. An empty value keeps the default multiplier.
+func (o OHLCV) WithMultiplier(m string) (OHLCV, error) {
+	if m == "" {
+		return o, nil
+	}
+	mul, err := decimal.NewFromString(m)
+	if err != nil {
+		return OHLCV{}, errors.Wrapf(err, "Error parsing multiplier: %+v", m)
+	}
+	if !mul.IsPositive() {
+		return OHLCV{}, errors.Errorf("Expected multiplier to be positive but got %+v", m)
+	}
+	o.Multiplier = mul
+	return o, nil
+}
+
+// BidAt returns the bid price at the snapshot
+func (o OHLCV) BidAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.Bid1545
+	}
+	return o.Bid
+}
+
+// AskAt returns the ask price at the snapshot
+func (o OHLCV) AskAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.Ask1545
+	}
+	return o.Ask
+}
+
+// MidAt returns the mid price of the ask and bid at the snapshot
+func (o OHLCV) MidAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.Ask1545.Add(o.Bid1545).Div(decimal.NewFromInt(2))
+	}
+	return o.AskBidMid
+}
+
+// HasQuoteAt returns true if the option has a bid or an ask at the snapshot
+func (o OHLCV) HasQuoteAt(s Snapshot) bool {
+	return !o.BidAt(s).IsZero() || !o.AskAt(s).IsZero()
+}
+
+// UndBidAt returns the underlying bid price at the snapshot
+func (o OHLCV) UndBidAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.UndBid1545
+	}
+	return o.UndBid
+}
+
+// UndAskAt returns the underlying ask price at the snapshot
+func (o OHLCV) UndAskAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.UndAsk1545
+	}
+	return o.UndAsk
+}
+
+// ParseSnapshot parses "eod" or "1545" into a Snapshot
+func ParseSnapshot(s string) (Snapshot, error) {
+	switch s {
+	case "", "eod":
+		return SnapshotEOD, nil
+	case "1545":
+		return Snapshot1545, nil
+	default:
+		return SnapshotEOD, errors.Errorf("Unsupported snapshot %+v, expected eod or 1545", s)
+	}
+}
+
+// String returns the snapshot name accepted by ParseSnapshot
+func (s Snapshot) String() string {
+	if s == Snapshot1545 {
+		return "1545"
+	}
+	return "eod"
+}
@@ -77,3 +77,110 @@ func TestOHLCV(t *testing.T) {
 		t.Error(errors.Errorf("Expected %+v but got %+v", undBid, ohlcv.UndBid))
 	}
 }
+
+func TestOHLCVWithDetail(t *testing.T) {
+	d, _ := time.Parse(DateLayout, "2016-06-01")
+	ohlcv, err := NewOHLCV(d, "^SPX", d, "2100", Put, "1", "1", "1", "1", "10", "1.1", "0.9", "2100", "2099")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating OHLCV"))
+	}
+	detail, err := ohlcv.WithDetail("SPXW", "15", "", "1.05", "3500", "AM")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error setting detail"))
+	}
+	if detail.Root != "SPXW" {
+		t.Errorf("Expected root to be SPXW but got %+v", detail.Root)
+	}
+	if detail.BidSize.String() != "15" {
+		t.Errorf("Expected bid size to be 15 but got %+v", detail.BidSize)
+	}
+	if !detail.AskSize.IsZero() {
+		t.Errorf("Expected empty ask size to be zero but got %+v", detail.AskSize)
+	}
+	if detail.Vwap.String() != "1.05" {
+		t.Errorf("Expected vwap to be 1.05 but got %+v", detail.Vwap)
+	}
+	if detail.OpenInterest.String() != "3500" {
+		t.Errorf("Expected open interest to be 3500 but got %+v", detail.OpenInterest)
+	}
+	if detail.DeliveryCode != "AM" {
+		t.Errorf("Expected delivery code to be AM but got %+v", detail.DeliveryCode)
+	}
+	if ohlcv.Root != "" {
+		t.Errorf("Expected the original OHLCV to be unchanged but got root %+v", ohlcv.Root)
+	}
+	if _, err := ohlcv.WithDetail("SPXW", "x", "", "", "", ""); err == nil {
+		t.Error("Expected an error for a non numeric bid size")
+	}
+}
+
+func TestOHLCVSnapshot(t *testing.T) {
+	d, _ := time.Parse(DateLayout, "2016-06-01")
+	ohlcv, _ := NewOHLCV(d, "SPY", d, "210", Call, "1", "1", "1", "1", "10", "1.2", "1", "210.1", "210")
+	ohlcv, err := ohlcv.WithSnapshot1545("10", "0.8", "12", "0.9", "209.5", "209.6")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error setting 15:45 snapshot"))
+	}
+	tt := []struct {
+		snapshot Snapshot
+		bid      string
+		ask      string
+		mid      string
+		undbid   string
+		undask   string
+	}{
+		{snapshot: SnapshotEOD, bid: "1", ask: "1.2", mid: "1.1", undbid: "210", undask: "210.1"},
+		{snapshot: Snapshot1545, bid: "0.8", ask: "0.9", mid: "0.85", undbid: "209.5", undask: "209.6"},
+	}
+	for idx, tab := range tt {
+		if ohlcv.BidAt(tab.snapshot).String() != tab.bid {
+			t.Errorf("Expected bid to be %+v but got %+v at idx: %d", tab.bid, ohlcv.BidAt(tab.snapshot), idx)
+		}
+		if ohlcv.AskAt(tab.snapshot).String() != tab.ask {
+			t.Errorf("Expected ask to be %+v but got %+v at idx: %d", tab.ask, ohlcv.AskAt(tab.snapshot), idx)
+		}
+		if ohlcv.MidAt(tab.snapshot).String() != tab.mid {
+			t.Errorf("Expected mid to be %+v but got %+v at idx: %d", tab.mid, ohlcv.MidAt(tab.snapshot), idx)
+		}
+		if ohlcv.UndBidAt(tab.snapshot).String() != tab.undbid {
+			t.Errorf("Expected underlying bid to be %+v but got %+v at idx: %d", tab.undbid, ohlcv.UndBidAt(tab.snapshot), idx)
+		}
+		if ohlcv.UndAskAt(tab.snapshot).String() != tab.undask {
+			t.Errorf("Expected underlying ask to be %+v but got %+v at idx: %d", tab.undask, ohlcv.UndAskAt(tab.snapshot), idx)
+		}
+		parsed, err := ParseSnapshot(tab.snapshot.String())
+		if err != nil || parsed != tab.snapshot {
+			t.Errorf("Expected %+v to parse back but got %+v, %+v at idx: %d", tab.snapshot, parsed, err, idx)
+		}
+	}
+	if _, err := ParseSnapshot("1600"); err == nil {
+		t.Error("Expected an error for an unsupported snapshot")
+	}
+}
+
+func TestOHLCVWithGreeks(t *testing.T) {
+	d, _ := time.Parse(DateLayout, "2016-06-01")
+	ohlcv, _ := NewOHLCV(d, "SPY", d, "210", Put, "1", "1", "1", "1", "10", "1.2", "1", "210.1", "210")
+
+	none, err := ohlcv.WithGreeks("", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error setting empty greeks"))
+	}
+	if none.HasGreeks {
+		t.Error("Expected HasGreeks to be false when the source has no implied volatility")
+	}
+
+	greeks, err := ohlcv.WithGreeks("0.1255", "-0.4821", "0.0851", "-0.0644", "0.1479", "-0.0472")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error setting greeks"))
+	}
+	if !greeks.HasGreeks {
+		t.Error("Expected HasGreeks to be true")
+	}
+	if greeks.IV.String() != "0.1255" || greeks.Delta.String() != "-0.4821" || greeks.Rho.String() != "-0.0472" {
+		t.Errorf("Expected iv, delta and rho to be 0.1255, -0.4821, -0.0472 but got %+v, %+v, %+v", greeks.IV, greeks.Delta, greeks.Rho)
+	}
+	if _, err := ohlcv.WithGreeks("0.1", "x", "", "", "", ""); err == nil {
+		t.Error("Expected an error for a non numeric delta")
+	}
+}
@@ -14,12 +14,25 @@ type OptChainList struct {
 	quoteMap map[time.Time]*OptChain
 	// quoteDates is a list of quote dates in ascending order of time
 	quotes []time.Time
+	// key is the underlying and root of the chain
+	key ChainKey
+	// und is the daily price history of the underlying. It is nil if none was loaded
+	und *UnderlyingSeries
+	// actions is the dividend and split calendar of the underlying. It is nil if none was loaded
+	actions *CorporateActions
+	// rates is the risk-free rate curve. It is nil if none was loaded
+	rates *RateCurve
 }
 
 // OptChain is an option chain for specific quote date
 type OptChain struct {
 	QuoteDate time.Time
-	UndPx     decimal.Decimal
+	// UndPx is the mid price of the underlying at the end of day
+	UndPx decimal.Decimal
+	// UndPx1545 is the mid price of the underlying at 15:45. It is zero if the data has no 15:45 snapshot
+	UndPx1545 decimal.Decimal
+	// IV is the at the money implied volatility and its IV rank and percentile, set by ComputeIVStats
+	IV        IVStats
 	expiryMap map[time.Time]*OptChainExp
 	expiry    []time.Time
 }
@@ -39,97 +52,280 @@ type OptChainStrike struct {
 	Call OHLCV
 }
 
-// NewOptionChain converts OHLCV data into option chain, OptChain
+// NewOptionChain converts OHLCV data of a single underlying and root into option chain, OptChain
 func NewOptionChain(data []OHLCV) (*OptChainList, error) {
-	optChainList := &OptChainList{}
-	ocs := make(map[time.Time]*OptChain)
-	quoteDateList := make([]time.Time, 0)
-
-	quoteDate := make(map[time.Time][]OHLCV)
-	// extract quote date list and index OHLCV for each date
+	b := NewOptionChainBuilder()
 	for _, v := range data {
-		if _, ok := quoteDate[v.QuoteDate]; !ok {
-			quoteDate[v.QuoteDate] = make([]OHLCV, 0)
-			quoteDateList = append(quoteDateList, v.QuoteDate)
+		b.Add(v)
+	}
+	return b.Build()
+}
+
+// ChainKey identifies the option chain of one underlying and option root. Roots split contracts of the same underlying with different settlement, e.g. AM settled SPX and PM settled SPXW.
+type ChainKey struct {
+	UndSym string
+	Root   string
+}
+
+// NewChainKey creates the key of the underlying and root. An empty root, as in files written before roots were imported, is the underlying symbol, so those rows join the chain of newer rows with the default root
+func NewChainKey(undSym, root string) ChainKey {
+	if root == "" {
+		root = undSym
+	}
+	return ChainKey{UndSym: undSym, Root: root}
+}
+
+// String returns the key as symbol or symbol:root
+func (k ChainKey) String() string {
+	if k.Root == "" || k.Root == k.UndSym {
+		return k.UndSym
+	}
+	return k.UndSym + ":" + k.Root
+}
+
+// OptChainSet holds a separate option chain for every underlying and root
+type OptChainSet struct {
+	chains map[ChainKey]*OptChainList
+	keys   []ChainKey
+}
+
+// Keys returns the keys of the chains in the set sorted by underlying and root
+func (o *OptChainSet) Keys() []ChainKey {
+	return o.keys
+}
+
+// Get returns the chain for the key, or nil if the set does not have it
+func (o *OptChainSet) Get(key ChainKey) *OptChainList {
+	return o.chains[key]
+}
+
+// Select returns the chain of the underlying symbol and root. An empty symbol or root is allowed only if it matches a single chain, so that contracts of different underlyings or roots are never mixed.
+func (o *OptChainSet) Select(symbol, root string) (*OptChainList, error) {
+	matches := make([]ChainKey, 0)
+	for _, k := range o.keys {
+		if (symbol == "" || k.UndSym == symbol) && (root == "" || k.Root == root) {
+			matches = append(matches, k)
 		}
-		quoteDate[v.QuoteDate] = append(quoteDate[v.QuoteDate], v)
 	}
+	if len(matches) == 0 {
+		return nil, errors.Errorf("No option chain for symbol %+v and root %+v, available: %+v", symbol, root, o.keys)
+	}
+	if len(matches) > 1 {
+		return nil, errors.Errorf("Expected a single option chain for symbol %+v and root %+v but found %+v, specify the symbol and root", symbol, root, matches)
+	}
+	return o.chains[matches[0]], nil
+}
 
-	optChainList.quotes = quoteDateList
-	sort.Slice(optChainList.quotes, func(i, j int) bool {
-		return optChainList.quotes[i].Before(optChainList.quotes[j])
-	})
+// OptionChainBuilder builds option chains one OHLCV at a time, so rows can be streamed from files without holding them all in memory. Rows are grouped by underlying symbol and root.
+type OptionChainBuilder struct {
+	lists map[ChainKey]*chainListBuilder
+}
 
-	// for each quote date list, retrieve expire dates
-	for _, d := range quoteDateList {
-		qd, ok := quoteDate[d]
-		if !ok {
-			return nil, errors.Errorf("Invalid quote date %+v", d)
+// chainListBuilder builds the chain of a single underlying and root
+type chainListBuilder struct {
+	key      ChainKey
+	quoteMap map[time.Time]*OptChain
+	quotes   []time.Time
+}
+
+// NewOptionChainBuilder creates an empty option chain builder
+func NewOptionChainBuilder() *OptionChainBuilder {
+	return &OptionChainBuilder{
+		lists: make(map[ChainKey]*chainListBuilder),
+	}
+}
+
+// Add adds an OHLCV to the chain of its underlying and root. If the same contract is added twice, the later one wins
+func (b *OptionChainBuilder) Add(opt OHLCV) {
+	key := NewChainKey(opt.UndSym, opt.Root)
+	l, ok := b.lists[key]
+	if !ok {
+		l = &chainListBuilder{
+			key:      key,
+			quoteMap: make(map[time.Time]*OptChain),
+			quotes:   make([]time.Time, 0),
 		}
-		optChain := OptChain{}
+		b.lists[key] = l
+	}
+	l.add(opt)
+}
 
-		// expiry date to ohlcv
-		expiryMap := make(map[time.Time][]OHLCV)
-		expiry := make([]time.Time, 0)
+func (b *chainListBuilder) add(opt OHLCV) {
+	optChain, ok := b.quoteMap[opt.QuoteDate]
+	if !ok {
+		optChain = &OptChain{
+			QuoteDate: opt.QuoteDate,
+			expiryMap: make(map[time.Time]*OptChainExp),
+			expiry:    make([]time.Time, 0),
+		}
+		b.quoteMap[opt.QuoteDate] = optChain
+		b.quotes = append(b.quotes, opt.QuoteDate)
+	}
 
-		var underlying *OHLCV
-		for _, opt := range qd {
-			// get underlying avg price
-			if underlying == nil {
-				if !opt.UndBid.IsZero() && !opt.UndAsk.IsZero() {
-					underlying = &opt
-				}
-			}
-			if _, ok := expiryMap[opt.Expiration]; !ok {
-				expiryMap[opt.Expiration] = make([]OHLCV, 0)
-				expiry = append(expiry, opt.Expiration)
-			}
-			expiryMap[opt.Expiration] = append(expiryMap[opt.Expiration], opt)
+	// get underlying avg price
+	if optChain.UndPx.IsZero() && !opt.UndBid.IsZero() && !opt.UndAsk.IsZero() {
+		optChain.UndPx = opt.UndBid.Add(opt.UndAsk).Div(decimal.NewFromFloat(2.0))
+	}
+	if optChain.UndPx1545.IsZero() && !opt.UndBid1545.IsZero() && !opt.UndAsk1545.IsZero() {
+		optChain.UndPx1545 = opt.UndBid1545.Add(opt.UndAsk1545).Div(decimal.NewFromFloat(2.0))
+	}
+
+	expChain, ok := optChain.expiryMap[opt.Expiration]
+	if !ok {
+		expChain = &OptChainExp{
+			ExpireDate: opt.Expiration,
+			strike:     make([]decimal.Decimal, 0),
+			strikeMap:  make(map[string]*OptChainStrike),
+		}
+		optChain.expiryMap[opt.Expiration] = expChain
+		optChain.expiry = append(optChain.expiry, opt.Expiration)
+	}
+
+	key := opt.Strike.String()
+	strike, ok := expChain.strikeMap[key]
+	if !ok {
+		strike = &OptChainStrike{
+			S:   opt.Strike,
+			Exp: opt.Expiration,
+		}
+		expChain.strikeMap[key] = strike
+		expChain.strike = append(expChain.strike, opt.Strike)
+	}
+	if opt.Type == Call {
+		strike.Call = opt
+	} else if opt.Type == Put {
+		strike.Put = opt
+	}
+}
+
+// Build sorts quote dates, expiries and strikes and returns the option chain. It fails if rows of more than one underlying or root were added, use BuildSet for those. The builder should not be used after Build
+func (b *OptionChainBuilder) Build() (*OptChainList, error) {
+	set, err := b.BuildSet()
+	if err != nil {
+		return nil, err
+	}
+	if len(set.keys) == 0 {
+		return &OptChainList{
+			quoteMap: make(map[time.Time]*OptChain),
+			quotes:   make([]time.Time, 0),
+		}, nil
+	}
+	return set.Select("", "")
+}
+
+// BuildSet sorts quote dates, expiries and strikes and returns one option chain per underlying and root. The builder should not be used after BuildSet
+func (b *OptionChainBuilder) BuildSet() (*OptChainSet, error) {
+	set := &OptChainSet{
+		chains: make(map[ChainKey]*OptChainList, len(b.lists)),
+		keys:   make([]ChainKey, 0, len(b.lists)),
+	}
+	for key, l := range b.lists {
+		list, err := l.build()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error building option chain for %s", key)
+		}
+		set.chains[key] = list
+		set.keys = append(set.keys, key)
+	}
+	sort.Slice(set.keys, func(i, j int) bool {
+		if set.keys[i].UndSym != set.keys[j].UndSym {
+			return set.keys[i].UndSym < set.keys[j].UndSym
+		}
+		return set.keys[i].Root < set.keys[j].Root
+	})
+	return set, nil
+}
+
+func (b *chainListBuilder) build() (*OptChainList, error) {
+	sort.Slice(b.quotes, func(i, j int) bool {
+		return b.quotes[i].Before(b.quotes[j])
+	})
+	for _, d := range b.quotes {
+		optChain, ok := b.quoteMap[d]
+		if !ok {
+			return nil, errors.Errorf("Invalid quote date %+v", d)
 		}
-		optChain.QuoteDate = d
-		optChain.expiry = expiry
 		sort.Slice(optChain.expiry, func(i, j int) bool {
 			return optChain.expiry[i].Before(optChain.expiry[j])
 		})
-		if underlying != nil {
-			optChain.UndPx = underlying.UndBid.Add(underlying.UndAsk).Div(decimal.NewFromFloat(2.0))
-		}
-
-		optExpiryMap := make(map[time.Time]*OptChainExp)
-		for _, exp := range expiry {
-			strikes := make([]decimal.Decimal, 0)
-			strikeMap := make(map[string]*OptChainStrike)
-			ohlcvs := expiryMap[exp]
-			for _, ohlcv := range ohlcvs {
-				if _, ok := strikeMap[ohlcv.Strike.String()]; !ok {
-					strikeMap[ohlcv.Strike.String()] = &OptChainStrike{
-						S:   ohlcv.Strike,
-						Exp: exp,
-					}
-					strikes = append(strikes, ohlcv.Strike)
-				}
-				if ohlcv.Type == Call {
-					strikeMap[ohlcv.Strike.String()].Call = ohlcv
-				} else if ohlcv.Type == Put {
-					strikeMap[ohlcv.Strike.String()].Put = ohlcv
-				}
-			}
+		for _, exp := range optChain.expiryMap {
+			strikes := exp.strike
 			sort.Slice(strikes, func(i, j int) bool {
 				return strikes[i].LessThan(strikes[j])
 			})
-			optExpiryMap[exp] = &OptChainExp{
-				ExpireDate: exp,
-				strike:     strikes,
-				strikeMap:  strikeMap,
-			}
 		}
-		optChain.expiryMap = optExpiryMap
-		ocs[d] = &optChain
 	}
+	return &OptChainList{
+		quoteMap: b.quoteMap,
+		quotes:   b.quotes,
+		key:      b.key,
+	}, nil
+}
+
+// Key returns the underlying and root of the chain
+func (o *OptChainList) Key() ChainKey {
+	return o.key
+}
 
-	optChainList.quoteMap = ocs
+// Underlying returns the daily price history of the underlying, or nil if none was set
+func (o *OptChainList) Underlying() *UnderlyingSeries {
+	return o.und
+}
+
+// SetUnderlying sets the daily price history of the underlying
+func (o *OptChainList) SetUnderlying(s *UnderlyingSeries) {
+	o.und = s
+}
+
+// CorporateActions returns the dividend and split calendar of the underlying, or nil if none was set
+func (o *OptChainList) CorporateActions() *CorporateActions {
+	return o.actions
+}
+
+// SetCorporateActions sets the dividend and split calendar of the underlying
+func (o *OptChainList) SetCorporateActions(c *CorporateActions) {
+	o.actions = c
+}
 
-	return optChainList, nil
+// RateCurve returns the risk-free rate curve, or nil if none was set
+func (o *OptChainList) RateCurve() *RateCurve {
+	return o.rates
+}
+
+// SetRateCurve sets the risk-free rate curve
+func (o *OptChainList) SetRateCurve(c *RateCurve) {
+	o.rates = c
+}
+
+// UndPxAt returns the mid price of the underlying at the snapshot
+func (o *OptChain) UndPxAt(s Snapshot) decimal.Decimal {
+	if s == Snapshot1545 {
+		return o.UndPx1545
+	}
+	return o.UndPx
+}
+
+// ValidateSnapshot checks that every quote date has an underlying price at the snapshot, and that every option quoted at the end of day is quoted at the snapshot too. Data without 15:45 columns, such as JPX imports, has zero 15:45 prices that strikes would be picked and filled at
+func (o *OptChainList) ValidateSnapshot(s Snapshot) error {
+	for _, d := range o.quotes {
+		quote := o.quoteMap[d]
+		if !quote.UndPxAt(s).IsPositive() {
+			return errors.Errorf("Expected an underlying price at the %+v snapshot on %+v but got none", s, d.Format(DateLayout))
+		}
+		for _, e := range quote.expiry {
+			exp := quote.expiryMap[e]
+			for _, k := range exp.strike {
+				strike := exp.strikeMap[k.String()]
+				for _, opt := range []OHLCV{strike.Call, strike.Put} {
+					if opt.Type != "" && opt.HasQuoteAt(SnapshotEOD) && !opt.HasQuoteAt(s) {
+						return errors.Errorf("Expected a quote at the %+v snapshot for the %+v %+v %+v on %+v but got none",
+							s, k, opt.Type, e.Format(DateLayout), d.Format(DateLayout))
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // GetOptionChainForQuoteDate returns an option chain for the quote date. If strict is false, it will find a nearest date after the specified date.
@@ -142,6 +338,17 @@ func (o *OptChainList) GetOptionChainForQuoteDate(t time.Time, strict bool) *Opt
 	return o.quoteMap[newt]
 }
 
+// GetLastOptionChainForQuoteDate gets the option chain of the last quote date on or before the specified date. It returns nil if there is none
+func (o *OptChainList) GetLastOptionChainForQuoteDate(t time.Time) *OptChain {
+	i := sort.Search(len(o.quotes), func(i int) bool {
+		return o.quotes[i].After(t)
+	})
+	if i == 0 {
+		return nil
+	}
+	return o.quoteMap[o.quotes[i-1]]
+}
+
 // searchQuote will find a quote that is closest to this time. TODO convert this into binary search for faster lookup
 func (o *OptChainList) searchQuote(t time.Time) time.Time {
 	for i := 0; i < len(o.quotes); i++ {
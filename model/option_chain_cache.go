@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/gob"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// optChainListGob is the serialized form of OptChainList. Quote dates, expiries and strikes are kept in their sorted order so decoding does not need to sort again.
+type optChainListGob struct {
+	Key    ChainKey
+	Chains []optChainGob
+}
+
+type optChainGob struct {
+	QuoteDate time.Time
+	UndPx     decimal.Decimal
+	UndPx1545 decimal.Decimal
+	Expiries  []optChainExpGob
+}
+
+type optChainExpGob struct {
+	ExpireDate time.Time
+	Strikes    []OptChainStrike
+}
+
+// Encode writes the option chain in a binary form that can be read back with DecodeOptionChain. The underlying price history is not included.
+func (o *OptChainList) Encode(w io.Writer) error {
+	v := optChainListGob{
+		Key:    o.key,
+		Chains: make([]optChainGob, 0, len(o.quotes)),
+	}
+	for _, d := range o.quotes {
+		chain := o.quoteMap[d]
+		c := optChainGob{
+			QuoteDate: chain.QuoteDate,
+			UndPx:     chain.UndPx,
+			UndPx1545: chain.UndPx1545,
+			Expiries:  make([]optChainExpGob, 0, len(chain.expiry)),
+		}
+		for _, e := range chain.expiry {
+			exp := chain.expiryMap[e]
+			strikes := make([]OptChainStrike, 0, len(exp.strike))
+			for _, s := range exp.strike {
+				strikes = append(strikes, *exp.strikeMap[s.String()])
+			}
+			c.Expiries = append(c.Expiries, optChainExpGob{
+				ExpireDate: exp.ExpireDate,
+				Strikes:    strikes,
+			})
+		}
+		v.Chains = append(v.Chains, c)
+	}
+	if err := gob.NewEncoder(w).Encode(v); err != nil {
+		return errors.Wrap(err, "Error encoding option chain")
+	}
+	return nil
+}
+
+// DecodeOptionChain reads an option chain written by Encode
+func DecodeOptionChain(r io.Reader) (*OptChainList, error) {
+	var v optChainListGob
+	if err := gob.NewDecoder(r).Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "Error decoding option chain")
+	}
+	list := &OptChainList{
+		quoteMap: make(map[time.Time]*OptChain, len(v.Chains)),
+		quotes:   make([]time.Time, 0, len(v.Chains)),
+		key:      v.Key,
+	}
+	for _, c := range v.Chains {
+		chain := &OptChain{
+			QuoteDate: c.QuoteDate,
+			UndPx:     c.UndPx,
+			UndPx1545: c.UndPx1545,
+			expiryMap: make(map[time.Time]*OptChainExp, len(c.Expiries)),
+			expiry:    make([]time.Time, 0, len(c.Expiries)),
+		}
+		for _, e := range c.Expiries {
+			exp := &OptChainExp{
+				ExpireDate: e.ExpireDate,
+				strike:     make([]decimal.Decimal, 0, len(e.Strikes)),
+				strikeMap:  make(map[string]*OptChainStrike, len(e.Strikes)),
+			}
+			for i := range e.Strikes {
+				s := &e.Strikes[i]
+				exp.strike = append(exp.strike, s.S)
+				exp.strikeMap[s.S.String()] = s
+			}
+			chain.expiryMap[e.ExpireDate] = exp
+			chain.expiry = append(chain.expiry, e.ExpireDate)
+		}
+		list.quoteMap[c.QuoteDate] = chain
+		list.quotes = append(list.quotes, c.QuoteDate)
+	}
+	return list, nil
+}
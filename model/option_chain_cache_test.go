@@ -0,0 +1,86 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestOptionChainEncode(t *testing.T) {
+	june1, _ := time.Parse(DateLayout, "2016-06-01")
+	june2, _ := time.Parse(DateLayout, "2016-06-02")
+	july2, _ := time.Parse(DateLayout, "2016-07-02")
+	aug1, _ := time.Parse(DateLayout, "2016-08-01")
+	ohlcv0, _ := NewOHLCV(june2, "SPY", aug1, "118", Call, "1", "1", "1", "1", "623", "1.2", "1", "115.5", "116.5")
+	ohlcv0, _ = ohlcv0.WithGreeks("0.2", "0.5", "0.01", "-0.02", "0.1", "0.03")
+	ohlcv1, _ := NewOHLCV(june1, "SPY", july2, "116", Call, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
+	ohlcv2, _ := NewOHLCV(june1, "SPY", july2, "114", Put, "0.5", "0.5", "0.5", "0.5", "623", "0.5", "0.5", "115.5", "116.5")
+	ohlcv2, _ = ohlcv2.WithSnapshot1545("1", "0.4", "1", "0.6", "114.5", "115.5")
+
+	chain, err := NewOptionChain([]OHLCV{ohlcv0, ohlcv1, ohlcv2})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new option chain"))
+	}
+	var buf bytes.Buffer
+	if err := chain.Encode(&buf); err != nil {
+		t.Fatal(errors.Wrap(err, "Error encoding option chain"))
+	}
+	decoded, err := DecodeOptionChain(&buf)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error decoding option chain"))
+	}
+
+	tests := []struct {
+		quote  time.Time
+		exp    time.Time
+		strike string
+		typ    OptType
+		ohlcv  OHLCV
+		undpx  decimal.Decimal
+	}{
+		{june1, july2, "116", Call, ohlcv1, decimal.NewFromInt(116)},
+		{june1, july2, "114", Put, ohlcv2, decimal.NewFromInt(116)},
+		{june2, aug1, "118", Call, ohlcv0, decimal.NewFromInt(116)},
+	}
+	for idx, test := range tests {
+		oc := decoded.GetOptionChainForQuoteDate(test.quote, true)
+		if oc == nil {
+			t.Fatalf("Expected option chain for %+v at idx: %d", test.quote, idx)
+		}
+		if !oc.UndPx.Equal(test.undpx) {
+			t.Errorf("Expected underlying price to be %+v but got %+v at idx: %d", test.undpx, oc.UndPx, idx)
+		}
+		exp := oc.GetOptionChainForExpiryDate(test.exp, true)
+		if exp == nil {
+			t.Fatalf("Expected expiry %+v at idx: %d", test.exp, idx)
+		}
+		s := exp.GetOptionChainForStrike(decimal.RequireFromString(test.strike), true)
+		if s == nil {
+			t.Fatalf("Expected strike %+v at idx: %d", test.strike, idx)
+		}
+		got := s.Call
+		if test.typ == Put {
+			got = s.Put
+		}
+		if !got.AskBidMid.Equal(test.ohlcv.AskBidMid) || !got.Bid1545.Equal(test.ohlcv.Bid1545) || !got.IV.Equal(test.ohlcv.IV) || got.HasGreeks != test.ohlcv.HasGreeks {
+			t.Errorf("Expected %+v but got %+v at idx: %d", test.ohlcv, got, idx)
+		}
+	}
+
+	// non strict lookups still work since sorted order is kept
+	oc := decoded.GetOptionChainForQuoteDate(june1.AddDate(0, 0, -1), false)
+	if oc == nil || !oc.QuoteDate.Equal(june1) {
+		t.Errorf("Expected the nearest quote date to be %+v but got %+v", june1, oc)
+	}
+	exp := oc.GetOptionChainForExpiryDate(june2, false)
+	if exp == nil || !exp.ExpireDate.Equal(july2) {
+		t.Fatalf("Expected the nearest expiry to be %+v but got %+v", july2, exp)
+	}
+	s := exp.GetOptionChainForStrike(decimal.NewFromInt(113), false)
+	if s == nil || !s.S.Equal(decimal.NewFromInt(114)) {
+		t.Errorf("Expected the nearest strike to be 114 but got %+v", s)
+	}
+}
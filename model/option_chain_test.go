@@ -16,6 +16,7 @@ func TestOptionChain(t *testing.T) {
 	july2, _ := time.Parse(DateLayout, "2016-07-02")
 	aug1, _ := time.Parse(DateLayout, "2016-08-01")
 	ohlcv0, _ := NewOHLCV(june1, "SPY", july2, "118", Call, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
+	ohlcv0, _ = ohlcv0.WithSnapshot1545("1", "1", "1", "1", "114.5", "115.5")
 	ohlcv1, _ := NewOHLCV(june1, "SPY", july2, "116", Call, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
 	ohlcv2, _ := NewOHLCV(june1, "SPY", july2, "116", Put, "0.5", "0.5", "0.5", "0.5", "623", "0.5", "0.5", "115.5", "116.5")
 	ohlcv3, _ := NewOHLCV(july2, "SPY", july2, "116", Call, "0.5", "0.5", "0.5", "0.5", "623", "0.5", "0.5", "117.5", "118.5")
@@ -37,6 +38,14 @@ func TestOptionChain(t *testing.T) {
 		t.Error(errors.Errorf("Expected to be nil but got %+v", augchain))
 	}
 
+	// should get the last option chain on or before the date, and none before the first quote date
+	if last := chain.GetLastOptionChainForQuoteDate(june20); last == nil || !last.QuoteDate.Equal(june1) {
+		t.Error(errors.Errorf("Expected last option chain before %+v to be quoted on %+v but got %+v", june20, june1, last))
+	}
+	if last := chain.GetLastOptionChainForQuoteDate(may1); last != nil {
+		t.Error(errors.Errorf("Expected to be nil but got %+v", last))
+	}
+
 	// should not get optoin chain for quote date if it's strict and it doesn't exist on that date
 	maychain := chain.GetOptionChainForQuoteDate(may1, true)
 	if maychain != nil {
@@ -57,6 +66,10 @@ func TestOptionChain(t *testing.T) {
 		t.Error(errors.Errorf("Expected underlying price to be %+v but got %+v", 116, oc.UndPx.String()))
 	}
 
+	if !oc.UndPxAt(Snapshot1545).Equal(decimal.NewFromFloat(115)) {
+		t.Error(errors.Errorf("Expected 15:45 underlying price to be %+v but got %+v", 115, oc.UndPxAt(Snapshot1545).String()))
+	}
+
 	// should not get options for expiry date if it's strict and data doesn't exist
 	nooc1 := oc.GetOptionChainForExpiryDate(june1, true)
 	if nooc1 != nil {
@@ -119,3 +132,109 @@ func TestOptionChain(t *testing.T) {
 		))
 	}
 }
+
+func TestOptionChainBuilder(t *testing.T) {
+	june1, _ := time.Parse(DateLayout, "2016-06-01")
+	june2, _ := time.Parse(DateLayout, "2016-06-02")
+	july2, _ := time.Parse(DateLayout, "2016-07-02")
+	aug1, _ := time.Parse(DateLayout, "2016-08-01")
+
+	b := NewOptionChainBuilder()
+	// rows are added out of order, as they would be when streaming several files
+	late, _ := NewOHLCV(june2, "SPY", july2, "116", Call, "1", "1", "1", "1", "10", "1", "1", "116.5", "115.5")
+	far, _ := NewOHLCV(june1, "SPY", aug1, "120", Call, "1", "1", "1", "1", "10", "2", "2", "116.5", "115.5")
+	high, _ := NewOHLCV(june1, "SPY", july2, "118", Call, "1", "1", "1", "1", "10", "1", "1", "116.5", "115.5")
+	low, _ := NewOHLCV(june1, "SPY", july2, "114", Call, "1", "1", "1", "1", "10", "3", "3", "116.5", "115.5")
+	dup, _ := NewOHLCV(june1, "SPY", july2, "114", Call, "1", "1", "1", "1", "10", "4", "4", "116.5", "115.5")
+	for _, v := range []OHLCV{late, far, high, low, dup} {
+		b.Add(v)
+	}
+	chain, err := b.Build()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error building option chain"))
+	}
+
+	oc := chain.GetOptionChainForQuoteDate(june1, false)
+	if oc == nil || !oc.QuoteDate.Equal(june1) {
+		t.Fatalf("Expected the earliest quote date to be %+v but got %+v", june1, oc)
+	}
+	exp := oc.GetOptionChainForExpiryDate(june1, false)
+	if exp == nil || !exp.ExpireDate.Equal(july2) {
+		t.Fatalf("Expected the nearest expiry to be %+v but got %+v", july2, exp)
+	}
+	strike := exp.GetOptionChainForStrike(decimal.NewFromInt(113), false)
+	if strike == nil || !strike.S.Equal(decimal.NewFromInt(114)) {
+		t.Fatalf("Expected the nearest strike to be 114 but got %+v", strike)
+	}
+	// the later duplicate wins
+	if !strike.Call.Ask.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("Expected the duplicate row to replace the first but got ask %+v", strike.Call.Ask)
+	}
+}
+
+func TestOptionChainSet(t *testing.T) {
+	june1, _ := time.Parse(DateLayout, "2016-06-01")
+	july2, _ := time.Parse(DateLayout, "2016-07-02")
+	b := NewOptionChainBuilder()
+	vix, _ := NewOHLCV(june1, "^VIX", july2, "15", Call, "1", "1", "1", "1", "10", "1", "1", "14.5", "15.5")
+	vix, _ = vix.WithDetail("VIX", "", "", "", "", "")
+	spy, _ := NewOHLCV(june1, "SPY", july2, "15", Call, "1", "1", "1", "1", "10", "2", "2", "209.5", "210.5")
+	spy, _ = spy.WithDetail("SPY", "", "", "", "", "")
+	spy7, _ := NewOHLCV(june1, "SPY", july2, "15", Call, "1", "1", "1", "1", "10", "3", "3", "209.5", "210.5")
+	spy7, _ = spy7.WithDetail("SPY7", "", "", "", "", "")
+	for _, v := range []OHLCV{vix, spy, spy7} {
+		b.Add(v)
+	}
+	set, err := b.BuildSet()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error building option chain set"))
+	}
+	keys := set.Keys()
+	expKeys := []ChainKey{{"SPY", "SPY"}, {"SPY", "SPY7"}, {"^VIX", "VIX"}}
+	if len(keys) != len(expKeys) {
+		t.Fatalf("Expected keys %+v but got %+v", expKeys, keys)
+	}
+	for idx, k := range keys {
+		if k != expKeys[idx] {
+			t.Errorf("Expected key to be %+v but got %+v at idx: %d", expKeys[idx], k, idx)
+		}
+	}
+
+	tests := []struct {
+		symbol string
+		root   string
+		ok     bool
+		undpx  decimal.Decimal
+		ask    decimal.Decimal
+	}{
+		{"^VIX", "", true, decimal.NewFromInt(15), decimal.NewFromInt(1)},
+		{"SPY", "SPY", true, decimal.NewFromInt(210), decimal.NewFromInt(2)},
+		{"SPY", "SPY7", true, decimal.NewFromInt(210), decimal.NewFromInt(3)},
+		{"SPY", "", false, decimal.Zero, decimal.Zero},
+		{"", "", false, decimal.Zero, decimal.Zero},
+		{"QQQ", "", false, decimal.Zero, decimal.Zero},
+	}
+	for idx, test := range tests {
+		chain, err := set.Select(test.symbol, test.root)
+		if (err == nil) != test.ok {
+			t.Errorf("Expected ok to be %+v but got error %+v at idx: %d", test.ok, err, idx)
+			continue
+		}
+		if !test.ok {
+			continue
+		}
+		oc := chain.GetOptionChainForQuoteDate(june1, true)
+		if !oc.UndPx.Equal(test.undpx) {
+			t.Errorf("Expected underlying price to be %+v but got %+v at idx: %d", test.undpx, oc.UndPx, idx)
+		}
+		s := oc.GetOptionChainForExpiryDate(july2, true).GetOptionChainForStrike(decimal.NewFromInt(15), true)
+		if !s.Call.Ask.Equal(test.ask) {
+			t.Errorf("Expected ask to be %+v but got %+v at idx: %d", test.ask, s.Call.Ask, idx)
+		}
+	}
+
+	// Build refuses to mix underlyings
+	if _, err := NewOptionChain([]OHLCV{vix, spy}); err == nil {
+		t.Errorf("Expected an error building a single chain from two underlyings")
+	}
+}
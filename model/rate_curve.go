@@ -0,0 +1,107 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// RatePoint is an annualized risk-free rate of a tenor on a date
+type RatePoint struct {
+	// Date is the date the rate was observed
+	Date time.Time
+	// Rate is the annualized rate as a fraction, e.g. 0.015 for 1.5 %
+	Rate decimal.Decimal
+	// Tenor is the number of calendar days to maturity
+	Tenor int
+}
+
+// RateCurve is a daily history of risk-free rates by tenor
+type RateCurve struct {
+	dates []time.Time
+	// curves has the points of dates at the same index, in ascending order of tenor
+	curves   [][]RatePoint
+	fallback decimal.Decimal
+}
+
+// NewRateCurve creates a curve from points in any order. If two points have the same date and tenor, the later one wins. fallback is the rate used before the first date, or for every date if there are no points.
+func NewRateCurve(points []RatePoint, fallback decimal.Decimal) (*RateCurve, error) {
+	type key struct {
+		date  time.Time
+		tenor int
+	}
+	byKey := make(map[key]RatePoint, len(points))
+	for _, p := range points {
+		if p.Date.IsZero() {
+			return nil, errors.Errorf("Expected a date for rate point %+v", p)
+		}
+		if p.Tenor <= 0 {
+			return nil, errors.Errorf("Expected tenor to be positive but got %+v", p)
+		}
+		byKey[key{p.Date, p.Tenor}] = p
+	}
+	sorted := make([]RatePoint, 0, len(byKey))
+	for _, p := range byKey {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].Date.Equal(sorted[j].Date) {
+			return sorted[i].Date.Before(sorted[j].Date)
+		}
+		return sorted[i].Tenor < sorted[j].Tenor
+	})
+
+	c := &RateCurve{fallback: fallback}
+	for _, p := range sorted {
+		last := len(c.dates) - 1
+		if last < 0 || !c.dates[last].Equal(p.Date) {
+			c.dates = append(c.dates, p.Date)
+			c.curves = append(c.curves, []RatePoint{p})
+			continue
+		}
+		c.curves[last] = append(c.curves[last], p)
+	}
+	return c, nil
+}
+
+// Points returns every point in ascending order of date and tenor
+func (c *RateCurve) Points() []RatePoint {
+	points := make([]RatePoint, 0)
+	for _, curve := range c.curves {
+		points = append(points, curve...)
+	}
+	return points
+}
+
+// Fallback returns the rate used when there is no curve on or before a date
+func (c *RateCurve) Fallback() decimal.Decimal {
+	return c.fallback
+}
+
+// Rate returns the annualized rate for days to maturity on t. It uses the latest curve on or before t, so rates published after t are never used, and interpolates linearly between tenors. Days outside of the curve's tenors get the nearest tenor's rate. It returns zero for a nil curve.
+func (c *RateCurve) Rate(t time.Time, days int) decimal.Decimal {
+	if c == nil {
+		return decimal.Zero
+	}
+	idx := sort.Search(len(c.dates), func(i int) bool {
+		return c.dates[i].After(t)
+	}) - 1
+	if idx < 0 {
+		return c.fallback
+	}
+	curve := c.curves[idx]
+	i := sort.Search(len(curve), func(i int) bool {
+		return curve[i].Tenor >= days
+	})
+	if i == 0 {
+		return curve[0].Rate
+	}
+	if i == len(curve) {
+		return curve[len(curve)-1].Rate
+	}
+	lo, hi := curve[i-1], curve[i]
+	w := decimal.NewFromInt(int64(days - lo.Tenor)).Div(decimal.NewFromInt(int64(hi.Tenor - lo.Tenor)))
+	return lo.Rate.Add(hi.Rate.Sub(lo.Rate).Mul(w))
+}
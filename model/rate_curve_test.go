@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestRateCurve(t *testing.T) {
+	d1 := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+	points := []RatePoint{
+		{Date: d2, Tenor: 30, Rate: decimal.NewFromFloat(0.01)},
+		{Date: d1, Tenor: 30, Rate: decimal.NewFromFloat(0.015)},
+		{Date: d1, Tenor: 90, Rate: decimal.NewFromFloat(0.018)},
+		{Date: d1, Tenor: 365, Rate: decimal.NewFromFloat(0.02)},
+	}
+	c, err := NewRateCurve(points, decimal.NewFromFloat(0.03))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating rate curve"))
+	}
+	tt := []struct {
+		date time.Time
+		days int
+		rate string
+	}{
+		// before the first date
+		{date: d1.AddDate(0, 0, -1), days: 30, rate: "0.03"},
+		{date: d1, days: 30, rate: "0.015"},
+		{date: d1, days: 60, rate: "0.0165"},
+		{date: d1, days: 7, rate: "0.015"},
+		{date: d1, days: 730, rate: "0.02"},
+		// the latest curve on or before the date
+		{date: d1.AddDate(0, 0, 2), days: 90, rate: "0.018"},
+		{date: d2.AddDate(0, 0, 10), days: 90, rate: "0.01"},
+	}
+	for idx, tab := range tt {
+		rate := c.Rate(tab.date, tab.days)
+		if !rate.Equal(decimal.RequireFromString(tab.rate)) {
+			t.Errorf("Expected rate to be %+v but got %+v at idx: %d", tab.rate, rate, idx)
+		}
+	}
+
+	var none *RateCurve
+	if !none.Rate(d1, 30).IsZero() {
+		t.Errorf("Expected zero rate for nil curve but got %+v", none.Rate(d1, 30))
+	}
+	if _, err := NewRateCurve([]RatePoint{{Date: d1, Tenor: 0}}, decimal.Zero); err == nil {
+		t.Errorf("Expected error for zero tenor")
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Settlement is the time of day at which expiring options settle
+type Settlement int
+
+const (
+	// SettlementAuto settles the roots in AMSettledRoots in the morning and every other root at the close. This is the default
+	SettlementAuto Settlement = iota
+	// SettlementPM settles at the close of the last trading session
+	SettlementPM
+	// SettlementAM settles at the opening price of the last trading session
+	SettlementAM
+)
+
+// AMSettledRoots are the roots of index options settled at the opening price of the expiration day, such as standard SPX options. Their weekly roots, e.g. SPXW, settle at the close. Nikkei 225 options, imported with the N225 root, settle at the special quotation of the opening prices on their SQ date
+var AMSettledRoots = map[string]bool{
+	"DJX":  true,
+	"N225": true,
+	"NDX":  true,
+	"RUT":  true,
+	"SPX":  true,
+	"VIX":  true,
+}
+
+// IsAM returns true if expiries of the root settle at the opening price
+func (s Settlement) IsAM(root string) bool {
+	switch s {
+	case SettlementAM:
+		return true
+	case SettlementPM:
+		return false
+	default:
+		return AMSettledRoots[strings.ToUpper(root)]
+	}
+}
+
+// ParseSettlement parses "auto", "am" or "pm" into a Settlement
+func ParseSettlement(s string) (Settlement, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return SettlementAuto, nil
+	case "am":
+		return SettlementAM, nil
+	case "pm":
+		return SettlementPM, nil
+	default:
+		return SettlementAuto, errors.Errorf("Unsupported settlement %+v, expected auto, am or pm", s)
+	}
+}
+
+// String returns the settlement name accepted by ParseSettlement
+func (s Settlement) String() string {
+	switch s {
+	case SettlementAM:
+		return "am"
+	case SettlementPM:
+		return "pm"
+	default:
+		return "auto"
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestSettlement(t *testing.T) {
+	tt := []struct {
+		flag string
+		root string
+		am   bool
+	}{
+		{flag: "", root: "SPX", am: true},
+		{flag: "auto", root: "SPXW", am: false},
+		{flag: "auto", root: "", am: false},
+		{flag: "auto", root: "N225", am: true},
+		{flag: "pm", root: "SPX", am: false},
+		{flag: "am", root: "SPY", am: true},
+	}
+	for idx, tab := range tt {
+		s, err := ParseSettlement(tab.flag)
+		if err != nil {
+			t.Fatalf("Error parsing settlement %+v: %+v", tab.flag, err)
+		}
+		if s.IsAM(tab.root) != tab.am {
+			t.Errorf("Expected AM settlement to be %+v but got %+v at idx: %d", tab.am, s.IsAM(tab.root), idx)
+		}
+	}
+	if _, err := ParseSettlement("noon"); err == nil {
+		t.Errorf("Expected error for unsupported settlement")
+	}
+}
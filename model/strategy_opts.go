@@ -1,6 +1,7 @@
 package model
 
 import (
+	"backtest-options/calendar"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -19,17 +20,71 @@ const (
 
 // StrategyOpts is an argument for strategy
 type StrategyOpts struct {
+	// Calendar is the trading calendar of the exchange. It is used to count DTE in trading days, to tell missing quotes from holidays and to settle expiries on holidays. It may be nil
+	Calendar *calendar.Calendar
+	// CallStrike selects the strike of call legs. The strike nearest to the underlying price, or the PIP target price, is used if it is zero
+	CallStrike StrikeTarget
+	// EntryIV restricts the quote dates positions are opened on to those whose IV rank and percentile are within it
+	EntryIV IVRange
 	// ExecMethod is an order execution method
 	ExecMethod ExecMethod
+	// IVLookback is the number of quote dates IV rank and percentile are over
+	IVLookback int
+	// IVTenorDays is the constant maturity in calendar days of the at the money implied volatility
+	IVTenorDays int
 	// MinExpDays is a minimum number of expiring days
 	MinExpDays int
+	// PutStrike selects the strike of put legs. The PIP target price is used if it is zero
+	PutStrike StrikeTarget
+	// Settlement is the time of day expiring options settle at. The default settles by root
+	Settlement Settlement
+	// Snapshot is the quote snapshot used for fills and the underlying price. The default is the end of day quote
+	Snapshot Snapshot
+	// SurfacePricing prices legs whose strike and expiry are missing from the data on the quote date they are closed on from its volatility surface, instead of using the nearest listed strike and expiry
+	SurfacePricing bool
+	// TradingDayDTE counts MinExpDays and the DTE of PipOpts in trading days of Calendar instead of calendar days
+	TradingDayDTE bool
 	// StartDate is the date in which the strategy starts executing
 	StartDate time.Time
-	// EndDate is the last date in which the strategy ends executing
-	EndDate string
+	// EndDate is the last date on which the strategy opens positions. Positions open on it are still closed after it. A zero date opens positions until the data ends
+	EndDate time.Time
 	PipOpts *PipOpts
 }
 
+// expiryGap is the number of calendar days between listed expiries that a minimum DTE may have to be extended by to reach the next one
+const expiryGap = 35
+
+// LookaheadDays is the number of calendar days after EndDate that quotes are needed for, so that positions opened on EndDate can settle
+func (o StrategyOpts) LookaheadDays() int {
+	days := o.MinExpDays
+	if o.PipOpts != nil && o.PipOpts.MinCallExpDTE > days {
+		// the put is closed when the call expires
+		days = o.PipOpts.MinCallExpDTE
+	}
+	if o.TradingDayDTE {
+		// five trading days a week, and a week for holidays
+		days = days*7/5 + 7
+	}
+	return days + expiryGap
+}
+
+// HistoryDays is the number of calendar days before StartDate that quotes are needed for, so that IV rank and percentile are over the full lookback when positions are opened by EntryIV
+func (o StrategyOpts) HistoryDays() int {
+	if o.EntryIV.IsZero() {
+		return 0
+	}
+	return IVHistoryDays(o.IVLookback)
+}
+
+// IVHistoryDays is the number of calendar days that lookback quote dates span
+func IVHistoryDays(lookback int) int {
+	if lookback <= 0 {
+		return 0
+	}
+	// five quote dates a week, and a week for holidays
+	return lookback*7/5 + 7
+}
+
 // PipOpts is an option custom for pip strategy
 type PipOpts struct {
 	// MinCallExpDTE is the minimum number of DTE until the next expiry for the call option
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLookaheadDays(t *testing.T) {
+	tt := []struct {
+		opts     StrategyOpts
+		expected int
+	}{
+		{opts: StrategyOpts{MinExpDays: 28}, expected: 63},
+		{opts: StrategyOpts{MinExpDays: 28, PipOpts: &PipOpts{MinCallExpDTE: 4, MinPutExpDTE: 150}}, expected: 63},
+		{opts: StrategyOpts{MinExpDays: 28, PipOpts: &PipOpts{MinCallExpDTE: 45, MinPutExpDTE: 150}}, expected: 80},
+		{opts: StrategyOpts{MinExpDays: 20, TradingDayDTE: true}, expected: 70},
+	}
+	for idx, tab := range tt {
+		if days := tab.opts.LookaheadDays(); days != tab.expected {
+			t.Errorf("Expected %d lookahead days but got %d at idx: %d", tab.expected, days, idx)
+		}
+	}
+}
+
+func TestHistoryDays(t *testing.T) {
+	tt := []struct {
+		opts     StrategyOpts
+		expected int
+	}{
+		{opts: StrategyOpts{IVLookback: 252}, expected: 0},
+		{opts: StrategyOpts{IVLookback: 252, EntryIV: IVRange{MinRank: decimal.NewFromInt(50)}}, expected: 359},
+		{opts: StrategyOpts{IVLookback: 20, EntryIV: IVRange{MaxPercentile: decimal.NewFromInt(30)}}, expected: 35},
+	}
+	for idx, tab := range tt {
+		if days := tab.opts.HistoryDays(); days != tab.expected {
+			t.Errorf("Expected %d history days but got %d at idx: %d", tab.expected, days, idx)
+		}
+	}
+}
+
+func TestIVHistoryDays(t *testing.T) {
+	tt := []struct {
+		lookback int
+		expected int
+	}{
+		{lookback: 0, expected: 0},
+		{lookback: 1, expected: 8},
+		{lookback: 252, expected: 359},
+	}
+	for idx, tab := range tt {
+		if days := IVHistoryDays(tab.lookback); days != tab.expected {
+			t.Errorf("Expected %d history days but got %d at idx: %d", tab.expected, days, idx)
+		}
+	}
+}
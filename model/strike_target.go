@@ -0,0 +1,107 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// StrikeTarget selects the strike of an option leg by delta, premium or percent out of the money. At most one of them is set, and the strike nearest to the underlying price is selected if none is
+type StrikeTarget struct {
+	// Delta selects the strike whose absolute delta is nearest to it, e.g. 0.3 for the 30 delta call or put
+	Delta decimal.Decimal
+	// OTMPct selects the strike nearest to this fraction out of the money, e.g. 0.05 for a call 5% above or a put 5% below the underlying price
+	OTMPct decimal.Decimal
+	// Premium selects the strike whose mid price is nearest to it
+	Premium decimal.Decimal
+}
+
+// IsZero returns true if no target is set
+func (t StrikeTarget) IsZero() bool {
+	return t.Delta.IsZero() && t.OTMPct.IsZero() && t.Premium.IsZero()
+}
+
+// Validate checks that at most one target is set and that it is in range
+func (t StrikeTarget) Validate() error {
+	set := 0
+	for _, v := range []decimal.Decimal{t.Delta, t.OTMPct, t.Premium} {
+		if !v.IsZero() {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.Errorf("Expected at most one of delta, premium and percent out of the money but got %+v", t)
+	}
+	if t.Delta.IsNegative() || t.Delta.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return errors.Errorf("Expected delta to be between 0 and 1 but got %+v", t.Delta)
+	}
+	if t.Premium.IsNegative() {
+		return errors.Errorf("Expected premium to be positive but got %+v", t.Premium)
+	}
+	if t.OTMPct.LessThanOrEqual(decimal.NewFromInt(-1)) {
+		return errors.Errorf("Expected percent out of the money to be above -1 but got %+v", t.OTMPct)
+	}
+	return nil
+}
+
+// GetOptionChainForTarget gets the strike of the target for options of the type, given the underlying price. It gets the strike nearest to undPx if the target is zero.
+func (o *OptChainExp) GetOptionChainForTarget(typ OptType, target StrikeTarget, undPx decimal.Decimal, s Snapshot) *OptChainStrike {
+	switch {
+	case !target.Delta.IsZero():
+		return o.GetOptionChainForDelta(typ, target.Delta)
+	case !target.Premium.IsZero():
+		return o.GetOptionChainForPremium(typ, target.Premium, s)
+	case !target.OTMPct.IsZero():
+		return o.GetOptionChainForOTM(typ, target.OTMPct, undPx)
+	default:
+		return o.GetOptionChainForStrike(undPx, false)
+	}
+}
+
+// GetOptionChainForDelta gets the strike whose option of the type has the absolute delta nearest to delta, e.g. 0.1 for the 10 delta put. Options without greeks are skipped, and nil is returned if no option has them.
+func (o *OptChainExp) GetOptionChainForDelta(typ OptType, delta decimal.Decimal) *OptChainStrike {
+	return o.nearest(typ, delta.Abs(), func(opt OHLCV) (decimal.Decimal, bool) {
+		return opt.Delta.Abs(), opt.HasIV
+	})
+}
+
+// GetOptionChainForPremium gets the strike whose option of the type has the mid price at the snapshot nearest to premium. Options without a quote are skipped.
+func (o *OptChainExp) GetOptionChainForPremium(typ OptType, premium decimal.Decimal, s Snapshot) *OptChainStrike {
+	return o.nearest(typ, premium, func(opt OHLCV) (decimal.Decimal, bool) {
+		mid := opt.MidAt(s)
+		return mid, mid.IsPositive()
+	})
+}
+
+// GetOptionChainForOTM gets the strike nearest to pct out of the money for options of the type, e.g. 0.05 for a call strike 5% above or a put strike 5% below undPx. A negative pct is in the money.
+func (o *OptChainExp) GetOptionChainForOTM(typ OptType, pct, undPx decimal.Decimal) *OptChainStrike {
+	if typ == Put {
+		pct = pct.Neg()
+	}
+	return o.GetOptionChainForStrike(undPx.Mul(decimal.NewFromInt(1).Add(pct)), false)
+}
+
+// nearest returns the strike whose option of the type has the value nearest to target. value returns false for options to skip. The lower strike wins ties.
+func (o *OptChainExp) nearest(typ OptType, target decimal.Decimal, value func(OHLCV) (decimal.Decimal, bool)) *OptChainStrike {
+	var best *OptChainStrike
+	var bestDiff decimal.Decimal
+	for _, k := range o.strike {
+		strike := o.strikeMap[k.String()]
+		opt := strike.Call
+		if typ == Put {
+			opt = strike.Put
+		}
+		if opt.Type != typ {
+			continue
+		}
+		v, ok := value(opt)
+		if !ok {
+			continue
+		}
+		diff := v.Sub(target).Abs()
+		if best == nil || diff.LessThan(bestDiff) {
+			best = strike
+			bestDiff = diff
+		}
+	}
+	return best
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestGetOptionChainForTarget(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	exp := quote.AddDate(0, 0, 30)
+	opt := func(k string, typ OptType, px, delta string) OHLCV {
+		o, _ := NewOHLCV(quote, "SPY", exp, k, typ, "0", "0", "0", "0", "0", px, px, "100", "100")
+		if delta != "" {
+			o, _ = o.WithGreeks("0.2", delta, "0", "0", "0", "0")
+		}
+		return o
+	}
+	chain, err := NewOptionChain([]OHLCV{
+		opt("95", Call, "6", "0.8"),
+		opt("100", Call, "2.5", "0.5"),
+		opt("105", Call, "0.8", "0.25"),
+		opt("110", Call, "0.2", ""),
+		opt("90", Put, "0.3", "-0.08"),
+		opt("95", Put, "1", "-0.2"),
+		opt("100", Put, "2.5", "-0.5"),
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	expchain := chain.GetOptionChainForQuoteDate(quote, true).GetOptionChainForExpiryDate(exp, true)
+	px := decimal.NewFromInt(100)
+
+	tt := []struct {
+		typ      OptType
+		target   StrikeTarget
+		expected string
+	}{
+		{typ: Call, target: StrikeTarget{}, expected: "100"},
+		{typ: Call, target: StrikeTarget{Delta: decimal.NewFromFloat(0.3)}, expected: "105"},
+		// the 110 call has no greeks
+		{typ: Call, target: StrikeTarget{Delta: decimal.NewFromFloat(0.01)}, expected: "105"},
+		{typ: Put, target: StrikeTarget{Delta: decimal.NewFromFloat(0.1)}, expected: "90"},
+		{typ: Call, target: StrikeTarget{Premium: decimal.NewFromFloat(0.4)}, expected: "110"},
+		{typ: Put, target: StrikeTarget{Premium: decimal.NewFromFloat(0.9)}, expected: "95"},
+		{typ: Call, target: StrikeTarget{OTMPct: decimal.NewFromFloat(0.05)}, expected: "105"},
+		{typ: Put, target: StrikeTarget{OTMPct: decimal.NewFromFloat(0.1)}, expected: "90"},
+		{typ: Call, target: StrikeTarget{OTMPct: decimal.NewFromFloat(-0.05)}, expected: "95"},
+	}
+	for idx, tab := range tt {
+		strike := expchain.GetOptionChainForTarget(tab.typ, tab.target, px, SnapshotEOD)
+		if strike == nil {
+			t.Fatalf("Expected strike %s but got nil at idx: %d", tab.expected, idx)
+		}
+		if strike.S.String() != tab.expected {
+			t.Errorf("Expected strike %s but got %s at idx: %d", tab.expected, strike.S, idx)
+		}
+	}
+}
+
+func TestStrikeTargetValidate(t *testing.T) {
+	tt := []struct {
+		target StrikeTarget
+		err    bool
+	}{
+		{target: StrikeTarget{}},
+		{target: StrikeTarget{Delta: decimal.NewFromFloat(0.3)}},
+		{target: StrikeTarget{Premium: decimal.NewFromFloat(1.5)}},
+		{target: StrikeTarget{OTMPct: decimal.NewFromFloat(-0.05)}},
+		{target: StrikeTarget{Delta: decimal.NewFromFloat(0.3), OTMPct: decimal.NewFromFloat(0.05)}, err: true},
+		{target: StrikeTarget{Delta: decimal.NewFromInt(1)}, err: true},
+		{target: StrikeTarget{Delta: decimal.NewFromFloat(-0.3)}, err: true},
+		{target: StrikeTarget{Premium: decimal.NewFromFloat(-1)}, err: true},
+		{target: StrikeTarget{OTMPct: decimal.NewFromInt(-1)}, err: true},
+	}
+	for idx, tab := range tt {
+		if err := tab.target.Validate(); (err != nil) != tab.err {
+			t.Errorf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+	}
+}
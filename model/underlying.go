@@ -0,0 +1,146 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// tradingDaysPerYear annualizes daily volatility
+const tradingDaysPerYear = 252
+
+// UnderlyingBar is a daily price bar of the underlying
+type UnderlyingBar struct {
+	// Close is the closing price
+	Close decimal.Decimal
+	// Date is the trading date
+	Date time.Time
+	// High is the highest price
+	High decimal.Decimal
+	// Low is the lowest price
+	Low decimal.Decimal
+	// Open is the opening price
+	Open decimal.Decimal
+	// Volume is the number of shares traded
+	Volume decimal.Decimal
+}
+
+// UnderlyingSeries is the daily price history of an underlying, independent of option rows
+type UnderlyingSeries struct {
+	// Symbol is the underlying symbol
+	Symbol string
+	bars   []UnderlyingBar
+	index  map[time.Time]int
+}
+
+// NewUnderlyingSeries creates a series from bars in any order. If two bars have the same date, the later one wins
+func NewUnderlyingSeries(symbol string, bars []UnderlyingBar) (*UnderlyingSeries, error) {
+	byDate := make(map[time.Time]UnderlyingBar, len(bars))
+	for _, b := range bars {
+		if b.Date.IsZero() {
+			return nil, errors.Errorf("Expected a date for underlying bar %+v", b)
+		}
+		byDate[b.Date] = b
+	}
+	s := &UnderlyingSeries{
+		Symbol: symbol,
+		bars:   make([]UnderlyingBar, 0, len(byDate)),
+		index:  make(map[time.Time]int, len(byDate)),
+	}
+	for _, b := range byDate {
+		s.bars = append(s.bars, b)
+	}
+	sort.Slice(s.bars, func(i, j int) bool {
+		return s.bars[i].Date.Before(s.bars[j].Date)
+	})
+	for i, b := range s.bars {
+		s.index[b.Date] = i
+	}
+	return s, nil
+}
+
+// Bars returns every bar in ascending order of date
+func (s *UnderlyingSeries) Bars() []UnderlyingBar {
+	return s.bars
+}
+
+// Get returns the bar of the date. If strict is false, it will find the nearest bar after the date.
+func (s *UnderlyingSeries) Get(t time.Time, strict bool) (UnderlyingBar, bool) {
+	i, ok := s.search(t, strict)
+	if !ok {
+		return UnderlyingBar{}, false
+	}
+	return s.bars[i], true
+}
+
+// search returns the index of the bar of the date, or of the nearest bar after it if strict is false
+func (s *UnderlyingSeries) search(t time.Time, strict bool) (int, bool) {
+	if i, ok := s.index[t]; ok {
+		return i, true
+	}
+	if strict {
+		return 0, false
+	}
+	i := sort.Search(len(s.bars), func(i int) bool {
+		return !s.bars[i].Date.Before(t)
+	})
+	return i, i < len(s.bars)
+}
+
+// Between returns the bars from start to end, both inclusive
+func (s *UnderlyingSeries) Between(start, end time.Time) []UnderlyingBar {
+	from := sort.Search(len(s.bars), func(i int) bool {
+		return !s.bars[i].Date.Before(start)
+	})
+	to := sort.Search(len(s.bars), func(i int) bool {
+		return s.bars[i].Date.After(end)
+	})
+	if from >= to {
+		return []UnderlyingBar{}
+	}
+	return s.bars[from:to]
+}
+
+// RealizedVol returns the annualized standard deviation of daily log returns of the closes over the lookback number of returns ending on the date. It returns false if there are not enough bars.
+func (s *UnderlyingSeries) RealizedVol(t time.Time, lookback int) (decimal.Decimal, bool) {
+	end, ok := s.search(t, true)
+	if !ok || lookback < 2 || end < lookback {
+		return decimal.Decimal{}, false
+	}
+	returns := make([]float64, 0, lookback)
+	for i := end - lookback + 1; i <= end; i++ {
+		prev, _ := s.bars[i-1].Close.Float64()
+		cur, _ := s.bars[i].Close.Float64()
+		if prev <= 0 || cur <= 0 {
+			return decimal.Decimal{}, false
+		}
+		returns = append(returns, math.Log(cur/prev))
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	return decimal.NewFromFloat(math.Sqrt(variance * tradingDaysPerYear)), true
+}
+
+// SMA returns the simple moving average of the closes of the n bars ending on the date. It returns false if there are not enough bars.
+func (s *UnderlyingSeries) SMA(t time.Time, n int) (decimal.Decimal, bool) {
+	end, ok := s.search(t, true)
+	if !ok || n < 1 || end+1 < n {
+		return decimal.Decimal{}, false
+	}
+	sum := decimal.Zero
+	for i := end - n + 1; i <= end; i++ {
+		sum = sum.Add(s.bars[i].Close)
+	}
+	return sum.Div(decimal.NewFromInt(int64(n))), true
+}
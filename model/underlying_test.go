@@ -0,0 +1,85 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestUnderlyingSeries(t *testing.T) {
+	dates := []string{"2016-06-06", "2016-06-01", "2016-06-02", "2016-06-03"}
+	closes := []float64{103, 100, 101, 102}
+	bars := make([]UnderlyingBar, 0)
+	for i, d := range dates {
+		date, _ := time.Parse(DateLayout, d)
+		bars = append(bars, UnderlyingBar{Date: date, Close: decimal.NewFromFloat(closes[i])})
+	}
+	// a later bar of the same date wins
+	june6, _ := time.Parse(DateLayout, "2016-06-06")
+	bars = append(bars, UnderlyingBar{Date: june6, Close: decimal.NewFromInt(104)})
+
+	s, err := NewUnderlyingSeries("SPY", bars)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating underlying series"))
+	}
+	if len(s.Bars()) != 4 {
+		t.Fatalf("Expected 4 bars but got %d", len(s.Bars()))
+	}
+
+	june4, _ := time.Parse(DateLayout, "2016-06-04")
+	tests := []struct {
+		date   time.Time
+		strict bool
+		ok     bool
+		close  decimal.Decimal
+	}{
+		{june4, true, false, decimal.Zero},
+		{june4, false, true, decimal.NewFromInt(104)},
+		{june6, true, true, decimal.NewFromInt(104)},
+		{june6.AddDate(0, 0, 1), false, false, decimal.Zero},
+	}
+	for idx, test := range tests {
+		bar, ok := s.Get(test.date, test.strict)
+		if ok != test.ok {
+			t.Errorf("Expected ok to be %+v but got %+v at idx: %d", test.ok, ok, idx)
+			continue
+		}
+		if ok && !bar.Close.Equal(test.close) {
+			t.Errorf("Expected close to be %+v but got %+v at idx: %d", test.close, bar.Close, idx)
+		}
+	}
+
+	june2, _ := time.Parse(DateLayout, "2016-06-02")
+	if between := s.Between(june2, june4); len(between) != 2 {
+		t.Errorf("Expected 2 bars between %+v and %+v but got %+v", june2, june4, between)
+	}
+
+	sma, ok := s.SMA(june6, 2)
+	if !ok || !sma.Equal(decimal.NewFromInt(103)) {
+		t.Errorf("Expected SMA to be 103 but got %+v %+v", sma, ok)
+	}
+	if _, ok := s.SMA(june2, 3); ok {
+		t.Errorf("Expected SMA to need 3 bars")
+	}
+
+	vol, ok := s.RealizedVol(june6, 3)
+	if !ok {
+		t.Fatalf("Expected realized vol")
+	}
+	returns := []float64{math.Log(101.0 / 100), math.Log(102.0 / 101), math.Log(104.0 / 102)}
+	mean := (returns[0] + returns[1] + returns[2]) / 3
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	expected := math.Sqrt(variance / 2 * 252)
+	if got, _ := vol.Float64(); math.Abs(got-expected) > 1e-9 {
+		t.Errorf("Expected realized vol to be %+v but got %+v", expected, got)
+	}
+	if _, ok := s.RealizedVol(june6, 4); ok {
+		t.Errorf("Expected realized vol to need 5 bars")
+	}
+}
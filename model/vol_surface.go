@@ -0,0 +1,225 @@
+package model
+
+import (
+	"backtest-options/pricing"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// VolSurface is the implied volatility of a quote date by moneyness, the strike over the underlying price, and time to expiry. Each expiry has a smile interpolated linearly in moneyness between its strikes and extrapolated flat beyond its lowest and highest strike. Between expiries the total variance at the same moneyness is interpolated linearly in time, and before the first or after the last expiry the volatility is extrapolated flat.
+type VolSurface struct {
+	QuoteDate time.Time
+	// UndPx is the underlying price that moneyness is relative to
+	UndPx  decimal.Decimal
+	smiles []volSmile
+}
+
+// volSmile is the smile of one expiry
+type volSmile struct {
+	exp time.Time
+	// t is the time to expiry in years
+	t float64
+	// m are the moneyness of the strikes with implied volatility in ascending order, and iv are their implied volatilities
+	m  []float64
+	iv []float64
+}
+
+// VolMetrics are the level, skew and term structure of the volatility surface of a quote date
+type VolMetrics struct {
+	QuoteDate time.Time
+	// ATM is the at the money implied volatility at the tenor
+	ATM decimal.Decimal
+	// Skew is the implied volatility below the money minus the one above the money by the same width at the tenor. It is positive when puts are priced richer than calls
+	Skew decimal.Decimal
+	// Term is the at the money implied volatility at the term tenor minus ATM. It is positive in contango and negative in backwardation
+	Term decimal.Decimal
+}
+
+// VolSurface builds the volatility surface of the quote from the implied volatilities of its options, relative to the underlying price at the snapshot. The implied volatility of a strike is the average of its call and put that have one, and expiries on the quote date are left out. It returns nil if no option has an implied volatility, so it is called after ComputeGreeks.
+func (o *OptChain) VolSurface(snapshot Snapshot) *VolSurface {
+	undPx := o.UndPxAt(snapshot)
+	s, _ := undPx.Float64()
+	if s <= 0 {
+		return nil
+	}
+	v := &VolSurface{QuoteDate: o.QuoteDate, UndPx: undPx}
+	for _, e := range o.expiry {
+		days := int(e.Sub(o.QuoteDate).Hours() / 24)
+		if days <= 0 {
+			continue
+		}
+		smile := volSmile{exp: e, t: float64(days) / daysPerYear}
+		exp := o.expiryMap[e]
+		for _, k := range exp.strike {
+			iv, ok := exp.strikeMap[k.String()].iv()
+			if !ok {
+				continue
+			}
+			kf, _ := k.Float64()
+			smile.m = append(smile.m, kf/s)
+			smile.iv = append(smile.iv, iv)
+		}
+		if len(smile.m) > 0 {
+			v.smiles = append(v.smiles, smile)
+		}
+	}
+	if len(v.smiles) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Expiries returns the expiries that have implied volatilities in ascending order
+func (v *VolSurface) Expiries() []time.Time {
+	exps := make([]time.Time, len(v.smiles))
+	for i, smile := range v.smiles {
+		exps[i] = smile.exp
+	}
+	return exps
+}
+
+// IV returns the implied volatility of the strike expiring on exp
+func (v *VolSurface) IV(k decimal.Decimal, exp time.Time) float64 {
+	s, _ := v.UndPx.Float64()
+	kf, _ := k.Float64()
+	days := int(exp.Sub(v.QuoteDate).Hours() / 24)
+	return v.MoneynessIV(kf/s, days)
+}
+
+// MoneynessIV returns the implied volatility at the moneyness, e.g. 0.9 for the strike 10% below the underlying price, for the calendar days to expiry
+func (v *VolSurface) MoneynessIV(m float64, days int) float64 {
+	t := float64(days) / daysPerYear
+	first, last := v.smiles[0], v.smiles[len(v.smiles)-1]
+	if t <= first.t {
+		return first.at(m)
+	}
+	if t >= last.t {
+		return last.at(m)
+	}
+	i := 1
+	for v.smiles[i].t < t {
+		i++
+	}
+	s0, s1 := v.smiles[i-1], v.smiles[i]
+	iv0, iv1 := s0.at(m), s1.at(m)
+	w0, w1 := iv0*iv0*s0.t, iv1*iv1*s1.t
+	return math.Sqrt((w0 + (w1-w0)*(t-s0.t)/(s1.t-s0.t)) / t)
+}
+
+// Metrics returns the ATM, skew and term structure of the surface. skewWidth is the distance in moneyness of the skew strikes from the money, e.g. 0.1 for the strikes 10% below and above it
+func (v *VolSurface) Metrics(tenorDays, termDays int, skewWidth float64) VolMetrics {
+	atm := v.MoneynessIV(1, tenorDays)
+	return VolMetrics{
+		QuoteDate: v.QuoteDate,
+		ATM:       decimal.NewFromFloat(atm).Round(6),
+		Skew:      decimal.NewFromFloat(v.MoneynessIV(1-skewWidth, tenorDays) - v.MoneynessIV(1+skewWidth, tenorDays)).Round(6),
+		Term:      decimal.NewFromFloat(v.MoneynessIV(1, termDays) - atm).Round(6),
+	}
+}
+
+// at returns the implied volatility of the smile at the moneyness
+func (s volSmile) at(m float64) float64 {
+	if m <= s.m[0] {
+		return s.iv[0]
+	}
+	n := len(s.m)
+	if m >= s.m[n-1] {
+		return s.iv[n-1]
+	}
+	i := 1
+	for s.m[i] < m {
+		i++
+	}
+	return s.iv[i-1] + (s.iv[i]-s.iv[i-1])*(m-s.m[i-1])/(s.m[i]-s.m[i-1])
+}
+
+// VolMetrics returns the metrics of the volatility surface of every quote date that has one, in ascending order of quote date
+func (o *OptChainList) VolMetrics(snapshot Snapshot, tenorDays, termDays int, skewWidth float64) []VolMetrics {
+	metrics := make([]VolMetrics, 0, len(o.quotes))
+	for _, d := range o.quotes {
+		if v := o.quoteMap[d].VolSurface(snapshot); v != nil {
+			metrics = append(metrics, v.Metrics(tenorDays, termDays, skewWidth))
+		}
+	}
+	return metrics
+}
+
+// SurfaceOption returns the option of the type, strike and expiry of the quote priced from its volatility surface, with the rate curve and dividends of the chain, for options missing from the data. Only its bid, ask and underlying price at the snapshot are set, to the model price and the underlying price of the quote, and it has the implied volatility and greeks of the surface. It fails if the quote has no underlying price at the snapshot.
+func (o *OptChainList) SurfaceOption(quote *OptChain, typ OptType, k decimal.Decimal, exp time.Time, snapshot Snapshot) (OHLCV, error) {
+	undPx := quote.UndPxAt(snapshot)
+	if !undPx.IsPositive() {
+		return OHLCV{}, errors.Errorf("Expected an underlying price at the %+v snapshot on %+v but got none", snapshot, quote.QuoteDate.Format(DateLayout))
+	}
+	v := quote.VolSurface(snapshot)
+	if v == nil {
+		return OHLCV{}, errors.Errorf("Expected implied volatilities on quote date %+v but got none", quote.QuoteDate.Format(DateLayout))
+	}
+	if !exp.After(quote.QuoteDate) {
+		return OHLCV{}, errors.Errorf("Expected expiry %+v to be after quote date %+v", exp.Format(DateLayout), quote.QuoteDate.Format(DateLayout))
+	}
+	right := pricing.Call
+	if typ == Put {
+		right = pricing.Put
+	}
+	in := o.pricingInputs(quote, exp, snapshot)
+	kf, _ := k.Float64()
+	vol := v.IV(k, exp)
+	px := decimal.NewFromFloat(pricing.BlackScholes(right, in.s, kf, in.t, in.r, in.q, vol)).Round(4)
+	g := pricing.BlackScholesGreeks(right, in.s, kf, in.t, in.r, in.q, vol)
+	opt := OHLCV{
+		Delta:      decimal.NewFromFloat(g.Delta).Round(6),
+		Expiration: exp,
+		Gamma:      decimal.NewFromFloat(g.Gamma).Round(6),
+		HasIV:      true,
+		IV:         decimal.NewFromFloat(vol).Round(6),
+		Multiplier: decimal.NewFromInt(DefaultMultiplier),
+		QuoteDate:  quote.QuoteDate,
+		Rho:        decimal.NewFromFloat(g.Rho).Round(6),
+		Root:       o.key.Root,
+		Strike:     k,
+		Theta:      decimal.NewFromFloat(g.Theta).Round(6),
+		Type:       typ,
+		UndSym:     o.key.UndSym,
+		Vega:       decimal.NewFromFloat(g.Vega).Round(6),
+	}
+	if snapshot == Snapshot1545 {
+		opt.Ask1545, opt.Bid1545 = px, px
+		opt.UndAsk1545, opt.UndBid1545 = undPx, undPx
+	} else {
+		opt.Ask, opt.Bid, opt.AskBidMid = px, px, px
+		opt.UndAsk, opt.UndBid = undPx, undPx
+	}
+	// the contract size of the listed options of the quote
+	for _, e := range quote.expiry {
+		exp := quote.expiryMap[e]
+		for _, s := range exp.strike {
+			strike := exp.strikeMap[s.String()]
+			for _, listed := range []OHLCV{strike.Call, strike.Put} {
+				if listed.Type != "" && !listed.Multiplier.IsZero() {
+					opt.Multiplier = listed.Multiplier
+					return opt, nil
+				}
+			}
+		}
+	}
+	return opt, nil
+}
+
+// iv returns the average implied volatility of the call and put of the strike that have one, or false if neither has
+func (o *OptChainStrike) iv() (float64, bool) {
+	sum, n := 0.0, 0
+	for _, opt := range []OHLCV{o.Call, o.Put} {
+		if opt.HasIV {
+			v, _ := opt.IV.Float64()
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
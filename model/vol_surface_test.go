@@ -0,0 +1,128 @@
+package model
+
+import (
+	"backtest-options/pricing"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestVolSurface(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	near := quote.AddDate(0, 0, 30)
+	far := quote.AddDate(0, 0, 90)
+	opt := func(exp time.Time, k string, typ OptType, iv string) OHLCV {
+		o, _ := NewOHLCV(quote, "SPY", exp, k, typ, "0", "0", "0", "0", "0", "1", "1", "99.5", "100.5")
+		o, _ = o.WithGreeks(iv, "0", "0", "0", "0", "0")
+		return o
+	}
+	chain, err := NewOptionChain([]OHLCV{
+		opt(near, "90", Put, "0.3"),
+		opt(near, "100", Put, "0.2"),
+		opt(near, "100", Call, "0.2"),
+		opt(near, "110", Call, "0.16"),
+		opt(far, "90", Put, "0.26"),
+		opt(far, "100", Call, "0.22"),
+		opt(far, "110", Call, "0.2"),
+		// expires on the quote date and is left out
+		opt(quote, "100", Call, "0.9"),
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	v := chain.GetOptionChainForQuoteDate(quote, true).VolSurface(SnapshotEOD)
+	if v == nil {
+		t.Fatal("Expected a volatility surface")
+	}
+	if exps := v.Expiries(); len(exps) != 2 || !exps[0].Equal(near) || !exps[1].Equal(far) {
+		t.Fatalf("Expected expiries %+v and %+v but got %+v", near, far, exps)
+	}
+
+	// total variance half way between the expiries
+	between := func(iv0, iv1 float64) float64 {
+		return math.Sqrt((iv0*iv0*30 + iv1*iv1*90) / 2 / 60)
+	}
+	tt := []struct {
+		m        float64
+		days     int
+		expected float64
+	}{
+		{m: 1, days: 30, expected: 0.2},
+		// the smile between the strikes
+		{m: 0.95, days: 30, expected: 0.25},
+		{m: 1.05, days: 90, expected: 0.21},
+		// flat beyond the strikes and expiries
+		{m: 0.8, days: 30, expected: 0.3},
+		{m: 1.2, days: 120, expected: 0.2},
+		{m: 1, days: 10, expected: 0.2},
+		{m: 1, days: 60, expected: between(0.2, 0.22)},
+		{m: 0.9, days: 60, expected: between(0.3, 0.26)},
+	}
+	for idx, tab := range tt {
+		if iv := v.MoneynessIV(tab.m, tab.days); math.Abs(iv-tab.expected) > 1e-9 {
+			t.Errorf("Expected implied volatility %+v but got %+v at idx: %d", tab.expected, iv, idx)
+		}
+	}
+	if iv := v.IV(decimal.NewFromInt(95), near); math.Abs(iv-0.25) > 1e-9 {
+		t.Errorf("Expected implied volatility of the 95 strike to be 0.25 but got %+v", iv)
+	}
+
+	m := v.Metrics(30, 90, 0.1)
+	expected := VolMetrics{QuoteDate: quote, ATM: decimal.NewFromFloat(0.2), Skew: decimal.NewFromFloat(0.14), Term: decimal.NewFromFloat(0.02)}
+	if !m.QuoteDate.Equal(expected.QuoteDate) || !m.ATM.Equal(expected.ATM) || !m.Skew.Equal(expected.Skew) || !m.Term.Equal(expected.Term) {
+		t.Errorf("Expected metrics %+v but got %+v", expected, m)
+	}
+	if metrics := chain.VolMetrics(SnapshotEOD, 30, 90, 0.1); len(metrics) != 1 || !metrics[0].Skew.Equal(expected.Skew) {
+		t.Errorf("Expected metrics %+v of one quote date but got %+v", expected, metrics)
+	}
+}
+
+func TestSurfaceOption(t *testing.T) {
+	quote := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	exp := quote.AddDate(0, 0, 73)
+	listed, _ := NewOHLCV(quote, "SPY", exp, "100", Put, "0", "0", "0", "0", "0", "1", "1", "99.5", "100.5")
+	listed, _ = listed.WithGreeks("0.25", "0", "0", "0", "0", "0")
+	listed.Multiplier = decimal.NewFromInt(10)
+	chain, err := NewOptionChain([]OHLCV{listed})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	rates, err := NewRateCurve(nil, decimal.NewFromFloat(0.02))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating rate curve"))
+	}
+	chain.SetRateCurve(rates)
+	optchain := chain.GetOptionChainForQuoteDate(quote, true)
+
+	// a strike and expiry missing from the data, priced at the flat surface
+	missing := quote.AddDate(0, 0, 100)
+	opt, err := chain.SurfaceOption(optchain, Call, decimal.NewFromInt(105), missing, SnapshotEOD)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error pricing option from the surface"))
+	}
+	tm := 100.0 / 365
+	px := decimal.NewFromFloat(pricing.BlackScholes(pricing.Call, 100, 105, tm, 0.02, 0, 0.25)).Round(4)
+	delta := decimal.NewFromFloat(pricing.BlackScholesGreeks(pricing.Call, 100, 105, tm, 0.02, 0, 0.25).Delta).Round(6)
+	if !opt.MidAt(SnapshotEOD).Equal(px) || !opt.IV.Equal(decimal.NewFromFloat(0.25)) || !opt.Delta.Equal(delta) || !opt.HasIV {
+		t.Errorf("Expected price %+v, IV 0.25 and delta %+v but got %+v", px, delta, opt)
+	}
+	if opt.Type != Call || !opt.Strike.Equal(decimal.NewFromInt(105)) || !opt.Expiration.Equal(missing) || opt.UndSym != "SPY" || !opt.Multiplier.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("Expected the SPY 105 call expiring on %+v with the listed multiplier but got %+v", missing, opt)
+	}
+
+	// only the prices of the snapshot are set
+	if !opt.Bid1545.IsZero() || !opt.Ask1545.IsZero() || !opt.UndBid1545.IsZero() || !opt.UndBidAt(SnapshotEOD).Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected only end of day prices but got %+v", opt)
+	}
+
+	if _, err := chain.SurfaceOption(optchain, Put, decimal.NewFromInt(100), quote, SnapshotEOD); err == nil {
+		t.Error("Expected an error pricing an expiry on the quote date")
+	}
+	// the data has no 15:45 underlying price
+	if _, err := chain.SurfaceOption(optchain, Call, decimal.NewFromInt(105), missing, Snapshot1545); err == nil {
+		t.Error("Expected an error pricing at a snapshot without an underlying price")
+	}
+}
@@ -0,0 +1,36 @@
+package pricing
+
+import "math"
+
+// Right is the right of an option, either a call or a put
+type Right int
+
+const (
+	// Call is the right to buy the underlying
+	Call Right = iota
+	// Put is the right to sell the underlying
+	Put
+)
+
+// normCDF is the cumulative distribution function of the standard normal distribution
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// BlackScholes returns the price of a European option. s is the underlying price, k the strike, t the time to expiry in years, r the annual risk-free rate, q the annual dividend yield and vol the annual volatility, all continuously compounded. An expired option, or one without volatility, is worth its discounted intrinsic value.
+func BlackScholes(right Right, s, k, t, r, q, vol float64) float64 {
+	if t <= 0 || vol <= 0 {
+		fwd := s*math.Exp(-q*math.Max(t, 0)) - k*math.Exp(-r*math.Max(t, 0))
+		if right == Put {
+			fwd = -fwd
+		}
+		return math.Max(fwd, 0)
+	}
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r-q+vol*vol/2)*t) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	if right == Put {
+		return k*math.Exp(-r*t)*normCDF(-d2) - s*math.Exp(-q*t)*normCDF(-d1)
+	}
+	return s*math.Exp(-q*t)*normCDF(d1) - k*math.Exp(-r*t)*normCDF(d2)
+}
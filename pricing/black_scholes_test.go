@@ -0,0 +1,40 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBlackScholes(t *testing.T) {
+	tt := []struct {
+		right Right
+		s     float64
+		k     float64
+		t     float64
+		r     float64
+		q     float64
+		vol   float64
+		px    float64
+	}{
+		// Hull, Options Futures and Other Derivatives, example 15.6
+		{right: Call, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, vol: 0.2, px: 4.7594},
+		{right: Put, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, vol: 0.2, px: 0.8086},
+		// an expired option is worth its intrinsic value
+		{right: Call, s: 105, k: 100, t: 0, r: 0.1, q: 0, vol: 0.2, px: 5},
+		{right: Put, s: 105, k: 100, t: 0, r: 0.1, q: 0, vol: 0.2, px: 0},
+	}
+	for idx, tab := range tt {
+		px := BlackScholes(tab.right, tab.s, tab.k, tab.t, tab.r, tab.q, tab.vol)
+		if math.Abs(px-tab.px) > 1e-4 {
+			t.Errorf("Expected price to be %+v but got %+v at idx: %d", tab.px, px, idx)
+		}
+	}
+
+	// put call parity with a dividend yield
+	s, k, tm, r, q, vol := 100.0, 95.0, 0.75, 0.03, 0.02, 0.25
+	call := BlackScholes(Call, s, k, tm, r, q, vol)
+	put := BlackScholes(Put, s, k, tm, r, q, vol)
+	if parity := s*math.Exp(-q*tm) - k*math.Exp(-r*tm); math.Abs(call-put-parity) > 1e-9 {
+		t.Errorf("Expected call minus put to be %+v but got %+v", parity, call-put)
+	}
+}
@@ -0,0 +1,46 @@
+package pricing
+
+import "math"
+
+// Greeks are the sensitivities of an option price. Theta is the change per calendar day, and vega and rho are the changes per percentage point of volatility and rate
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Theta float64
+	Vega  float64
+	Rho   float64
+}
+
+// BlackScholesGreeks returns the greeks of a European option with the inputs of BlackScholes. An expired option, or one without volatility, has the delta of its intrinsic value and no other greeks.
+func BlackScholesGreeks(right Right, s, k, t, r, q, vol float64) Greeks {
+	if t <= 0 || vol <= 0 {
+		g := Greeks{}
+		if right == Call && s > k {
+			g.Delta = 1
+		} else if right == Put && s < k {
+			g.Delta = -1
+		}
+		return g
+	}
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r-q+vol*vol/2)*t) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	divDisc := math.Exp(-q * t)
+	rateDisc := math.Exp(-r * t)
+
+	g := Greeks{
+		Gamma: divDisc * normPDF(d1) / (s * vol * sqrtT),
+		Vega:  vega(s, k, t, r, q, vol) / 100,
+	}
+	decay := -s * divDisc * normPDF(d1) * vol / (2 * sqrtT)
+	if right == Put {
+		g.Delta = divDisc * (normCDF(d1) - 1)
+		g.Theta = (decay + r*k*rateDisc*normCDF(-d2) - q*s*divDisc*normCDF(-d1)) / 365
+		g.Rho = -k * t * rateDisc * normCDF(-d2) / 100
+		return g
+	}
+	g.Delta = divDisc * normCDF(d1)
+	g.Theta = (decay - r*k*rateDisc*normCDF(d2) + q*s*divDisc*normCDF(d1)) / 365
+	g.Rho = k * t * rateDisc * normCDF(d2) / 100
+	return g
+}
@@ -0,0 +1,57 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBlackScholesGreeks(t *testing.T) {
+	// Hull, Options Futures and Other Derivatives, chapter 19: a 20 week call with delta 0.522, gamma 0.066, theta -4.31 a year, vega 12.1 and rho 8.91
+	g := BlackScholesGreeks(Call, 49, 50, 0.3846, 0.05, 0, 0.2)
+	tt := []struct {
+		name     string
+		value    float64
+		expected float64
+		tol      float64
+	}{
+		{name: "delta", value: g.Delta, expected: 0.522, tol: 1e-3},
+		{name: "gamma", value: g.Gamma, expected: 0.066, tol: 1e-3},
+		{name: "theta", value: g.Theta, expected: -4.31 / 365, tol: 1e-4},
+		{name: "vega", value: g.Vega, expected: 0.121, tol: 1e-3},
+		{name: "rho", value: g.Rho, expected: 0.0891, tol: 1e-3},
+	}
+	for idx, tab := range tt {
+		if math.Abs(tab.value-tab.expected) > tab.tol {
+			t.Errorf("Expected %s to be %+v but got %+v at idx: %d", tab.name, tab.expected, tab.value, idx)
+		}
+	}
+
+	// the greeks of a put with a dividend yield match finite differences of its price
+	s, k, tm, r, q, vol := 100.0, 105.0, 0.5, 0.03, 0.02, 0.3
+	px := func(s, tm, r, vol float64) float64 {
+		return BlackScholes(Put, s, k, tm, r, q, vol)
+	}
+	g = BlackScholesGreeks(Put, s, k, tm, r, q, vol)
+	h := 1e-4
+	diffs := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{name: "delta", value: g.Delta, expected: (px(s+h, tm, r, vol) - px(s-h, tm, r, vol)) / (2 * h)},
+		{name: "gamma", value: g.Gamma, expected: (px(s+h, tm, r, vol) - 2*px(s, tm, r, vol) + px(s-h, tm, r, vol)) / (h * h)},
+		{name: "theta", value: g.Theta, expected: (px(s, tm-1.0/365, r, vol) - px(s, tm, r, vol))},
+		{name: "vega", value: g.Vega, expected: (px(s, tm, r, vol+h) - px(s, tm, r, vol-h)) / (2 * h) / 100},
+		{name: "rho", value: g.Rho, expected: (px(s, tm, r+h, vol) - px(s, tm, r-h, vol)) / (2 * h) / 100},
+	}
+	for idx, tab := range diffs {
+		if math.Abs(tab.value-tab.expected) > 1e-3*math.Max(1, math.Abs(tab.expected)) {
+			t.Errorf("Expected %s to be %+v but got %+v at idx: %d", tab.name, tab.expected, tab.value, idx)
+		}
+	}
+
+	// expired options only have the delta of their intrinsic value
+	if g := BlackScholesGreeks(Put, 90, 100, 0, 0.03, 0, 0.3); g != (Greeks{Delta: -1}) {
+		t.Errorf("Expected an expired in the money put to have delta -1 only but got %+v", g)
+	}
+}
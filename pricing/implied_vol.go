@@ -0,0 +1,76 @@
+package pricing
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// minIV and maxIV bracket the implied volatilities the solver searches
+	minIV = 1e-4
+	maxIV = 10.0
+	// ivTolerance is the largest price error of a solved implied volatility
+	ivTolerance = 1e-8
+	// ivIterations is the most iterations the solver runs
+	ivIterations = 100
+)
+
+// normPDF is the probability density function of the standard normal distribution
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// vega returns the change of the option price per unit of volatility, which is the same for calls and puts
+func vega(s, k, t, r, q, vol float64) float64 {
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r-q+vol*vol/2)*t) / (vol * sqrtT)
+	return s * math.Exp(-q*t) * normPDF(d1) * sqrtT
+}
+
+// ImpliedVol returns the volatility at which BlackScholes prices the option at px. It fails if the option has expired or px is outside of the prices any volatility gives, i.e. below the discounted intrinsic value or above the discounted underlying price for calls and the discounted strike for puts, or if the volatility does not converge.
+func ImpliedVol(right Right, px, s, k, t, r, q float64) (float64, error) {
+	return impliedVol(right, px, s, k, t, r, q, ivIterations)
+}
+
+// impliedVol is ImpliedVol with at most iterations solver iterations. It fails if the volatility has not converged by then
+func impliedVol(right Right, px, s, k, t, r, q float64, iterations int) (float64, error) {
+	if t <= 0 || s <= 0 || k <= 0 {
+		return 0, errors.Errorf("Expected a positive time to expiry, underlying price and strike but got %+v, %+v and %+v", t, s, k)
+	}
+	lo, hi := minIV, maxIV
+	plo := BlackScholes(right, s, k, t, r, q, lo)
+	phi := BlackScholes(right, s, k, t, r, q, hi)
+	if px < plo-ivTolerance || px > phi+ivTolerance {
+		return 0, errors.Errorf("Expected price %+v to be between %+v and %+v", px, plo, phi)
+	}
+	if px <= plo {
+		return lo, nil
+	}
+
+	// Newton's method from the volatility where vega is highest, falling back to bisection whenever a step leaves the bracket
+	vol := math.Sqrt(2 * math.Abs((math.Log(s/k)+(r-q)*t)/t))
+	if vol < lo || vol > hi {
+		vol = (lo + hi) / 2
+	}
+	for i := 0; i < iterations; i++ {
+		diff := BlackScholes(right, s, k, t, r, q, vol) - px
+		if math.Abs(diff) < ivTolerance {
+			return vol, nil
+		}
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		next := vol - diff/vega(s, k, t, r, q, vol)
+		if math.IsNaN(next) || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		vol = next
+		if hi-lo < ivTolerance {
+			return vol, nil
+		}
+	}
+	return 0, errors.Errorf("Expected implied volatility of price %+v to converge in %d iterations but got %+v", px, iterations, vol)
+}
@@ -0,0 +1,81 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImpliedVol(t *testing.T) {
+	tt := []struct {
+		right Right
+		px    float64
+		s     float64
+		k     float64
+		t     float64
+		r     float64
+		q     float64
+		vol   float64
+		err   bool
+	}{
+		// Hull, Options Futures and Other Derivatives, section 15.11
+		{right: Call, px: 1.875, s: 21, k: 20, t: 0.25, r: 0.1, q: 0, vol: 0.2345},
+		// Hull example 15.6 priced at 20% volatility
+		{right: Call, px: 4.7594, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, vol: 0.2},
+		{right: Put, px: 0.8086, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, vol: 0.2},
+		// Hull example 17.1, an index option with a dividend yield priced at 20% volatility
+		{right: Call, px: 51.83, s: 930, k: 900, t: 2.0 / 12, r: 0.08, q: 0.03, vol: 0.2},
+		// below the intrinsic value and above the underlying price
+		{right: Call, px: 0.5, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, err: true},
+		{right: Call, px: 50, s: 42, k: 40, t: 0.5, r: 0.1, q: 0, err: true},
+		// expired
+		{right: Put, px: 1, s: 42, k: 40, t: 0, r: 0.1, q: 0, err: true},
+	}
+	for idx, tab := range tt {
+		vol, err := ImpliedVol(tab.right, tab.px, tab.s, tab.k, tab.t, tab.r, tab.q)
+		if (err != nil) != tab.err {
+			t.Fatalf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+		if !tab.err && math.Abs(vol-tab.vol) > 1e-3 {
+			t.Errorf("Expected implied volatility to be %+v but got %+v at idx: %d", tab.vol, vol, idx)
+		}
+	}
+
+	// deep in and out of the money, short and long dated options price back to themselves
+	for idx, k := range []float64{50, 80, 100, 120, 200} {
+		for _, tm := range []float64{1.0 / 365, 0.1, 1, 5} {
+			for _, right := range []Right{Call, Put} {
+				px := BlackScholes(right, 100, k, tm, 0.03, 0.01, 0.35)
+				if px < 1e-6 {
+					continue
+				}
+				vol, err := ImpliedVol(right, px, 100, k, tm, 0.03, 0.01)
+				if err != nil {
+					t.Fatalf("Expected no error but got %+v at idx: %d", err, idx)
+				}
+				if back := BlackScholes(right, 100, k, tm, 0.03, 0.01, vol); math.Abs(back-px) > 1e-6 {
+					t.Errorf("Expected %+v to price at %+v but got %+v at idx: %d", vol, px, back, idx)
+				}
+			}
+		}
+	}
+}
+
+func TestImpliedVolNotConverged(t *testing.T) {
+	tt := []struct {
+		iterations int
+		err        bool
+	}{
+		{iterations: 1, err: true},
+		{iterations: 2, err: true},
+		{iterations: ivIterations, err: false},
+	}
+	for idx, tab := range tt {
+		vol, err := impliedVol(Call, 1.875, 21, 20, 0.25, 0.1, 0, tab.iterations)
+		if (err != nil) != tab.err {
+			t.Errorf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+		if tab.err && vol != 0 {
+			t.Errorf("Expected no implied volatility but got %+v at idx: %d", vol, idx)
+		}
+	}
+}
@@ -27,6 +27,15 @@ var buyStockLeg = "buy-stock"
 
 // Validate
 func (s *coveredCall) Validate(opts model.StrategyOpts) error {
+	if err := opts.CallStrike.Validate(); err != nil {
+		return errors.Wrap(err, "Error validating the call strike")
+	}
+	if err := opts.EntryIV.Validate(); err != nil {
+		return errors.Wrap(err, "Error validating the entry IV range")
+	}
+	if err := s.optchain.ValidateSnapshot(opts.Snapshot); err != nil {
+		return errors.Wrap(err, "Error validating the snapshot, use the eod snapshot for data without 15:45 quotes")
+	}
 	return nil
 }
 
@@ -39,27 +48,36 @@ func (s *coveredCall) Run(opts model.StrategyOpts) (*model.StrategyResult, error
 	minexpday := opts.MinExpDays
 
 	for {
-		optchain := s.optchain.GetOptionChainForQuoteDate(start, false)
+		optchain := quoteOnOrAfter(s.optchain, opts, start)
 		if optchain == nil {
 			log.Warnf("Exiting since quote does not exist for date %+v", start)
 			break
 		}
 		quotedate := optchain.QuoteDate
-		px := optchain.UndPx
-		expdate := quotedate.AddDate(0, 0, minexpday)
+		if afterEnd(opts, quotedate) {
+			log.Infof("Exiting since quote date %+v is after the end date %+v", quotedate.Format(model.DateLayout), opts.EndDate.Format(model.DateLayout))
+			break
+		}
+		if !opts.EntryIV.Allows(optchain.IV) {
+			log.Debugf("Skipping quote date %+v since its IV %+v is outside the entry range %+v", quotedate.Format(model.DateLayout), optchain.IV, opts.EntryIV)
+			start = quotedate.AddDate(0, 0, 1)
+			continue
+		}
+		px := optchain.UndPxAt(opts.Snapshot)
+		expdate := expiryTarget(opts, quotedate, minexpday)
 		expchain := optchain.GetOptionChainForExpiryDate(expdate, false)
 		if expchain == nil {
 			log.Warnf("Exiting since expire does not exist for date %+v, for quote date: %+v", expdate, start)
 			break
 		}
-		strike := expchain.GetOptionChainForStrike(px, false)
+		strike := expchain.GetOptionChainForTarget(model.Call, opts.CallStrike, px, opts.Snapshot)
 		if strike == nil {
-			log.Warnf("Exiting since strike does not exist for price %+v, expire date %+v, for quote date: %+v", px, expdate, start)
+			log.Warnf("Exiting since strike does not exist for price %+v and target %+v, expire date %+v, for quote date: %+v", px, opts.CallStrike, expdate, start)
 			break
 		}
 
-		// purchase 100 underlying stocks
-		stkqty := decimal.NewFromInt(100)
+		// purchase the underlying stocks delivered by one option contract, which is 100 for US options
+		stkqty := strike.Call.Multiplier
 		stkleg := model.NewOpenExec(
 			model.Stock,
 			quotedate,
@@ -73,31 +91,41 @@ func (s *coveredCall) Run(opts model.StrategyOpts) (*model.StrategyResult, error
 		optleg := model.NewOpenExec(
 			model.Option,
 			quotedate,
-			strike.Call.AskBidMid,
+			strike.Call.MidAt(opts.Snapshot),
 			optqty,
 			model.Sell,
 			fmt.Sprintf("%+v C %+v", strike.S.String(), expchain.ExpireDate.Format("2006-01-02")),
 		)
+		optleg.Multiplier = strike.Call.Multiplier
+		optleg.Greeks = strike.Call.Greeks()
 
 		expire := expchain.ExpireDate
-		expiredquote := s.optchain.GetOptionChainForQuoteDate(expire, false)
-		if expiredquote == nil {
-			log.Debugf("Exiting since GetOptionChainForQuoteDate does not exist for quotedate: %+v, expiredate: %+v, start: %+v",
-				quotedate,
-				expire,
-				start)
-			break
+		settled := settle(s.optchain, opts, quotedate, expire)
+		if settled == nil {
+			if s.optchain.GetOptionChainForQuoteDate(expire, false) == nil {
+				log.Debugf("Exiting since settlement quote does not exist for quotedate: %+v, expiredate: %+v, start: %+v",
+					quotedate,
+					expire,
+					start)
+				break
+			}
+			log.Warnf("Skipping position opened on %+v since its expiry %+v has no quotes or underlying price to settle at", quotedate.Format(model.DateLayout), expire.Format(model.DateLayout))
+			start = expire
+			continue
 		}
-		endpx := expiredquote.UndPx
+		settle := settled.Date
+		// prices after a split are compared with the strike in the terms of the shares held at open
+		endpx := settled.Px.Mul(splitFactor(s.optchain, quotedate, settle))
 
-		// close 100 underlying stocks, capped at the lower of the price and strike
+		// close the underlying stocks, capped at the lower of the price and strike
 		adjendpx := endpx
 		if endpx.GreaterThan(strike.S) {
 			adjendpx = strike.S
 		}
-		stkleg.CloseExec(expire, adjendpx)
+		stkleg.CloseExec(settle, adjendpx)
+		stkleg.Dividend = dividends(s.optchain, quotedate, settle)
 
-		optleg.CloseExec(expire, decimal.NewFromInt(0))
+		optleg.CloseExec(settle, decimal.NewFromInt(0))
 
 		legs := map[string]*model.ExecOpenClose{
 			coveredCallLeg: optleg,
@@ -180,6 +208,7 @@ func (s *coveredCall) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 	one := decimal.NewFromInt(1)
 	firstPx := decimal.NewFromInt(0)
 	lastPx := decimal.NewFromInt(0)
+	shares := decimal.NewFromInt(model.DefaultMultiplier)
 
 	for idx, ex := range r.Execs {
 		cc, ok := ex.Leg[coveredCallLeg]
@@ -192,6 +221,7 @@ func (s *coveredCall) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 		}
 		if idx == 0 {
 			firstPx = stk.Open.Px
+			shares = stk.Open.Qty
 		}
 		if idx == len(r.Execs)-1 {
 			lastPx = stk.Close.Px
@@ -221,14 +251,23 @@ func (s *coveredCall) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 		data = append(data, d)
 	}
 
+	dividend := decimal.Zero
+	if len(r.Execs) > 0 {
+		first := r.Execs[0].Leg[buyStockLeg]
+		last := r.Execs[len(r.Execs)-1].Leg[buyStockLeg]
+		firstPx, lastPx = buyAndHoldPx(s.optchain, first.Open.Date, last.Close.Date, firstPx, lastPx)
+		dividend = dividends(s.optchain, first.Open.Date, last.Close.Date)
+	}
+
 	table := tablewriter.NewWriter(w)
 	table.SetHeader([]string{
 		"Total Profit",
 		"Total Executions",
 		"Max Drawdown",
 		"Buy & Hold",
+		"Buy & Hold (Total Return)",
 	})
-	initbp := firstPx.Mul(hundred)
+	initbp := firstPx.Mul(shares)
 	data = [][]string{
 		[]string{
 			fmt.Sprintf("%s (%s %%)",
@@ -237,8 +276,11 @@ func (s *coveredCall) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 			fmt.Sprintf("%d", r.Meta.TotalExecutions),
 			fmt.Sprintf("%s", maxdrawdown.Mul(hundred).StringFixed(2)),
 			fmt.Sprintf("%s (%s %%)",
-				lastPx.Sub(firstPx).Mul(hundred).StringFixed(2),
-				lastPx.Sub(firstPx).Mul(hundred).Mul(hundred).Div(initbp).StringFixed(2)),
+				lastPx.Sub(firstPx).Mul(shares).StringFixed(2),
+				lastPx.Sub(firstPx).Mul(shares).Mul(hundred).Div(initbp).StringFixed(2)),
+			fmt.Sprintf("%s (%s %%)",
+				lastPx.Sub(firstPx).Add(dividend).Mul(shares).StringFixed(2),
+				lastPx.Sub(firstPx).Add(dividend).Mul(shares).Mul(hundred).Div(initbp).StringFixed(2)),
 		},
 	}
 
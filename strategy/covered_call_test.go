@@ -129,7 +129,7 @@ func TestCoveredCall(t *testing.T) {
 	if exp.Opts.MinExpDays != strat.Opts.MinExpDays {
 		t.Errorf("Expected MinExpDays to be %+v but got %+v", exp.Opts.MinExpDays, strat.Opts.MinExpDays)
 	}
-	if exp.Opts.EndDate != strat.Opts.EndDate {
+	if !exp.Opts.EndDate.Equal(strat.Opts.EndDate) {
 		t.Errorf("Expected EndDate to be %+v but got %+v", exp.Opts.EndDate, strat.Opts.EndDate)
 	}
 	if !exp.Opts.StartDate.Equal(strat.Opts.StartDate) {
@@ -186,11 +186,11 @@ func TestCoveredCall(t *testing.T) {
 		t.Error(errors.Wrap(err, "expected no error to occur when GenerateResults is ran"))
 	}
 
-	metawant := `+-----------------+------------------+--------------+-----------------+
-|  TOTAL PROFIT   | TOTAL EXECUTIONS | MAX DRAWDOWN |   BUY & HOLD    |
-+-----------------+------------------+--------------+-----------------+
-| 215.00 (1.85 %) |                2 |         0.00 | 200.00 (1.72 %) |
-+-----------------+------------------+--------------+-----------------+
+	metawant := `+-----------------+------------------+--------------+-----------------+---------------------------+
+|  TOTAL PROFIT   | TOTAL EXECUTIONS | MAX DRAWDOWN |   BUY & HOLD    | BUY & HOLD (TOTAL RETURN) |
++-----------------+------------------+--------------+-----------------+---------------------------+
+| 215.00 (1.85 %) |                2 |         0.00 | 200.00 (1.72 %) | 200.00 (1.72 %)           |
++-----------------+------------------+--------------+-----------------+---------------------------+
 `
 	if metaBuf.String() != metawant {
 		t.Errorf("Expected to write %+v but got %+v",
@@ -218,3 +218,266 @@ func TestCoveredCall(t *testing.T) {
 	}
 
 }
+
+func TestCoveredCallSnapshot(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2006-07-02")
+
+	v1, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "1.1", "0.9", "115.5", "116.5")
+	v1, _ = v1.WithSnapshot1545("10", "1.2", "10", "1.4", "115", "116")
+	v2, _ := model.NewOHLCV(july2, "SPY", july2, "116", model.Call, "0", "0", "0", "0", "623", "1", "1", "117.5", "118.5")
+	v2, _ = v2.WithSnapshot1545("10", "1", "10", "1", "114", "115")
+
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	st, err := NewCoveredCallStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+
+	tt := []struct {
+		snapshot model.Snapshot
+		stkOpen  string
+		stkClose string
+		optOpen  string
+	}{
+		{snapshot: model.SnapshotEOD, stkOpen: "116", stkClose: "116", optOpen: "1"},
+		{snapshot: model.Snapshot1545, stkOpen: "115.5", stkClose: "114.5", optOpen: "1.3"},
+	}
+	for idx, tab := range tt {
+		strat, err := st.Run(model.StrategyOpts{
+			StartDate:  june1,
+			MinExpDays: 28,
+			Snapshot:   tab.snapshot,
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+		}
+		if len(strat.Execs) != 1 {
+			t.Fatalf("Expected 1 execution but got %d at idx: %d", len(strat.Execs), idx)
+		}
+		stk := strat.Execs[0].Leg[buyStockLeg]
+		cc := strat.Execs[0].Leg[coveredCallLeg]
+		if stk.Open.Px.String() != tab.stkOpen {
+			t.Errorf("Expected stock open px to be %+v but got %+v at idx: %d", tab.stkOpen, stk.Open.Px, idx)
+		}
+		if stk.Close.Px.String() != tab.stkClose {
+			t.Errorf("Expected stock close px to be %+v but got %+v at idx: %d", tab.stkClose, stk.Close.Px, idx)
+		}
+		if cc.Open.Px.String() != tab.optOpen {
+			t.Errorf("Expected option open px to be %+v but got %+v at idx: %d", tab.optOpen, cc.Open.Px, idx)
+		}
+	}
+}
+
+func TestCoveredCallSnapshotMissing(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2006-07-02")
+
+	// end of day data without 15:45 columns
+	eod, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "1.1", "0.9", "115.5", "116.5")
+	// a 15:45 underlying price, but an option without a 15:45 quote
+	und1545, _ := eod.WithSnapshot1545("0", "0", "0", "0", "115", "116")
+	quoted, _ := eod.WithSnapshot1545("10", "1.2", "10", "1.4", "115", "116")
+
+	tt := []struct {
+		row      model.OHLCV
+		snapshot model.Snapshot
+		err      bool
+	}{
+		{row: eod, snapshot: model.SnapshotEOD, err: false},
+		{row: eod, snapshot: model.Snapshot1545, err: true},
+		{row: und1545, snapshot: model.Snapshot1545, err: true},
+		{row: quoted, snapshot: model.Snapshot1545, err: false},
+	}
+	for idx, tab := range tt {
+		chain, err := model.NewOptionChain([]model.OHLCV{tab.row})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+		}
+		st, err := NewCoveredCallStrategy(chain)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+		}
+		if err := st.Validate(model.StrategyOpts{MinExpDays: 28, Snapshot: tab.snapshot}); (err != nil) != tab.err {
+			t.Errorf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+	}
+}
+
+func TestCoveredCallCorporateActions(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	june10, _ := time.Parse(model.DateLayout, "2006-06-10")
+	june15, _ := time.Parse(model.DateLayout, "2006-06-15")
+	july2, _ := time.Parse(model.DateLayout, "2006-07-02")
+
+	// a 2:1 split halves the underlying price from 116 to 57, which is 114 in the shares held at open
+	v1, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "1.1", "0.9", "115.5", "116.5")
+	v2, _ := model.NewOHLCV(july2, "SPY", july2, "58", model.Call, "0", "0", "0", "0", "623", "0", "0", "56.5", "57.5")
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	actions, err := model.NewCorporateActions("SPY", []model.CorporateAction{
+		{ExDate: june10, Type: model.ActionDividend, Value: decimal.NewFromFloat(0.5)},
+		{ExDate: june15, Type: model.ActionSplit, Value: decimal.NewFromInt(2)},
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating corporate actions"))
+	}
+	chain.SetCorporateActions(actions)
+
+	st, err := NewCoveredCallStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+	strat, err := st.Run(model.StrategyOpts{
+		StartDate:  june1,
+		MinExpDays: 28,
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+	}
+	if len(strat.Execs) != 1 {
+		t.Fatalf("Expected 1 execution but got %d", len(strat.Execs))
+	}
+	stk := strat.Execs[0].Leg[buyStockLeg]
+	if !stk.Close.Px.Equal(decimal.NewFromInt(114)) {
+		t.Errorf("Expected split adjusted stock close px to be 114 but got %+v", stk.Close.Px)
+	}
+	if !stk.Dividend.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected dividend to be 0.5 but got %+v", stk.Dividend)
+	}
+	// (114 - 116 + 0.5) * 100 for the stock and (1 - 0) * 100 for the call
+	if !strat.Execs[0].TotalProfit.Equal(decimal.NewFromInt(-50)) {
+		t.Errorf("Expected total profit to be -50 but got %+v", strat.Execs[0].TotalProfit)
+	}
+
+	var metaBuf bytes.Buffer
+	if err := st.OutputMeta(&metaBuf, strat); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing meta"))
+	}
+	if !bytes.Contains(metaBuf.Bytes(), []byte("-150.00 (-1.29 %)")) {
+		t.Errorf("Expected total return buy & hold of -150.00 but got %+v", metaBuf.String())
+	}
+}
+
+func TestCoveredCallEndDate(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	june30, _ := time.Parse(model.DateLayout, "2006-06-30")
+	july2, _ := time.Parse(model.DateLayout, "2006-07-02")
+	aug2, _ := time.Parse(model.DateLayout, "2006-08-02")
+
+	v1, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "1.1", "0.9", "115.5", "116.5")
+	v2, _ := model.NewOHLCV(july2, "SPY", july2, "116", model.Call, "0", "0", "0", "0", "623", "1", "1", "117.5", "118.5")
+	v3, _ := model.NewOHLCV(july2, "SPY", aug2, "118", model.Call, "1.1", "1.1", "1.1", "1.1", "55", "1.2", "1.1", "117.5", "118.5")
+	v4, _ := model.NewOHLCV(aug2, "SPY", aug2, "118", model.Call, "0.0", "0.0", "0.0", "0.0", "55", "1", "1", "119.8", "120")
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2, v3, v4})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	st, err := NewCoveredCallStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+
+	tt := []struct {
+		end   time.Time
+		execs int
+	}{
+		{end: time.Time{}, execs: 2},
+		// the position opened before the end date is still closed after it
+		{end: june30, execs: 1},
+		{end: july2, execs: 2},
+	}
+	for idx, tab := range tt {
+		strat, err := st.Run(model.StrategyOpts{
+			EndDate:    tab.end,
+			MinExpDays: 28,
+			StartDate:  june1,
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+		}
+		if len(strat.Execs) != tab.execs {
+			t.Fatalf("Expected %d executions but got %d at idx: %d", tab.execs, len(strat.Execs), idx)
+		}
+		if last := strat.Execs[len(strat.Execs)-1]; tab.execs == 1 && !last.Leg[buyStockLeg].Close.Date.Equal(july2) {
+			t.Errorf("Expected the last position to close on %+v but got %+v at idx: %d", july2, last.Leg[buyStockLeg].Close.Date, idx)
+		}
+	}
+}
+
+func TestCoveredCallGreeks(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2006-07-02")
+
+	v1, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "2.1", "1.9", "115.5", "116.5")
+	v2, _ := model.NewOHLCV(july2, "SPY", july2, "116", model.Call, "0", "0", "0", "0", "623", "1", "1", "117.5", "118.5")
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	chain.ComputeGreeks(model.SnapshotEOD)
+	st, err := NewCoveredCallStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+	strat, err := st.Run(model.StrategyOpts{
+		StartDate:  june1,
+		MinExpDays: 28,
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+	}
+	if len(strat.Execs) != 1 {
+		t.Fatalf("Expected 1 execution but got %d", len(strat.Execs))
+	}
+	call := chain.GetOptionChainForQuoteDate(june1, true).GetOptionChainForExpiryDate(july2, true).GetOptionChainForStrike(decimal.NewFromInt(116), true).Call
+	if !call.HasIV {
+		t.Fatal("Expected the call to have an implied volatility")
+	}
+	// long 100 shares and short one call
+	expected := decimal.NewFromInt(100).Sub(call.Delta.Mul(decimal.NewFromInt(100)))
+	if g := strat.Execs[0].PositionGreeks(); !g.Delta.Equal(expected) || !g.Theta.Equal(call.Theta.Mul(decimal.NewFromInt(-100))) {
+		t.Errorf("Expected delta %+v and theta %+v but got %+v", expected, call.Theta.Mul(decimal.NewFromInt(-100)), g)
+	}
+}
+
+func TestCoveredCallEntryIV(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	june2, _ := time.Parse(model.DateLayout, "2006-06-02")
+	july3, _ := time.Parse(model.DateLayout, "2006-07-03")
+
+	v1, _ := model.NewOHLCV(june1, "SPY", july3, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "2.1", "1.9", "115.5", "116.5")
+	v1, _ = v1.WithGreeks("0.2", "0.5", "0", "0", "0", "0")
+	v2, _ := model.NewOHLCV(june2, "SPY", july3, "116", model.Call, "1.1", "1.1", "1.1", "1.1", "623", "2.6", "2.4", "115.5", "116.5")
+	v2, _ = v2.WithGreeks("0.3", "0.5", "0", "0", "0", "0")
+	v3, _ := model.NewOHLCV(july3, "SPY", july3, "116", model.Call, "0", "0", "0", "0", "623", "1", "1", "117.5", "118.5")
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2, v3})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	chain.ComputeIVStats(model.SnapshotEOD, 30, 252)
+	st, err := NewCoveredCallStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+	// june1 has nothing to rank against, and june2 has the highest IV
+	strat, err := st.Run(model.StrategyOpts{
+		StartDate:  june1,
+		MinExpDays: 28,
+		EntryIV:    model.IVRange{MinRank: decimal.NewFromInt(50)},
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+	}
+	if len(strat.Execs) != 1 {
+		t.Fatalf("Expected 1 execution but got %d", len(strat.Execs))
+	}
+	if open := strat.Execs[0].Leg[coveredCallLeg].Open.Date; !open.Equal(june2) {
+		t.Errorf("Expected the call to be opened on %+v but got %+v", june2, open)
+	}
+}
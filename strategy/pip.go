@@ -45,6 +45,18 @@ func (s *pip) Validate(opts model.StrategyOpts) error {
 	if opts.PipOpts.TgtPutPxMul.IsZero() {
 		return errors.Errorf("Expected `TgtPutPxMul` to be non-zero")
 	}
+	if err := opts.CallStrike.Validate(); err != nil {
+		return errors.Wrap(err, "Error validating the call strike")
+	}
+	if err := opts.PutStrike.Validate(); err != nil {
+		return errors.Wrap(err, "Error validating the put strike")
+	}
+	if err := opts.EntryIV.Validate(); err != nil {
+		return errors.Wrap(err, "Error validating the entry IV range")
+	}
+	if err := s.optchain.ValidateSnapshot(opts.Snapshot); err != nil {
+		return errors.Wrap(err, "Error validating the snapshot, use the eod snapshot for data without 15:45 quotes")
+	}
 	return nil
 }
 
@@ -60,32 +72,41 @@ func (s *pip) Run(opts model.StrategyOpts) (*model.StrategyResult, error) {
 	tgtPutPxMul := opts.PipOpts.TgtPutPxMul
 
 	for {
-		optchain := s.optchain.GetOptionChainForQuoteDate(start, false)
+		optchain := quoteOnOrAfter(s.optchain, opts, start)
 		if optchain == nil {
 			log.Warnf("Exiting since quote does not exist for date %+v", start)
 			break
 		}
 		quotedate := optchain.QuoteDate
+		if afterEnd(opts, quotedate) {
+			log.Infof("Exiting since quote date %+v is after the end date %+v", quotedate.Format(model.DateLayout), opts.EndDate.Format(model.DateLayout))
+			break
+		}
+		if !opts.EntryIV.Allows(optchain.IV) {
+			log.Debugf("Skipping quote date %+v since its IV %+v is outside the entry range %+v", quotedate.Format(model.DateLayout), optchain.IV, opts.EntryIV)
+			start = quotedate.AddDate(0, 0, 1)
+			continue
+		}
 
-		px := optchain.UndPx
+		px := optchain.UndPxAt(opts.Snapshot)
 		callpx := px.Mul(tgtCallPxMul)
-		callexpdate := quotedate.AddDate(0, 0, shortCallMinDays)
-		callstrike := s.getStrikePx(optchain, callexpdate, callpx)
+		callexpdate := expiryTarget(opts, quotedate, shortCallMinDays)
+		callstrike := s.getStrike(optchain, callexpdate, model.Call, opts.CallStrike, px, callpx, opts.Snapshot)
 		if callstrike == nil {
 			log.Warnf("Exiting since call strike does not exist for price %+v, expire date %+v, for quote date: %+v", callpx, callexpdate, start)
 			break
 		}
 
-		putexpdate := quotedate.AddDate(0, 0, longPutMinDays)
+		putexpdate := expiryTarget(opts, quotedate, longPutMinDays)
 		putpx := px.Mul(tgtPutPxMul)
-		putstrike := s.getStrikePx(optchain, putexpdate, putpx)
+		putstrike := s.getStrike(optchain, putexpdate, model.Put, opts.PutStrike, px, putpx, opts.Snapshot)
 		if putstrike == nil {
 			log.Warnf("Exiting since initial put strike does not exist for price %+v, expire date %+v, for quote date: %+v", putpx, putexpdate, start)
 			break
 		}
 
-		// purchase 100 underlying stocks
-		stkqty := decimal.NewFromInt(100)
+		// purchase the underlying stocks delivered by one option contract, which is 100 for US options
+		stkqty := callstrike.Call.Multiplier
 		stkleg := model.NewOpenExec(
 			model.Stock,
 			quotedate,
@@ -99,48 +120,63 @@ func (s *pip) Run(opts model.StrategyOpts) (*model.StrategyResult, error) {
 		optleg := model.NewOpenExec(
 			model.Option,
 			quotedate,
-			callstrike.Call.AskBidMid,
+			callstrike.Call.MidAt(opts.Snapshot),
 			optqty,
 			model.Sell,
 			fmt.Sprintf("%+v C %+v", callstrike.S.String(), callstrike.Exp.Format("2006-01-02")),
 		)
+		optleg.Multiplier = callstrike.Call.Multiplier
+		optleg.Greeks = callstrike.Call.Greeks()
 
 		putleg := model.NewOpenExec(
 			model.Option,
 			quotedate,
-			putstrike.Put.AskBidMid,
+			putstrike.Put.MidAt(opts.Snapshot),
 			optqty,
 			model.Buy,
 			fmt.Sprintf("%+v P %+v", putstrike.S.String(), putstrike.Exp.Format("2006-01-02")),
 		)
+		putleg.Multiplier = putstrike.Put.Multiplier
+		putleg.Greeks = putstrike.Put.Greeks()
 
 		expire := callstrike.Exp
-		expiredquote := s.optchain.GetOptionChainForQuoteDate(expire, false)
-		if expiredquote == nil {
-			log.Debugf("Exiting since GetOptionChainForQuoteDate does not exist for quotedate: %+v, expiredate: %+v, start: %+v",
-				quotedate,
-				expire,
-				start)
-			break
+		// the put is closed at the quotes of the settlement session
+		settled := settle(s.optchain, opts, quotedate, expire)
+		if settled == nil || settled.Quote == nil {
+			if s.optchain.GetOptionChainForQuoteDate(expire, false) == nil {
+				log.Debugf("Exiting since settlement quote does not exist for quotedate: %+v, expiredate: %+v, start: %+v",
+					quotedate,
+					expire,
+					start)
+				break
+			}
+			log.Warnf("Skipping position opened on %+v since its expiry %+v has no quotes to settle and close the put at", quotedate.Format(model.DateLayout), expire.Format(model.DateLayout))
+			start = expire
+			continue
 		}
-		endpx := expiredquote.UndPx
+		settle := settled.Date
+		// prices after a split are compared with the strike in the terms of the shares held at open
+		factor := splitFactor(s.optchain, quotedate, settle)
+		endpx := settled.Px.Mul(factor)
 
-		// close 100 underlying stocks, capped at the lower of the price and strike
+		// close the underlying stocks, capped at the lower of the price and strike
 		adjendpx := endpx
 		if endpx.GreaterThan(callstrike.S) {
 			adjendpx = callstrike.S
 		}
 
-		stkleg.CloseExec(expire, adjendpx)
+		stkleg.CloseExec(settle, adjendpx)
+		stkleg.Dividend = dividends(s.optchain, quotedate, settle)
 
-		optleg.CloseExec(expire, decimal.NewFromInt(0))
+		optleg.CloseExec(settle, decimal.NewFromInt(0))
 
-		putendstrike := s.getStrikePx(expiredquote, putstrike.Exp, putstrike.S)
-		if putendstrike == nil {
-			log.Warnf("Exiting since last put strike does not exist for price %+v, expire date %+v, for quote date: %+v", putstrike.S, putstrike.Exp, expiredquote)
+		// a split divides the strike and multiplies the number of contracts by the split factor
+		putendpx, err := s.getPutClosePx(settled.Quote, putstrike.Exp, putstrike.S.Div(factor), opts)
+		if err != nil {
+			log.Warn(errors.Wrapf(err, "Exiting since last put does not exist for quote date: %+v", settle.Format(model.DateLayout)))
 			break
 		}
-		putleg.CloseExec(expire, putendstrike.Put.AskBidMid)
+		putleg.CloseExec(settle, putendpx.Mul(factor))
 
 		legs := map[string]*model.ExecOpenClose{
 			pipcoveredCallLeg: optleg,
@@ -161,6 +197,46 @@ func (s *pip) Run(opts model.StrategyOpts) (*model.StrategyResult, error) {
 	return newstrat, nil
 }
 
+// getStrike returns the strike of the target for a given option chain and expire time. The strike nearest to tgtpx is returned if the target is zero, and targets out of the money are relative to the underlying price undpx
+func (s *pip) getStrike(optchain *model.OptChain, expd time.Time, typ model.OptType, target model.StrikeTarget, undpx, tgtpx decimal.Decimal, snapshot model.Snapshot) *model.OptChainStrike {
+	if target.IsZero() {
+		return s.getStrikePx(optchain, expd, tgtpx)
+	}
+	chain := optchain.GetOptionChainForExpiryDate(expd, false)
+	if chain == nil {
+		log.Warnf("Exiting since expire does not exist for expire date %+v", expd)
+		return nil
+	}
+	strike := chain.GetOptionChainForTarget(typ, target, undpx, snapshot)
+	if strike == nil {
+		log.Warnf("Exiting since strike does not exist for target %+v, expire date %+v", target, expd)
+		return nil
+	}
+	return strike
+}
+
+// getPutClosePx returns the mid price of the put of the strike and expiry on the quote. The put of the nearest strike and expiry is used if it is missing from the data, unless SurfacePricing prices it from the volatility surface of the quote
+func (s *pip) getPutClosePx(quote *model.OptChain, expd time.Time, k decimal.Decimal, opts model.StrategyOpts) (decimal.Decimal, error) {
+	if opts.SurfacePricing {
+		if exp := quote.GetOptionChainForExpiryDate(expd, true); exp != nil {
+			if strike := exp.GetOptionChainForStrike(k, true); strike != nil && strike.Put.Type == model.Put {
+				return strike.Put.MidAt(opts.Snapshot), nil
+			}
+		}
+		put, err := s.optchain.SurfaceOption(quote, model.Put, k, expd, opts.Snapshot)
+		if err != nil {
+			return decimal.Zero, errors.Wrapf(err, "Error pricing put %+v expiring on %+v from the volatility surface", k, expd.Format(model.DateLayout))
+		}
+		log.Infof("Pricing put %+v expiring on %+v at %+v from the volatility surface of %+v", k, expd.Format(model.DateLayout), put.MidAt(opts.Snapshot), quote.QuoteDate.Format(model.DateLayout))
+		return put.MidAt(opts.Snapshot), nil
+	}
+	strike := s.getStrikePx(quote, expd, k)
+	if strike == nil {
+		return decimal.Zero, errors.Errorf("Expected a put near strike %+v expiring on %+v", k, expd.Format(model.DateLayout))
+	}
+	return strike.Put.MidAt(opts.Snapshot), nil
+}
+
 // getStrikePx returns strike price for a given option chain, expire time and nearest price
 func (s *pip) getStrikePx(optchain *model.OptChain, expd time.Time, px decimal.Decimal) *model.OptChainStrike {
 	chain := optchain.GetOptionChainForExpiryDate(expd, false)
@@ -249,6 +325,7 @@ func (s *pip) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 	one := decimal.NewFromInt(1)
 	firstPx := decimal.NewFromInt(0)
 	lastPx := decimal.NewFromInt(0)
+	shares := decimal.NewFromInt(model.DefaultMultiplier)
 
 	for idx, ex := range r.Execs {
 		cc, ok := ex.Leg[pipcoveredCallLeg]
@@ -261,6 +338,7 @@ func (s *pip) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 		}
 		if idx == 0 {
 			firstPx = stk.Open.Px
+			shares = stk.Open.Qty
 		}
 		if idx == len(r.Execs)-1 {
 			lastPx = stk.Close.Px
@@ -290,14 +368,23 @@ func (s *pip) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 		data = append(data, d)
 	}
 
+	dividend := decimal.Zero
+	if len(r.Execs) > 0 {
+		first := r.Execs[0].Leg[pipbuyStockLeg]
+		last := r.Execs[len(r.Execs)-1].Leg[pipbuyStockLeg]
+		firstPx, lastPx = buyAndHoldPx(s.optchain, first.Open.Date, last.Close.Date, firstPx, lastPx)
+		dividend = dividends(s.optchain, first.Open.Date, last.Close.Date)
+	}
+
 	table := tablewriter.NewWriter(w)
 	table.SetHeader([]string{
 		"Total Profit",
 		"Total Executions",
 		"Max Drawdown",
 		"Buy & Hold",
+		"Buy & Hold (Total Return)",
 	})
-	initbp := firstPx.Mul(hundred)
+	initbp := firstPx.Mul(shares)
 	data = [][]string{
 		[]string{
 			fmt.Sprintf("%s (%s %%)",
@@ -306,8 +393,11 @@ func (s *pip) OutputMeta(w io.Writer, r *model.StrategyResult) error {
 			fmt.Sprintf("%d", r.Meta.TotalExecutions),
 			fmt.Sprintf("%s", maxdrawdown.Mul(hundred).StringFixed(2)),
 			fmt.Sprintf("%s (%s %%)",
-				lastPx.Sub(firstPx).Mul(hundred).StringFixed(2),
-				lastPx.Sub(firstPx).Mul(hundred).Mul(hundred).Div(initbp).StringFixed(2)),
+				lastPx.Sub(firstPx).Mul(shares).StringFixed(2),
+				lastPx.Sub(firstPx).Mul(shares).Mul(hundred).Div(initbp).StringFixed(2)),
+			fmt.Sprintf("%s (%s %%)",
+				lastPx.Sub(firstPx).Add(dividend).Mul(shares).StringFixed(2),
+				lastPx.Sub(firstPx).Add(dividend).Mul(shares).Mul(hundred).Div(initbp).StringFixed(2)),
 		},
 	}
 
@@ -165,7 +165,7 @@ func TestPipStrategy(t *testing.T) {
 	if exp.Opts.MinExpDays != strat.Opts.MinExpDays {
 		t.Errorf("Expected MinExpDays to be %+v but got %+v", exp.Opts.MinExpDays, strat.Opts.MinExpDays)
 	}
-	if exp.Opts.EndDate != strat.Opts.EndDate {
+	if !exp.Opts.EndDate.Equal(strat.Opts.EndDate) {
 		t.Errorf("Expected EndDate to be %+v but got %+v", exp.Opts.EndDate, strat.Opts.EndDate)
 	}
 	if !exp.Opts.StartDate.Equal(strat.Opts.StartDate) {
@@ -222,11 +222,11 @@ func TestPipStrategy(t *testing.T) {
 		t.Error(errors.Wrap(err, "expected no error to occur when GenerateResults is ran"))
 	}
 
-	metawant := `+----------------+------------------+--------------+------------------+
-|  TOTAL PROFIT  | TOTAL EXECUTIONS | MAX DRAWDOWN |    BUY & HOLD    |
-+----------------+------------------+--------------+------------------+
-| 20.00 (0.17 %) |                2 |        73.33 | -25.00 (-0.22 %) |
-+----------------+------------------+--------------+------------------+
+	metawant := `+----------------+------------------+--------------+------------------+---------------------------+
+|  TOTAL PROFIT  | TOTAL EXECUTIONS | MAX DRAWDOWN |    BUY & HOLD    | BUY & HOLD (TOTAL RETURN) |
++----------------+------------------+--------------+------------------+---------------------------+
+| 20.00 (0.17 %) |                2 |        73.33 | -25.00 (-0.22 %) | -25.00 (-0.22 %)          |
++----------------+------------------+--------------+------------------+---------------------------+
 `
 	if metaBuf.String() != metawant {
 		t.Errorf("Expected to write %+v but got %+v",
@@ -462,3 +462,66 @@ func TestPipStrategyOptsMinPutExpDTE(t *testing.T) {
 		}
 	}
 }
+
+func TestPipStrategySurfacePricing(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2006-06-01")
+	june8, _ := time.Parse(model.DateLayout, "2006-06-08")
+	dec15, _ := time.Parse(model.DateLayout, "2006-12-15")
+
+	v1, _ := model.NewOHLCV(june1, "SPY", june8, "116", model.Call, "0.0", "0.0", "0.0", "0.0", "623", "1.1", "0.9", "115.5", "116.5")
+	v2, _ := model.NewOHLCV(june1, "SPY", dec15, "116", model.Put, "0.0", "0.0", "0.0", "0.0", "0", "4.1", "3.8", "115.5", "116.5")
+	v3, _ := model.NewOHLCV(june8, "SPY", june8, "116", model.Call, "0.0", "0.0", "0.0", "0.0", "0", "0", "0", "115.5", "116.5")
+	// the 116 put is missing on the day it is closed
+	v4, _ := model.NewOHLCV(june8, "SPY", dec15, "110", model.Put, "0.0", "0.0", "0.0", "0.0", "0", "2.1", "1.9", "115.5", "116.5")
+	v4, _ = v4.WithGreeks("0.2", "-0.3", "0", "0", "0", "0")
+	v5, _ := model.NewOHLCV(june8, "SPY", dec15, "120", model.Put, "0.0", "0.0", "0.0", "0.0", "0", "9.1", "8.9", "115.5", "116.5")
+	v5, _ = v5.WithGreeks("0.3", "-0.6", "0", "0", "0", "0")
+
+	chain, err := model.NewOptionChain([]model.OHLCV{v1, v2, v3, v4, v5})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+	}
+	rates, err := model.NewRateCurve(nil, decimal.Zero)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating rate curve"))
+	}
+	chain.SetRateCurve(rates)
+	surface, err := chain.SurfaceOption(chain.GetOptionChainForQuoteDate(june8, true), model.Put, decimal.NewFromInt(116), dec15, model.SnapshotEOD)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error pricing the put from the surface"))
+	}
+	if !surface.MidAt(model.SnapshotEOD).IsPositive() {
+		t.Fatalf("Expected a positive surface price but got %+v", surface)
+	}
+	st, err := NewPIPStrategy(chain)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+	}
+
+	tt := []struct {
+		surfacePricing bool
+		expected       decimal.Decimal
+	}{
+		// the nearest listed put, 120
+		{surfacePricing: false, expected: decimal.NewFromInt(9)},
+		{surfacePricing: true, expected: surface.MidAt(model.SnapshotEOD)},
+	}
+	for idx, tab := range tt {
+		strat, err := st.Run(model.StrategyOpts{
+			SurfacePricing: tab.surfacePricing,
+			PipOpts: &model.PipOpts{
+				MinCallExpDTE: 7,
+				MinPutExpDTE:  150,
+			},
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling pip"))
+		}
+		if len(strat.Execs) != 1 {
+			t.Fatalf("Expected 1 execution but got %d at idx: %d", len(strat.Execs), idx)
+		}
+		if px := strat.Execs[0].Leg[pipfarput].Close.Px; !px.Equal(tab.expected) {
+			t.Errorf("Expected the put to close at %+v but got %+v at idx: %d", tab.expected, px, idx)
+		}
+	}
+}
@@ -3,6 +3,10 @@ package strategy
 import (
 	"backtest-options/model"
 	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
 )
 
 // Strategy is a strategy interface
@@ -12,3 +16,134 @@ type Strategy interface {
 	OutputDetail(w io.Writer, s *model.StrategyResult) error
 	Validate(opts model.StrategyOpts) error
 }
+
+// buyAndHoldPx returns the underlying prices to compare a strategy with buying and holding from open to close. The closes of the underlying price history are used when the chain has one, and the stock leg prices otherwise. The close is split adjusted to the shares held at open.
+func buyAndHoldPx(chain *model.OptChainList, open, close time.Time, openPx, closePx decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	und := chain.Underlying()
+	if und == nil {
+		return openPx, closePx
+	}
+	first, ok := und.Get(open, false)
+	if !ok {
+		return openPx, closePx
+	}
+	last, ok := und.Get(close, true)
+	if !ok {
+		bars := und.Between(open, close)
+		if len(bars) == 0 {
+			return openPx, closePx
+		}
+		last = bars[len(bars)-1]
+	}
+	return first.Close, last.Close.Mul(splitFactor(chain, open, close))
+}
+
+// splitFactor returns the number of shares held at close for every share held at open. Prices quoted after a split are multiplied by it to compare them with prices at open. It is 1 if the chain has no corporate actions
+func splitFactor(chain *model.OptChainList, open, close time.Time) decimal.Decimal {
+	actions := chain.CorporateActions()
+	if actions == nil {
+		return decimal.NewFromInt(1)
+	}
+	return actions.SplitFactor(open, close)
+}
+
+// dividends returns the cash dividends received for every share held from open to close. It is zero if the chain has no corporate actions
+func dividends(chain *model.OptChainList, open, close time.Time) decimal.Decimal {
+	actions := chain.CorporateActions()
+	if actions == nil {
+		return decimal.Zero
+	}
+	return actions.Dividends(open, close)
+}
+
+// expiryTarget returns the date days after the quote date, which is the earliest expiry to trade. days are trading days of the calendar if TradingDayDTE is set, and calendar days otherwise
+func expiryTarget(opts model.StrategyOpts, quotedate time.Time, days int) time.Time {
+	if opts.TradingDayDTE && opts.Calendar != nil {
+		return opts.Calendar.AddTradingDays(quotedate, days)
+	}
+	return quotedate.AddDate(0, 0, days)
+}
+
+// settlement is the session an expiry settles on and the underlying price it settles at
+type settlement struct {
+	// Date is the last trading session on or before the expiry date, or the quote date on the expiry date if it is quoted
+	Date time.Time
+	// Px is the underlying price the expiry settles at
+	Px decimal.Decimal
+	// Quote is the option chain of Date, or nil if it has no quotes and Px is from the underlying price history
+	Quote *model.OptChain
+}
+
+// settle returns the settlement of an expiry opened on open. Saturday expiries and expiries on holidays settle on the session before. If the settlement session has no quotes, the expiry settles at the underlying price history of the session. It returns nil if the session is not after open, or if neither has it
+func settle(chain *model.OptChainList, opts model.StrategyOpts, open, expire time.Time) *settlement {
+	last := lastSession(opts, expire)
+	if quote := chain.GetLastOptionChainForQuoteDate(expire); quote != nil && quote.QuoteDate.After(open) && !quote.QuoteDate.Before(last) {
+		return &settlement{Date: quote.QuoteDate, Px: settlementPx(chain, opts, quote), Quote: quote}
+	}
+	if !last.After(open) {
+		return nil
+	}
+	und := chain.Underlying()
+	if und == nil {
+		return nil
+	}
+	bar, ok := und.Get(last, true)
+	if !ok {
+		return nil
+	}
+	px := bar.Close
+	if opts.Settlement.IsAM(chain.Key().Root) && !bar.Open.IsZero() {
+		px = bar.Open
+	}
+	if px.IsZero() {
+		return nil
+	}
+	log.Warnf("Missing quotes for settlement day %+v of expiry %+v, settling at %+v of the underlying price history",
+		last.Format(model.DateLayout), expire.Format(model.DateLayout), px)
+	return &settlement{Date: last, Px: px}
+}
+
+// lastSession returns the last trading day on or before t. Every weekday is a trading day without a calendar
+func lastSession(opts model.StrategyOpts, t time.Time) time.Time {
+	if opts.Calendar != nil {
+		return opts.Calendar.LastTradingDay(t)
+	}
+	for t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// settlementPx returns the underlying price an expiry settles at. AM settled expiries settle at the opening price of the settlement session in the underlying price history, and the others at the quote of the settlement session
+func settlementPx(chain *model.OptChainList, opts model.StrategyOpts, quote *model.OptChain) decimal.Decimal {
+	if opts.Settlement.IsAM(chain.Key().Root) {
+		if und := chain.Underlying(); und != nil {
+			if bar, ok := und.Get(quote.QuoteDate, true); ok && !bar.Open.IsZero() {
+				return bar.Open
+			}
+		}
+		log.Warnf("Settling AM expiry at the %+v quote of %+v since the underlying price history has no opening price",
+			opts.Snapshot, quote.QuoteDate.Format(model.DateLayout))
+	}
+	return quote.UndPxAt(opts.Snapshot)
+}
+
+// afterEnd returns true if no positions are opened on t since it is after the end date
+func afterEnd(opts model.StrategyOpts, t time.Time) bool {
+	return !opts.EndDate.IsZero() && t.After(opts.EndDate)
+}
+
+// quoteOnOrAfter returns the option chain of the first quote date on or after t. Trading days without quotes that are skipped over are reported, while holidays and days before the first quote are skipped silently
+func quoteOnOrAfter(chain *model.OptChainList, opts model.StrategyOpts, t time.Time) *model.OptChain {
+	optchain := chain.GetOptionChainForQuoteDate(t, false)
+	if optchain == nil || opts.Calendar == nil || t.IsZero() || !optchain.QuoteDate.After(t) {
+		return optchain
+	}
+	if chain.GetLastOptionChainForQuoteDate(t) == nil {
+		return optchain
+	}
+	for _, d := range opts.Calendar.TradingDays(t, optchain.QuoteDate.AddDate(0, 0, -1)) {
+		log.Warnf("Missing quotes for trading day %+v, using %+v", d.Format(model.DateLayout), optchain.QuoteDate.Format(model.DateLayout))
+	}
+	return optchain
+}
@@ -0,0 +1,255 @@
+package strategy
+
+import (
+	"backtest-options/calendar"
+	"backtest-options/model"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestExpiryTarget(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	tt := []struct {
+		opts   model.StrategyOpts
+		quote  string
+		days   int
+		target string
+	}{
+		// calendar days without a calendar
+		{opts: model.StrategyOpts{}, quote: "2020-04-06", days: 4, target: "2020-04-10"},
+		// the calendar only counts trading days when asked to
+		{opts: model.StrategyOpts{Calendar: calendar.NYSE()}, quote: "2020-04-06", days: 4, target: "2020-04-10"},
+		// good friday is skipped
+		{opts: model.StrategyOpts{Calendar: calendar.NYSE(), TradingDayDTE: true}, quote: "2020-04-06", days: 4, target: "2020-04-13"},
+	}
+	for idx, tab := range tt {
+		if target := expiryTarget(tab.opts, parse(tab.quote), tab.days); !target.Equal(parse(tab.target)) {
+			t.Errorf("Expected target to be %+v but got %+v at idx: %d", tab.target, target, idx)
+		}
+	}
+}
+
+func TestSaturdayExpirySettlement(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	jan7 := parse("2005-01-07")
+	jan21 := parse("2005-01-21")
+	jan22 := parse("2005-01-22")
+	jan24 := parse("2005-01-24")
+	feb19 := parse("2005-02-19")
+
+	tt := []struct {
+		root       string
+		settlement model.Settlement
+		closePx    string
+	}{
+		// the saturday expiry settles at the friday close, not at the monday quote of 119.6
+		{root: "SPY", settlement: model.SettlementAuto, closePx: "116.6"},
+		// SPX is AM settled at the friday open
+		{root: "SPX", settlement: model.SettlementAuto, closePx: "117.2"},
+		{root: "SPX", settlement: model.SettlementPM, closePx: "116.6"},
+	}
+	for idx, tab := range tt {
+		rows := make([]model.OHLCV, 0)
+		for _, r := range []struct {
+			quote  time.Time
+			exp    time.Time
+			undbid string
+			undask string
+		}{
+			{jan7, jan22, "118.8", "118.9"},
+			{jan21, jan22, "116.5", "116.7"},
+			{jan24, feb19, "119.5", "119.7"},
+		} {
+			v, _ := model.NewOHLCV(r.quote, "SPY", r.exp, "119", model.Call, "1", "1", "1", "1", "10", "1.1", "0.9", r.undbid, r.undask)
+			v.Root = tab.root
+			rows = append(rows, v)
+		}
+		chain, err := model.NewOptionChain(rows)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+		}
+		und, err := model.NewUnderlyingSeries("SPY", []model.UnderlyingBar{
+			{Date: jan21, Open: decimal.NewFromFloat(117.2), Close: decimal.NewFromFloat(116.6)},
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating underlying series"))
+		}
+		chain.SetUnderlying(und)
+
+		st, err := NewCoveredCallStrategy(chain)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+		}
+		strat, err := st.Run(model.StrategyOpts{
+			Calendar:   calendar.NYSE(),
+			MinExpDays: 10,
+			Settlement: tab.settlement,
+			StartDate:  jan7,
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+		}
+		if len(strat.Execs) != 1 {
+			t.Fatalf("Expected 1 execution but got %d at idx: %d", len(strat.Execs), idx)
+		}
+		stk := strat.Execs[0].Leg[buyStockLeg]
+		if !stk.Close.Px.Equal(decimal.RequireFromString(tab.closePx)) {
+			t.Errorf("Expected stock close px to be %+v but got %+v at idx: %d", tab.closePx, stk.Close.Px, idx)
+		}
+		if !stk.Close.Date.Equal(jan21) {
+			t.Errorf("Expected stock close date to be %+v but got %+v at idx: %d", jan21, stk.Close.Date, idx)
+		}
+	}
+}
+
+func TestMissingSettlementSession(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	jan7 := parse("2005-01-07")
+	jan20 := parse("2005-01-20")
+	jan21 := parse("2005-01-21")
+	jan24 := parse("2005-01-24")
+	feb19 := parse("2005-02-19")
+
+	tt := []struct {
+		bars    []model.UnderlyingBar
+		execs   int
+		closePx string
+	}{
+		// the friday settlement session has no quotes, so it settles at the close of the underlying price history instead of the thursday quote
+		{bars: []model.UnderlyingBar{{Date: jan21, Open: decimal.NewFromFloat(117.2), Close: decimal.NewFromFloat(116.6)}}, execs: 1, closePx: "116.6"},
+		// without a price for the settlement session the position is skipped
+		{bars: []model.UnderlyingBar{{Date: jan20, Open: decimal.NewFromFloat(117.2), Close: decimal.NewFromFloat(117.4)}}, execs: 0},
+	}
+	for idx, tab := range tt {
+		rows := make([]model.OHLCV, 0)
+		for _, r := range []struct {
+			quote time.Time
+			exp   time.Time
+		}{
+			{jan7, jan21},
+			{jan20, jan21},
+			{jan24, feb19},
+		} {
+			v, _ := model.NewOHLCV(r.quote, "SPY", r.exp, "119", model.Call, "1", "1", "1", "1", "10", "1.1", "0.9", "118.8", "118.9")
+			rows = append(rows, v)
+		}
+		chain, err := model.NewOptionChain(rows)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+		}
+		und, err := model.NewUnderlyingSeries("SPY", tab.bars)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating underlying series"))
+		}
+		chain.SetUnderlying(und)
+
+		st, err := NewCoveredCallStrategy(chain)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+		}
+		strat, err := st.Run(model.StrategyOpts{
+			Calendar:   calendar.NYSE(),
+			MinExpDays: 10,
+			StartDate:  jan7,
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+		}
+		if len(strat.Execs) != tab.execs {
+			t.Fatalf("Expected %d executions but got %d at idx: %d", tab.execs, len(strat.Execs), idx)
+		}
+		if tab.execs == 0 {
+			continue
+		}
+		stk := strat.Execs[0].Leg[buyStockLeg]
+		if !stk.Close.Px.Equal(decimal.RequireFromString(tab.closePx)) {
+			t.Errorf("Expected stock close px to be %+v but got %+v at idx: %d", tab.closePx, stk.Close.Px, idx)
+		}
+		if !stk.Close.Date.Equal(jan21) {
+			t.Errorf("Expected stock close date to be %+v but got %+v at idx: %d", jan21, stk.Close.Date, idx)
+		}
+	}
+}
+
+func TestJPXSettlement(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	may14 := parse("2021-05-14")
+	jun11 := parse("2021-06-11")
+	jul9 := parse("2021-07-09")
+
+	tt := []struct {
+		settlement model.Settlement
+		closePx    string
+	}{
+		// Nikkei 225 options settle at the SQ, the opening price of the SQ date
+		{settlement: model.SettlementAuto, closePx: "28900"},
+		{settlement: model.SettlementPM, closePx: "28948.73"},
+	}
+	for idx, tab := range tt {
+		rows := make([]model.OHLCV, 0)
+		for _, r := range []struct {
+			quote time.Time
+			exp   time.Time
+			undpx string
+		}{
+			{may14, jun11, "28084.47"},
+			// the expiring contract stops trading the day before its SQ date, but later contracts are quoted on it
+			{jun11, jul9, "28948.73"},
+		} {
+			// the JPX importer writes the N225 symbol without a root
+			v, _ := model.NewOHLCV(r.quote, "N225", r.exp, "29500", model.Call, "0", "0", "0", "0", "0", "300", "300", r.undpx, r.undpx)
+			v.Multiplier = decimal.NewFromInt(1000)
+			rows = append(rows, v)
+		}
+		chain, err := model.NewOptionChain(rows)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating NewOptionChain"))
+		}
+		und, err := model.NewUnderlyingSeries("N225", []model.UnderlyingBar{
+			{Date: jun11, Open: decimal.NewFromInt(28900), Close: decimal.NewFromFloat(28948.73)},
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating underlying series"))
+		}
+		chain.SetUnderlying(und)
+
+		st, err := NewCoveredCallStrategy(chain)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error creating new strategy"))
+		}
+		strat, err := st.Run(model.StrategyOpts{
+			Calendar:   calendar.JPX(),
+			MinExpDays: 20,
+			Settlement: tab.settlement,
+			StartDate:  may14,
+		})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error from calling covered call"))
+		}
+		if len(strat.Execs) != 1 {
+			t.Fatalf("Expected 1 execution but got %d at idx: %d", len(strat.Execs), idx)
+		}
+		stk := strat.Execs[0].Leg[buyStockLeg]
+		if !stk.Close.Px.Equal(decimal.RequireFromString(tab.closePx)) {
+			t.Errorf("Expected stock close px to be %+v but got %+v at idx: %d", tab.closePx, stk.Close.Px, idx)
+		}
+		if !stk.Close.Date.Equal(jun11) {
+			t.Errorf("Expected stock close date to be %+v but got %+v at idx: %d", jun11, stk.Close.Date, idx)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package synth
+
+import (
+	"backtest-options/calendar"
+	"backtest-options/model"
+	"backtest-options/pricing"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// minVol is the lowest implied volatility options are priced with
+const minVol = 0.01
+
+// realizedLookback is the number of daily returns the realized volatility is measured over
+const realizedLookback = 20
+
+// Skew is the implied volatility surface options are priced with
+type Skew struct {
+	// ATMVol is the implied volatility of 30 day at the money options
+	ATMVol float64
+	// Curvature is the change of volatility per squared unit of standardized moneyness, which lifts both wings of the smile
+	Curvature float64
+	// FollowRealized raises the at the money volatility to the realized volatility of the last 20 days when that is higher, so that implied volatility spikes after a crash
+	FollowRealized bool
+	// Slope is the change of volatility per unit of standardized moneyness ln(K/F)/sqrt(T). It is negative for the usual equity skew
+	Slope float64
+	// TermSlope is the change of at the money volatility per year of maturity beyond 30 days
+	TermSlope float64
+}
+
+// Vol returns the implied volatility of an option with the standardized moneyness and t years to expiry, given the at the money volatility
+func (s Skew) Vol(atm, moneyness, t float64) float64 {
+	vol := atm + s.TermSlope*(t-30.0/365) + s.Slope*moneyness + s.Curvature*moneyness*moneyness
+	return math.Max(vol, minVol)
+}
+
+// Shock is a one day return applied to the simulated path on a date, e.g. -0.2 for a 20% crash
+type Shock struct {
+	Date   time.Time
+	Return float64
+}
+
+// Config configures generated option data
+type Config struct {
+	// Calendar is the trading calendar quotes and expiries follow. NYSE is used if it is nil
+	Calendar *calendar.Calendar
+	// DividendYield is the annual dividend yield of the underlying
+	DividendYield float64
+	// End is the last quote date
+	End time.Time
+	// MinSpread is the narrowest bid ask spread
+	MinSpread float64
+	// Monthlies is the number of monthly expiries, on the third Friday, listed on every quote date
+	Monthlies int
+	// Path simulates the underlying
+	Path Path
+	// Rate is the annual risk-free rate
+	Rate float64
+	// Root is the option root. The symbol is used if it is empty
+	Root string
+	// Seed seeds the simulation, so the same config always generates the same data
+	Seed int64
+	// Shocks are one day returns applied to the simulated path
+	Shocks []Shock
+	// Skew is the implied volatility surface
+	Skew Skew
+	// SpreadPct is the bid ask spread as a fraction of the option price
+	SpreadPct float64
+	// Start is the first quote date
+	Start time.Time
+	// StrikeRange is the range of listed strikes around the underlying price as a fraction, e.g. 0.2 for 20% below to 20% above
+	StrikeRange float64
+	// StrikeStep is the distance between strikes
+	StrikeStep float64
+	// Symbol is the underlying symbol
+	Symbol string
+	// Tick is the price increment of option quotes
+	Tick float64
+	// Weeklies is the number of weekly expiries, on Fridays, listed on every quote date
+	Weeklies int
+}
+
+// validate checks the config
+func (cfg Config) validate() error {
+	if cfg.Path == nil {
+		return errors.New("Expected a path to simulate")
+	}
+	if cfg.Symbol == "" {
+		return errors.New("Expected a symbol")
+	}
+	if cfg.Start.IsZero() || cfg.End.Before(cfg.Start) {
+		return errors.Errorf("Expected start %+v to be on or before end %+v", cfg.Start, cfg.End)
+	}
+	if cfg.StrikeStep <= 0 || cfg.StrikeRange <= 0 || cfg.Tick <= 0 {
+		return errors.Errorf("Expected strike step, strike range and tick to be positive but got %+v, %+v and %+v", cfg.StrikeStep, cfg.StrikeRange, cfg.Tick)
+	}
+	if cfg.Weeklies < 0 || cfg.Monthlies < 0 || cfg.Weeklies+cfg.Monthlies == 0 {
+		return errors.Errorf("Expected weekly or monthly expiries but got %+v and %+v", cfg.Weeklies, cfg.Monthlies)
+	}
+	if cfg.Skew.ATMVol <= 0 {
+		return errors.Errorf("Expected ATMVol to be positive but got %+v", cfg.Skew.ATMVol)
+	}
+	return nil
+}
+
+// Generate simulates the underlying over the trading days from Start to End and calls fn with every call and put quote, in order of quote date. It returns the simulated daily bars of the underlying.
+func Generate(cfg Config, fn func(model.OHLCV) error) ([]model.UnderlyingBar, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	cal := cfg.Calendar
+	if cal == nil {
+		cal = calendar.NYSE()
+	}
+	root := cfg.Root
+	if root == "" {
+		root = cfg.Symbol
+	}
+	days := cal.TradingDays(cfg.Start, cfg.End)
+	if len(days) == 0 {
+		return nil, errors.Errorf("Expected trading days between %+v and %+v", cfg.Start, cfg.End)
+	}
+	closes, err := cfg.Path.Closes(len(days), rand.New(rand.NewSource(cfg.Seed)))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error simulating the underlying")
+	}
+	days = days[:len(closes)]
+	closes = applyShocks(days, closes, cfg.Shocks)
+
+	bars := make([]model.UnderlyingBar, 0, len(days))
+	for i, d := range days {
+		s := closes[i]
+		open := s
+		if i > 0 {
+			open = closes[i-1]
+		}
+		bars = append(bars, model.UnderlyingBar{
+			Close: decimal.NewFromFloat(s).Round(2),
+			Date:  d,
+			High:  decimal.NewFromFloat(math.Max(open, s)).Round(2),
+			Low:   decimal.NewFromFloat(math.Min(open, s)).Round(2),
+			Open:  decimal.NewFromFloat(open).Round(2),
+		})
+
+		atm := cfg.Skew.ATMVol
+		if rv := realizedVol(closes[:i+1]); cfg.Skew.FollowRealized && rv > atm {
+			atm = rv
+		}
+		und := bars[i].Close
+		for _, exp := range listedExpiries(cal, d, cfg.Weeklies, cfg.Monthlies) {
+			t := exp.Sub(d).Hours() / 24 / 365
+			fwd := s * math.Exp((cfg.Rate-cfg.DividendYield)*t)
+			for _, k := range listedStrikes(s, cfg.StrikeRange, cfg.StrikeStep) {
+				moneyness := math.Log(k/fwd) / math.Sqrt(math.Max(t, 1.0/365))
+				vol := cfg.Skew.Vol(atm, moneyness, t)
+				for _, right := range []pricing.Right{pricing.Call, pricing.Put} {
+					mid := pricing.BlackScholes(right, s, k, t, cfg.Rate, cfg.DividendYield, vol)
+					o := cfg.quote(d, exp, k, right, mid, und, root)
+					if err := fn(o); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return bars, nil
+}
+
+// quote makes the quote of an option worth mid, with a spread around it
+func (cfg Config) quote(d, exp time.Time, k float64, right pricing.Right, mid float64, und decimal.Decimal, root string) model.OHLCV {
+	half := math.Max(cfg.MinSpread, cfg.SpreadPct*mid) / 2
+	bid := math.Max(math.Floor((mid-half)/cfg.Tick+1e-9)*cfg.Tick, 0)
+	ask := math.Ceil((mid+half)/cfg.Tick-1e-9) * cfg.Tick
+	if ask <= bid {
+		ask = bid + cfg.Tick
+	}
+	b := decimal.NewFromFloat(bid).Round(4)
+	a := decimal.NewFromFloat(ask).Round(4)
+	m := a.Add(b).Div(decimal.NewFromInt(2))
+	typ := model.Call
+	if right == pricing.Put {
+		typ = model.Put
+	}
+	return model.OHLCV{
+		Ask:        a,
+		Ask1545:    a,
+		AskBidMid:  m,
+		Bid:        b,
+		Bid1545:    b,
+		Close:      m,
+		Expiration: exp,
+		High:       m,
+		Low:        m,
+		Multiplier: decimal.NewFromInt(model.DefaultMultiplier),
+		Open:       m,
+		QuoteDate:  d,
+		Root:       root,
+		Strike:     decimal.NewFromFloat(k).Round(4),
+		Type:       typ,
+		UndAsk:     und,
+		UndAsk1545: und,
+		UndBid:     und,
+		UndBid1545: und,
+		UndSym:     cfg.Symbol,
+	}
+}
+
+// applyShocks multiplies the closes from the first trading day on or after each shock's date by one plus its return
+func applyShocks(days []time.Time, closes []float64, shocks []Shock) []float64 {
+	shocked := append([]float64{}, closes...)
+	for _, shock := range shocks {
+		i := sort.Search(len(days), func(i int) bool {
+			return !days[i].Before(shock.Date)
+		})
+		for j := i; j < len(shocked); j++ {
+			shocked[j] *= 1 + shock.Return
+		}
+	}
+	return shocked
+}
+
+// realizedVol returns the annualized volatility of the last daily log returns of closes, or zero if there are less than two returns
+func realizedVol(closes []float64) float64 {
+	start := len(closes) - realizedLookback - 1
+	if start < 0 {
+		start = 0
+	}
+	rets := make([]float64, 0, realizedLookback)
+	for i := start + 1; i < len(closes); i++ {
+		rets = append(rets, math.Log(closes[i]/closes[i-1]))
+	}
+	if len(rets) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, r := range rets {
+		mean += r
+	}
+	mean /= float64(len(rets))
+	variance := 0.0
+	for _, r := range rets {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(rets)-1) * daysPerYear)
+}
+
+// listedExpiries returns the expiries listed on d: the next weeklies Fridays and the third Fridays of the next monthlies months, including d itself. Expiries on holidays move to the trading day before
+func listedExpiries(cal *calendar.Calendar, d time.Time, weeklies, monthlies int) []time.Time {
+	seen := make(map[time.Time]bool)
+	expiries := make([]time.Time, 0, weeklies+monthlies)
+	add := func(friday time.Time) bool {
+		exp := cal.LastTradingDay(friday)
+		if exp.Before(d) {
+			return false
+		}
+		if !seen[exp] {
+			seen[exp] = true
+			expiries = append(expiries, exp)
+		}
+		return true
+	}
+
+	friday := d.AddDate(0, 0, (int(time.Friday)-int(d.Weekday())+7)%7)
+	for n := 0; n < weeklies; friday = friday.AddDate(0, 0, 7) {
+		if add(friday) {
+			n++
+		}
+	}
+	month := time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for n := 0; n < monthlies; month = month.AddDate(0, 1, 0) {
+		third := month.AddDate(0, 0, (int(time.Friday)-int(month.Weekday())+7)%7+14)
+		if add(third) {
+			n++
+		}
+	}
+	sort.Slice(expiries, func(i, j int) bool {
+		return expiries[i].Before(expiries[j])
+	})
+	return expiries
+}
+
+// listedStrikes returns the multiples of step within rng of s
+func listedStrikes(s, rng, step float64) []float64 {
+	lo := int(math.Ceil(s * (1 - rng) / step))
+	hi := int(math.Floor(s * (1 + rng) / step))
+	if lo < 1 {
+		lo = 1
+	}
+	strikes := make([]float64, 0, hi-lo+1)
+	for i := lo; i <= hi; i++ {
+		strikes = append(strikes, float64(i)*step)
+	}
+	return strikes
+}
@@ -0,0 +1,145 @@
+package synth
+
+import (
+	"backtest-options/calendar"
+	"backtest-options/model"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func testConfig(path Path) Config {
+	start, _ := time.Parse(model.DateLayout, "2020-03-30")
+	end, _ := time.Parse(model.DateLayout, "2020-04-17")
+	return Config{
+		Calendar:    calendar.NYSE(),
+		End:         end,
+		MinSpread:   0.02,
+		Monthlies:   2,
+		Path:        path,
+		Rate:        0.01,
+		Seed:        7,
+		Skew:        Skew{ATMVol: 0.2, Slope: -0.1},
+		SpreadPct:   0.05,
+		Start:       start,
+		StrikeRange: 0.1,
+		StrikeStep:  5,
+		Symbol:      "SYN",
+		Tick:        0.01,
+		Weeklies:    2,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	path, err := NewGBMPath(100, 0.05, 0.2)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating path"))
+	}
+	cfg := testConfig(path)
+
+	rows := make([]model.OHLCV, 0)
+	bars, err := Generate(cfg, func(o model.OHLCV) error {
+		rows = append(rows, o)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error generating"))
+	}
+	// 15 trading days without good friday
+	if len(bars) != 14 {
+		t.Errorf("Expected 14 bars but got %d", len(bars))
+	}
+	if !bars[0].Close.Equal(rows[0].UndBid) || bars[0].Close.String() != "100" {
+		t.Errorf("Expected the first close to be 100 but got %+v", bars[0].Close)
+	}
+
+	goodFriday, _ := time.Parse(model.DateLayout, "2020-04-10")
+	chain, err := model.NewOptionChain(rows)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating option chain"))
+	}
+	if chain.GetOptionChainForQuoteDate(goodFriday, true) != nil {
+		t.Errorf("Expected no quotes on good friday")
+	}
+	for idx, o := range rows {
+		if !o.Ask.GreaterThan(o.Bid) || o.Bid.IsNegative() {
+			t.Fatalf("Expected bid %+v below ask %+v at idx: %d", o.Bid, o.Ask, idx)
+		}
+		if o.Expiration.Equal(goodFriday) {
+			t.Fatalf("Expected the good friday expiry to move to thursday at idx: %d", idx)
+		}
+	}
+
+	// the same seed generates the same data
+	again := make([]model.OHLCV, 0)
+	if _, err := Generate(cfg, func(o model.OHLCV) error {
+		again = append(again, o)
+		return nil
+	}); err != nil {
+		t.Fatal(errors.Wrap(err, "Error generating"))
+	}
+	if len(again) != len(rows) || !again[len(again)-1].Ask.Equal(rows[len(rows)-1].Ask) {
+		t.Errorf("Expected the same rows for the same seed")
+	}
+}
+
+func TestListedExpiries(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	expiries := listedExpiries(calendar.NYSE(), parse("2020-04-03"), 2, 2)
+	expected := []string{"2020-04-03", "2020-04-09", "2020-04-17", "2020-05-15"}
+	if len(expiries) != len(expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, expiries)
+	}
+	for idx, exp := range expected {
+		if !expiries[idx].Equal(parse(exp)) {
+			t.Errorf("Expected %+v but got %+v at idx: %d", exp, expiries[idx], idx)
+		}
+	}
+}
+
+func TestShockAndReplay(t *testing.T) {
+	replay, err := NewReplayPath([]float64{100, 101, 102, 103, 104, 105, 106})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating path"))
+	}
+	cfg := testConfig(replay)
+	crash, _ := time.Parse(model.DateLayout, "2020-04-01")
+	cfg.Shocks = []Shock{{Date: crash, Return: -0.2}}
+	cfg.Skew.FollowRealized = true
+	bars, err := Generate(cfg, func(o model.OHLCV) error { return nil })
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error generating"))
+	}
+	// the replay ends after 7 days and the crash hits the third
+	expected := []string{"100", "101", "81.6", "82.4", "83.2", "84", "84.8"}
+	if len(bars) != len(expected) {
+		t.Fatalf("Expected %d bars but got %d", len(expected), len(bars))
+	}
+	for idx, exp := range expected {
+		if bars[idx].Close.String() != exp {
+			t.Errorf("Expected close to be %+v but got %+v at idx: %d", exp, bars[idx].Close, idx)
+		}
+	}
+}
+
+func TestJumpDiffusionPath(t *testing.T) {
+	path, err := NewJumpDiffusionPath(100, 0, 0, 252, -0.1, 0)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating path"))
+	}
+	closes, err := path.Closes(3, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error simulating"))
+	}
+	// a jump every day of exactly -10% in log terms, compensated by the drift
+	ret := math.Log(closes[2] / closes[1])
+	if want := -0.1 - (math.Exp(-0.1) - 1); math.Abs(ret-want) > 1e-9 {
+		t.Errorf("Expected daily log return to be %+v but got %+v", want, ret)
+	}
+}
@@ -0,0 +1,111 @@
+package synth
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// daysPerYear is the number of trading days a year used to scale annual drift and volatility to a day
+const daysPerYear = 252
+
+// Path simulates daily closes of the underlying
+type Path interface {
+	// Closes returns up to n daily closes, starting with the close of the first day
+	Closes(n int, rng *rand.Rand) ([]float64, error)
+}
+
+type gbmPath struct {
+	s0    float64
+	drift float64
+	vol   float64
+}
+
+// NewGBMPath is a geometric brownian motion starting at s0 with an annual drift and volatility
+func NewGBMPath(s0, drift, vol float64) (Path, error) {
+	if s0 <= 0 || vol < 0 {
+		return nil, errors.Errorf("Expected a positive start price and non-negative volatility but got %+v and %+v", s0, vol)
+	}
+	return &gbmPath{s0: s0, drift: drift, vol: vol}, nil
+}
+
+func (p *gbmPath) Closes(n int, rng *rand.Rand) ([]float64, error) {
+	return simulate(p.s0, p.drift, p.vol, 0, 0, 0, n, rng), nil
+}
+
+type jumpDiffusionPath struct {
+	s0        float64
+	drift     float64
+	vol       float64
+	intensity float64
+	jumpMean  float64
+	jumpVol   float64
+}
+
+// NewJumpDiffusionPath is a Merton jump diffusion starting at s0. Jumps arrive intensity times a year on average, and their log returns are normally distributed with jumpMean and jumpVol. The drift is compensated so that jumps do not change the expected return
+func NewJumpDiffusionPath(s0, drift, vol, intensity, jumpMean, jumpVol float64) (Path, error) {
+	if s0 <= 0 || vol < 0 || intensity < 0 || jumpVol < 0 {
+		return nil, errors.Errorf("Expected a positive start price and non-negative volatility, jump intensity and jump volatility but got %+v, %+v, %+v and %+v",
+			s0, vol, intensity, jumpVol)
+	}
+	return &jumpDiffusionPath{
+		s0:        s0,
+		drift:     drift,
+		vol:       vol,
+		intensity: intensity,
+		jumpMean:  jumpMean,
+		jumpVol:   jumpVol,
+	}, nil
+}
+
+func (p *jumpDiffusionPath) Closes(n int, rng *rand.Rand) ([]float64, error) {
+	return simulate(p.s0, p.drift, p.vol, p.intensity, p.jumpMean, p.jumpVol, n, rng), nil
+}
+
+// simulate draws n daily closes of a jump diffusion, which is a geometric brownian motion without jumps
+func simulate(s0, drift, vol, intensity, jumpMean, jumpVol float64, n int, rng *rand.Rand) []float64 {
+	dt := 1.0 / daysPerYear
+	compensation := intensity * (math.Exp(jumpMean+jumpVol*jumpVol/2) - 1)
+	mu := (drift - compensation - vol*vol/2) * dt
+	sigma := vol * math.Sqrt(dt)
+	jumpProb := intensity * dt
+
+	closes := make([]float64, 0, n)
+	s := s0
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			ret := mu + sigma*rng.NormFloat64()
+			if jumpProb > 0 && rng.Float64() < jumpProb {
+				ret += jumpMean + jumpVol*rng.NormFloat64()
+			}
+			s *= math.Exp(ret)
+		}
+		closes = append(closes, s)
+	}
+	return closes
+}
+
+type replayPath struct {
+	closes []float64
+}
+
+// NewReplayPath replays recorded closes, e.g. of a historical crash, one a trading day
+func NewReplayPath(closes []float64) (Path, error) {
+	if len(closes) == 0 {
+		return nil, errors.New("Expected closes to replay")
+	}
+	for i, c := range closes {
+		if c <= 0 {
+			return nil, errors.Errorf("Expected closes to be positive but got %+v at %d", c, i)
+		}
+	}
+	return &replayPath{closes: closes}, nil
+}
+
+func (p *replayPath) Closes(n int, rng *rand.Rand) ([]float64, error) {
+	if n > len(p.closes) {
+		n = len(p.closes)
+	}
+	return p.closes[:n], nil
+}
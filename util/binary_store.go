@@ -0,0 +1,475 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// BinaryExt is the file extension of the binary option quote store
+const BinaryExt = ".bin"
+
+// binaryMagic identifies a binary option quote file
+var binaryMagic = []byte("BTOQ")
+
+const (
+	// binaryVersion is the version of the binary layout written by BinaryWriter. Files of other versions are not fresh, so they are read from their csv file until converted again
+	binaryVersion = 3
+	// binaryScale is the number of decimal places kept by the fixed-point encoding of prices, sizes and greeks
+	binaryScale = 6
+	// secondsPerDay converts dates to the number of days since the unix epoch
+	secondsPerDay = 24 * 60 * 60
+)
+
+// binaryDecimals returns pointers to the decimal columns of an OHLCV in the order they are stored
+func binaryDecimals(o *model.OHLCV) []*decimal.Decimal {
+	return []*decimal.Decimal{
+		&o.Open, &o.High, &o.Low, &o.Close, &o.Volume,
+		&o.BidSize, &o.Bid, &o.AskSize, &o.Ask, &o.UndBid, &o.UndAsk,
+		&o.Vwap, &o.OpenInterest,
+		&o.BidSize1545, &o.Bid1545, &o.AskSize1545, &o.Ask1545, &o.UndBid1545, &o.UndAsk1545,
+		&o.IV, &o.Delta, &o.Gamma, &o.Theta, &o.Vega, &o.Rho,
+		&o.Multiplier,
+	}
+}
+
+// BinaryWriter writes option quotes in a compact columnar format. Rows are grouped into one block per quote date. Each block holds dictionaries of the strings, expirations and strikes used that day, followed by one column per field. Numbers are stored as fixed-point integers with 6 decimal places, so values with more precision are rounded.
+type BinaryWriter struct {
+	w       *bufio.Writer
+	started bool
+	// srcSize and srcChecksum identify the csv file the rows are converted from
+	srcSize     int64
+	srcChecksum string
+	rows        []model.OHLCV
+	scratch     [binary.MaxVarintLen64]byte
+	buf         bytes.Buffer
+}
+
+// NewBinaryWriter creates a binary writer. Flush must be called after the last row.
+func NewBinaryWriter(w io.Writer) *BinaryWriter {
+	return &BinaryWriter{
+		w:    bufio.NewWriter(w),
+		rows: make([]model.OHLCV, 0),
+	}
+}
+
+// SetSource records the size and checksum of the normalized csv file the rows are converted from in the header, so that FreshBinaryPath can tell whether the csv file changed since. It must be called before the first row is written.
+func (b *BinaryWriter) SetSource(size int64, checksum string) {
+	b.srcSize = size
+	b.srcChecksum = checksum
+}
+
+// Write adds a row. Rows with the same quote date should be written together since a block is written every time the quote date changes.
+func (b *BinaryWriter) Write(o model.OHLCV) error {
+	if len(b.rows) > 0 && !b.rows[0].QuoteDate.Equal(o.QuoteDate) {
+		if err := b.writeBlock(); err != nil {
+			return err
+		}
+	}
+	b.rows = append(b.rows, o)
+	return nil
+}
+
+// Flush writes any buffered rows
+func (b *BinaryWriter) Flush() error {
+	if err := b.writeBlock(); err != nil {
+		return err
+	}
+	if err := b.writeHeader(); err != nil {
+		return err
+	}
+	return errors.Wrap(b.w.Flush(), "Error flushing binary file")
+}
+
+func (b *BinaryWriter) writeHeader() error {
+	if b.started {
+		return nil
+	}
+	b.started = true
+	if _, err := b.w.Write(binaryMagic); err != nil {
+		return errors.Wrap(err, "Error writing binary header")
+	}
+	b.putUvarint(binaryVersion)
+	b.putUvarint(uint64(b.srcSize))
+	b.putUvarint(uint64(len(b.srcChecksum)))
+	b.buf.WriteString(b.srcChecksum)
+	return b.flushBuf()
+}
+
+func (b *BinaryWriter) writeBlock() error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	if err := b.writeHeader(); err != nil {
+		return err
+	}
+
+	strs := newDictionary()
+	exps := newDictionary()
+	strikes := newDictionary()
+	syms := make([]int, len(b.rows))
+	roots := make([]int, len(b.rows))
+	codes := make([]int, len(b.rows))
+	expIdx := make([]int, len(b.rows))
+	strikeIdx := make([]int, len(b.rows))
+	for i, o := range b.rows {
+		syms[i] = strs.add(o.UndSym)
+		roots[i] = strs.add(o.Root)
+		codes[i] = strs.add(o.DeliveryCode)
+		expIdx[i] = exps.add(o.Expiration.Format(model.DateLayout))
+		strikeIdx[i] = strikes.add(o.Strike.String())
+	}
+
+	b.putUvarint(uint64(len(b.rows)))
+	b.putVarint(b.rows[0].QuoteDate.Unix() / secondsPerDay)
+
+	b.putUvarint(uint64(len(strs.values)))
+	for _, s := range strs.values {
+		b.putUvarint(uint64(len(s)))
+		b.buf.WriteString(s)
+	}
+	b.putUvarint(uint64(len(exps.values)))
+	for _, s := range exps.values {
+		exp, err := time.Parse(model.DateLayout, s)
+		if err != nil {
+			return errors.Wrapf(err, "Error encoding expiration %+v", s)
+		}
+		b.putVarint(exp.Unix() / secondsPerDay)
+	}
+	b.putUvarint(uint64(len(strikes.values)))
+	for _, s := range strikes.values {
+		b.putVarint(toFixed(decimal.RequireFromString(s)))
+	}
+
+	for _, col := range [][]int{syms, roots, codes, expIdx, strikeIdx} {
+		for _, idx := range col {
+			b.putUvarint(uint64(idx))
+		}
+	}
+	for _, o := range b.rows {
+		typ := byte(0)
+		if o.Type == model.Put {
+			typ = 1
+		}
+		if o.HasGreeks {
+			typ |= 2
+		}
+		if o.HasIV {
+			typ |= 4
+		}
+		b.buf.WriteByte(typ)
+	}
+	for col := range binaryDecimals(&model.OHLCV{}) {
+		for i := range b.rows {
+			b.putVarint(toFixed(*binaryDecimals(&b.rows[i])[col]))
+		}
+	}
+
+	b.rows = b.rows[:0]
+	return b.flushBuf()
+}
+
+func (b *BinaryWriter) putUvarint(v uint64) {
+	n := binary.PutUvarint(b.scratch[:], v)
+	b.buf.Write(b.scratch[:n])
+}
+
+func (b *BinaryWriter) putVarint(v int64) {
+	n := binary.PutVarint(b.scratch[:], v)
+	b.buf.Write(b.scratch[:n])
+}
+
+func (b *BinaryWriter) flushBuf() error {
+	_, err := b.buf.WriteTo(b.w)
+	return errors.Wrap(err, "Error writing binary block")
+}
+
+// dictionary assigns an index to every distinct value in the order they are added
+type dictionary struct {
+	index  map[string]int
+	values []string
+}
+
+func newDictionary() *dictionary {
+	return &dictionary{
+		index:  make(map[string]int),
+		values: make([]string, 0),
+	}
+}
+
+func (d *dictionary) add(s string) int {
+	if idx, ok := d.index[s]; ok {
+		return idx
+	}
+	d.index[s] = len(d.values)
+	d.values = append(d.values, s)
+	return len(d.values) - 1
+}
+
+// toFixed converts a decimal to a fixed-point integer
+func toFixed(d decimal.Decimal) int64 {
+	return d.Shift(binaryScale).Round(0).IntPart()
+}
+
+// fromFixed converts a fixed-point integer back to a decimal
+func fromFixed(v int64) decimal.Decimal {
+	return decimal.New(v, -binaryScale)
+}
+
+// binaryHeader is the header of a binary file
+type binaryHeader struct {
+	version uint64
+	// size and checksum are of the normalized csv file the binary file was converted from. They are empty if it was written without a source
+	size     int64
+	checksum string
+}
+
+// readBinaryHeader reads the header of a binary file. Only the version is read from files of other versions
+func readBinaryHeader(br *bufio.Reader) (binaryHeader, error) {
+	var h binaryHeader
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return h, errors.Wrap(err, "Error reading binary header")
+	}
+	if !bytes.Equal(magic, binaryMagic) {
+		return h, errors.New("Not a binary option quote file")
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return h, errors.Wrap(err, "Error reading binary version")
+	}
+	h.version = version
+	if version != binaryVersion {
+		return h, nil
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return h, errors.Wrap(err, "Error reading binary source size")
+	}
+	h.size = int64(size)
+	n, err := binary.ReadUvarint(br)
+	if err != nil || n > 64 {
+		return h, errors.Errorf("Expected a binary source checksum of at most 64 bytes but got %d %+v", n, err)
+	}
+	checksum := make([]byte, n)
+	if _, err := io.ReadFull(br, checksum); err != nil {
+		return h, errors.Wrap(err, "Error reading binary source checksum")
+	}
+	h.checksum = string(checksum)
+	return h, nil
+}
+
+// StreamBinaryFile reads a file written by BinaryWriter and calls fn with every row in the order they were written
+func StreamBinaryFile(r io.Reader, fn func(model.OHLCV) error) error {
+	br := bufio.NewReader(r)
+	header, err := readBinaryHeader(br)
+	if err != nil {
+		return err
+	}
+	if header.version != binaryVersion {
+		return errors.Errorf("Unsupported binary version %d", header.version)
+	}
+
+	for block := 1; ; block++ {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Error reading block %d", block)
+		}
+		rows, err := readBinaryBlock(br, int(n))
+		if err != nil {
+			return errors.Wrapf(err, "Error reading block %d", block)
+		}
+		for _, o := range rows {
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readBinaryBlock reads the rows of one quote date block after its row count
+func readBinaryBlock(br *bufio.Reader, n int) ([]model.OHLCV, error) {
+	day, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, err
+	}
+	quoteDate := time.Unix(day*secondsPerDay, 0).UTC()
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, count)
+	for i := range strs {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		s := make([]byte, l)
+		if _, err := io.ReadFull(br, s); err != nil {
+			return nil, err
+		}
+		strs[i] = string(s)
+	}
+	count, err = binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	exps := make([]time.Time, count)
+	for i := range exps {
+		day, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, err
+		}
+		exps[i] = time.Unix(day*secondsPerDay, 0).UTC()
+	}
+	count, err = binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	strikes := make([]decimal.Decimal, count)
+	for i := range strikes {
+		v, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, err
+		}
+		strikes[i] = fromFixed(v)
+	}
+
+	rows := make([]model.OHLCV, n)
+	for i := range rows {
+		rows[i].QuoteDate = quoteDate
+	}
+	lookup := func(size int, set func(o *model.OHLCV, idx int)) error {
+		for i := range rows {
+			idx, err := binary.ReadUvarint(br)
+			if err != nil {
+				return err
+			}
+			if int(idx) >= size {
+				return errors.Errorf("Dictionary index %d out of range", idx)
+			}
+			set(&rows[i], int(idx))
+		}
+		return nil
+	}
+	if err := lookup(len(strs), func(o *model.OHLCV, idx int) { o.UndSym = strs[idx] }); err != nil {
+		return nil, err
+	}
+	if err := lookup(len(strs), func(o *model.OHLCV, idx int) { o.Root = strs[idx] }); err != nil {
+		return nil, err
+	}
+	if err := lookup(len(strs), func(o *model.OHLCV, idx int) { o.DeliveryCode = strs[idx] }); err != nil {
+		return nil, err
+	}
+	if err := lookup(len(exps), func(o *model.OHLCV, idx int) { o.Expiration = exps[idx] }); err != nil {
+		return nil, err
+	}
+	if err := lookup(len(strikes), func(o *model.OHLCV, idx int) { o.Strike = strikes[idx] }); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		typ, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if typ&^7 != 0 {
+			return nil, errors.Errorf("Expected a call or put row type but got %d", typ)
+		}
+		rows[i].Type = model.Call
+		if typ&1 != 0 {
+			rows[i].Type = model.Put
+		}
+		rows[i].HasGreeks = typ&2 != 0
+		rows[i].HasIV = typ&4 != 0
+	}
+	for col := range binaryDecimals(&model.OHLCV{}) {
+		for i := range rows {
+			v, err := binary.ReadVarint(br)
+			if err != nil {
+				return nil, err
+			}
+			*binaryDecimals(&rows[i])[col] = fromFixed(v)
+		}
+	}
+	for i := range rows {
+		rows[i].AskBidMid = rows[i].Ask.Add(rows[i].Bid).Div(decimal.NewFromInt(2))
+	}
+	return rows, nil
+}
+
+// BinaryPath returns the binary file kept next to a normalized csv file
+func BinaryPath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, ".csv") + BinaryExt
+}
+
+// ConvertCSVToBinary writes the binary version of a normalized csv file next to it and returns its path
+func ConvertCSVToBinary(csvPath string) (string, error) {
+	in, err := os.Open(csvPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error opening %s", csvPath)
+	}
+	defer in.Close()
+
+	path := BinaryPath(csvPath)
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error opening %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	checksum, size, err := FileChecksum(csvPath)
+	if err != nil {
+		return "", err
+	}
+	bw := NewBinaryWriter(out)
+	bw.SetSource(size, checksum)
+	r := csv.NewReader(bufio.NewReader(in))
+	if err := NewFileReader().StreamNormalizedCSVFile(r, bw.Write); err != nil {
+		return "", errors.Wrapf(err, "Error reading %s", csvPath)
+	}
+	if err := bw.Flush(); err != nil {
+		return "", errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := out.Close(); err != nil {
+		return "", errors.Wrapf(err, "Error closing %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", errors.Wrapf(err, "Error renaming %s", tmp)
+	}
+	return path, nil
+}
+
+// FreshBinaryPath returns the binary file of a normalized csv file if it exists, has the current binary version and was converted from the current contents of the csv file
+func FreshBinaryPath(csvPath string) (string, bool) {
+	path := BinaryPath(csvPath)
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	header, err := readBinaryHeader(bufio.NewReader(f))
+	if err != nil || header.version != binaryVersion || header.checksum == "" {
+		return path, false
+	}
+	src, err := os.Stat(csvPath)
+	if err != nil || src.Size() != header.size {
+		return path, false
+	}
+	checksum, _, err := FileChecksum(csvPath)
+	return path, err == nil && checksum == header.checksum
+}
@@ -0,0 +1,256 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binary")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		input string
+		imp   Importer
+	}{
+		{"./../testdata/quotes_sample.zip", NewLiveVolImporter(nil)},
+		{"./../testdata/cboe/option_eod_calcs_sample.csv", NewCBOECalcsImporter(nil)},
+		{"./../testdata/jpx/n225_options_sample.csv", NewJPXImporter(nil)},
+	}
+	for idx, test := range tests {
+		csvPath := filepath.Join(dir, filepath.Base(test.input)+".csv")
+		if err := test.imp.ImportFile(test.input, csvPath); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error importing %s", test.input))
+		}
+
+		f, err := os.Open(csvPath)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error opening normalized file"))
+		}
+		expData, err := NewFileReader().ReadNormalizedCSVFile(csv.NewReader(bufio.NewReader(f)))
+		f.Close()
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error reading normalized file"))
+		}
+
+		binPath, err := ConvertCSVToBinary(csvPath)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error converting to binary"))
+		}
+		if _, ok := FreshBinaryPath(csvPath); !ok {
+			t.Errorf("Expected binary file to be fresh at idx: %d", idx)
+		}
+		b, err := os.Open(binPath)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error opening binary file"))
+		}
+		data := make([]model.OHLCV, 0)
+		err = StreamBinaryFile(b, func(o model.OHLCV) error {
+			data = append(data, o)
+			return nil
+		})
+		b.Close()
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error reading binary file"))
+		}
+
+		if len(data) != len(expData) || len(data) == 0 {
+			t.Fatalf("Expected %d rows but got %d at idx: %d", len(expData), len(data), idx)
+		}
+		for i, d := range data {
+			exp := expData[i]
+			if d.UndSym != exp.UndSym || d.Root != exp.Root || d.DeliveryCode != exp.DeliveryCode || d.Type != exp.Type || d.HasGreeks != exp.HasGreeks || d.HasIV != exp.HasIV {
+				t.Errorf("Expected %+v but got %+v at idx: %d row: %d", exp, d, idx, i)
+			}
+			if !d.QuoteDate.Equal(exp.QuoteDate) || !d.Expiration.Equal(exp.Expiration) {
+				t.Errorf("Expected dates %+v %+v but got %+v %+v at idx: %d row: %d", exp.QuoteDate, exp.Expiration, d.QuoteDate, d.Expiration, idx, i)
+			}
+			expDecimals := binaryDecimals(&exp)
+			for col, v := range binaryDecimals(&d) {
+				if !v.Equal(*expDecimals[col]) {
+					t.Errorf("Expected column %d to be %+v but got %+v at idx: %d row: %d", col, *expDecimals[col], *v, idx, i)
+				}
+			}
+			if !d.Strike.Equal(exp.Strike) || !d.AskBidMid.Equal(exp.AskBidMid) {
+				t.Errorf("Expected strike %+v and mid %+v but got %+v and %+v at idx: %d row: %d", exp.Strike, exp.AskBidMid, d.Strike, d.AskBidMid, idx, i)
+			}
+		}
+	}
+}
+
+func TestBinaryInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binary")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bad.bin")
+	if err := ioutil.WriteFile(path, []byte("underlying_symbol,quote_date\n"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing file"))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error opening file"))
+	}
+	defer f.Close()
+	if err := StreamBinaryFile(f, func(model.OHLCV) error { return nil }); err == nil {
+		t.Errorf("Expected an error reading a file that is not binary")
+	}
+}
+
+func TestBinaryFlags(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2016-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2016-07-02")
+	ohlcv, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Put, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
+	tt := []struct {
+		hasGreeks bool
+		hasIV     bool
+	}{
+		{hasGreeks: false, hasIV: false},
+		{hasGreeks: false, hasIV: true},
+		{hasGreeks: true, hasIV: true},
+	}
+	var buf bytes.Buffer
+	bw := NewBinaryWriter(&buf)
+	for _, tab := range tt {
+		o := ohlcv
+		o.HasGreeks, o.HasIV = tab.hasGreeks, tab.hasIV
+		if err := bw.Write(o); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing row"))
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing binary"))
+	}
+	data := make([]model.OHLCV, 0)
+	if err := StreamBinaryFile(&buf, func(o model.OHLCV) error {
+		data = append(data, o)
+		return nil
+	}); err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading binary"))
+	}
+	if len(data) != len(tt) {
+		t.Fatalf("Expected %d rows but got %d", len(tt), len(data))
+	}
+	for idx, tab := range tt {
+		if data[idx].Type != model.Put || data[idx].HasGreeks != tab.hasGreeks || data[idx].HasIV != tab.hasIV {
+			t.Errorf("Expected put with greeks %+v and IV %+v but got %+v %+v %+v at idx: %d", tab.hasGreeks, tab.hasIV, data[idx].Type, data[idx].HasGreeks, data[idx].HasIV, idx)
+		}
+	}
+}
+
+func TestBinaryOldVersionNotFresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binary")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	csvPath := filepath.Join(dir, "data.csv")
+	if err := NewCBOECalcsImporter(nil).ImportFile("./../testdata/cboe/option_eod_calcs_sample.csv", csvPath); err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	binPath, err := ConvertCSVToBinary(csvPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error converting to binary"))
+	}
+	if _, ok := FreshBinaryPath(csvPath); !ok {
+		t.Errorf("Expected binary file to be fresh")
+	}
+	b, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading binary file"))
+	}
+	b[len(binaryMagic)] = binaryVersion - 1
+	if err := ioutil.WriteFile(binPath, b, 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing binary file"))
+	}
+	if _, ok := FreshBinaryPath(csvPath); ok {
+		t.Errorf("Expected binary file of version %d not to be fresh", binaryVersion-1)
+	}
+}
+
+func TestBinaryUnknownType(t *testing.T) {
+	june1, _ := time.Parse(model.DateLayout, "2016-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2016-07-02")
+	write := func(typ model.OptType) []byte {
+		ohlcv, _ := model.NewOHLCV(june1, "SPY", july2, "116", typ, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
+		var buf bytes.Buffer
+		bw := NewBinaryWriter(&buf)
+		if err := bw.Write(ohlcv); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing row"))
+		}
+		if err := bw.Flush(); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing binary"))
+		}
+		return buf.Bytes()
+	}
+	// the files of a call and a put only differ in the type byte
+	b, put := write(model.Call), write(model.Put)
+	if len(b) != len(put) {
+		t.Fatalf("Expected call and put files of the same size but got %d and %d", len(b), len(put))
+	}
+	for i := range b {
+		if b[i] != put[i] {
+			b[i] = 0x80
+		}
+	}
+	if err := StreamBinaryFile(bytes.NewReader(b), func(model.OHLCV) error { return nil }); err == nil {
+		t.Errorf("Expected an error reading an unknown row type")
+	}
+}
+
+func TestBinaryRestoredSourceNotFresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binary")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	csvPath := filepath.Join(dir, "data.csv")
+	if err := NewCBOECalcsImporter(nil).ImportFile("./../testdata/cboe/option_eod_calcs_sample.csv", csvPath); err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	if _, err := ConvertCSVToBinary(csvPath); err != nil {
+		t.Fatal(errors.Wrap(err, "Error converting to binary"))
+	}
+	b, err := ioutil.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading csv file"))
+	}
+
+	tt := []struct {
+		contents []byte
+		fresh    bool
+	}{
+		{contents: b, fresh: true},
+		// the same size with other contents, restored with an older modification time
+		{contents: bytes.Replace(b, []byte("2016-06-17"), []byte("2016-06-24"), 1), fresh: false},
+		{contents: append(append([]byte{}, b...), b[len(b)-10:]...), fresh: false},
+	}
+	for idx, tab := range tt {
+		if err := ioutil.WriteFile(csvPath, tab.contents, 0666); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing csv file"))
+		}
+		old := time.Now().Add(-24 * time.Hour)
+		if err := os.Chtimes(csvPath, old, old); err != nil {
+			t.Fatal(errors.Wrap(err, "Error touching csv file"))
+		}
+		if _, ok := FreshBinaryPath(csvPath); ok != tab.fresh {
+			t.Errorf("Expected binary file to be fresh %+v but got %+v at idx: %d", tab.fresh, ok, idx)
+		}
+	}
+}
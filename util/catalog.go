@@ -0,0 +1,161 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CatalogFileName is the default name of the dataset catalog
+const CatalogFileName = "datasets.json"
+
+// Dataset is a named data directory
+type Dataset struct {
+	// Name selects the dataset
+	Name string `json:"name"`
+	// Path is the data directory of the dataset. A relative path is relative to the catalog
+	Path string `json:"path"`
+	// Description describes what the data is, e.g. SPY end of day quotes from LiveVol
+	Description string `json:"description"`
+}
+
+// Catalog is a list of named datasets
+type Catalog struct {
+	path     string
+	Datasets []Dataset `json:"datasets"`
+}
+
+// LoadCatalog reads a catalog from path. A missing file results in an empty catalog.
+func LoadCatalog(path string) (*Catalog, error) {
+	c := &Catalog{
+		path:     path,
+		Datasets: make([]Dataset, 0),
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading catalog %s", path)
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing catalog %s", path)
+	}
+	return c, nil
+}
+
+// Save writes the catalog back to the path it was loaded from
+func (c *Catalog) Save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error encoding catalog")
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrapf(err, "Error writing catalog %s", tmp)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return errors.Wrapf(err, "Error renaming catalog %s", tmp)
+	}
+	return nil
+}
+
+// Find returns the dataset with the name, or nil if the catalog has none
+func (c *Catalog) Find(name string) *Dataset {
+	for i := range c.Datasets {
+		if c.Datasets[i].Name == name {
+			return &c.Datasets[i]
+		}
+	}
+	return nil
+}
+
+// Put adds the dataset, or replaces the dataset with the same name
+func (c *Catalog) Put(d Dataset) error {
+	if d.Name == "" || d.Path == "" {
+		return errors.Errorf("Expected a dataset name and path but got %+v and %+v", d.Name, d.Path)
+	}
+	if e := c.Find(d.Name); e != nil {
+		*e = d
+		return nil
+	}
+	c.Datasets = append(c.Datasets, d)
+	sort.Slice(c.Datasets, func(i, j int) bool {
+		return c.Datasets[i].Name < c.Datasets[j].Name
+	})
+	return nil
+}
+
+// DataDir returns the data directory of the dataset with the name
+func (c *Catalog) DataDir(name string) (string, error) {
+	d := c.Find(name)
+	if d == nil {
+		return "", errors.Errorf("Unknown dataset %+v in catalog %s", name, c.path)
+	}
+	if filepath.IsAbs(d.Path) {
+		return d.Path, nil
+	}
+	return filepath.Join(filepath.Dir(c.path), d.Path), nil
+}
+
+// Coverage is the range of quote dates of a symbol imported into a data directory
+type Coverage struct {
+	Symbol         string
+	FirstQuoteDate string
+	LastQuoteDate  string
+	// Files is the number of partition files of the symbol
+	Files int
+}
+
+// DatasetCoverage returns the coverage of every symbol in the import manifest of dataDir, ordered by symbol. Sources holding more than one symbol are assumed to cover their whole quote date range within each partition year.
+func DatasetCoverage(dataDir string) ([]Coverage, error) {
+	m, err := LoadManifest(filepath.Join(dataDir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[string]*Coverage)
+	for _, e := range m.Entries {
+		if e.FirstQuoteDate == "" {
+			continue
+		}
+		for _, o := range e.Outputs {
+			parts := strings.Split(filepath.ToSlash(o), "/")
+			if len(parts) != 3 {
+				continue
+			}
+			sym, year := parts[0], parts[1]
+			first, last := e.FirstQuoteDate, e.LastQuoteDate
+			if first < year+"-01-01" {
+				first = year + "-01-01"
+			}
+			if last > year+"-12-31" {
+				last = year + "-12-31"
+			}
+			c, ok := bySymbol[sym]
+			if !ok {
+				c = &Coverage{Symbol: sym, FirstQuoteDate: first, LastQuoteDate: last}
+				bySymbol[sym] = c
+			}
+			if first < c.FirstQuoteDate {
+				c.FirstQuoteDate = first
+			}
+			if last > c.LastQuoteDate {
+				c.LastQuoteDate = last
+			}
+			c.Files++
+		}
+	}
+	coverage := make([]Coverage, 0, len(bySymbol))
+	for _, c := range bySymbol {
+		coverage = append(coverage, *c)
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].Symbol < coverage[j].Symbol
+	})
+	return coverage, nil
+}
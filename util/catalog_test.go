@@ -0,0 +1,118 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, CatalogFileName)
+
+	c, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading missing catalog"))
+	}
+	if len(c.Datasets) != 0 {
+		t.Errorf("Expected an empty catalog but got %+v", c.Datasets)
+	}
+	if err := c.Put(Dataset{Name: "spy-eod", Path: "spy", Description: "SPY end of day"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put(Dataset{Name: "synthetic", Path: "/data/synthetic"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put(Dataset{Name: "spy-eod", Path: "spy-eod", Description: "SPY end of day from LiveVol"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put(Dataset{Name: "no-path"}); err == nil {
+		t.Error("Expected an error for a dataset without a path")
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving catalog"))
+	}
+
+	c, err = LoadCatalog(path)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading catalog"))
+	}
+	if len(c.Datasets) != 2 {
+		t.Fatalf("Expected 2 datasets but got %+v", c.Datasets)
+	}
+	if d := c.Find("spy-eod"); d == nil || d.Description != "SPY end of day from LiveVol" {
+		t.Errorf("Expected the updated spy-eod dataset but got %+v", d)
+	}
+
+	tt := []struct {
+		name     string
+		expected string
+		err      bool
+	}{
+		// relative paths are relative to the catalog
+		{name: "spy-eod", expected: filepath.Join(dir, "spy-eod")},
+		{name: "synthetic", expected: "/data/synthetic"},
+		{name: "unknown", err: true},
+	}
+	for idx, tab := range tt {
+		dataDir, err := c.DataDir(tab.name)
+		if (err != nil) != tab.err {
+			t.Errorf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+		if dataDir != tab.expected {
+			t.Errorf("Expected %+v but got %+v at idx: %d", tab.expected, dataDir, idx)
+		}
+	}
+}
+
+func TestDatasetCoverage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coverage")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := LoadManifest(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading manifest"))
+	}
+	m.put(ManifestEntry{
+		Source:         "spy_2015.csv",
+		FirstQuoteDate: "2015-06-01",
+		LastQuoteDate:  "2016-03-31",
+		Outputs:        []string{"SPY/2015/spy_2015.csv", "SPY/2016/spy_2015.csv"},
+	})
+	m.put(ManifestEntry{
+		Source:         "mixed.csv",
+		FirstQuoteDate: "2016-04-01",
+		LastQuoteDate:  "2016-06-30",
+		Outputs:        []string{"SPY/2016/mixed.csv", "^VIX/2016/mixed.csv"},
+	})
+	if err := m.Save(); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving manifest"))
+	}
+
+	coverage, err := DatasetCoverage(dir)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading coverage"))
+	}
+	expected := []Coverage{
+		{Symbol: "SPY", FirstQuoteDate: "2015-06-01", LastQuoteDate: "2016-06-30", Files: 3},
+		{Symbol: "^VIX", FirstQuoteDate: "2016-04-01", LastQuoteDate: "2016-06-30", Files: 1},
+	}
+	if len(coverage) != len(expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, coverage)
+	}
+	for idx := range expected {
+		if coverage[idx] != expected[idx] {
+			t.Errorf("Expected %+v but got %+v at idx: %d", expected[idx], coverage[idx], idx)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChainCacheDirName is the directory in the data directory holding cached option chains
+const ChainCacheDirName = ".cache"
+
+// chainCacheVersion changes whenever the cached chain layout changes, so old cache files are not read
+const chainCacheVersion = 2
+
+// ChainCacheKey identifies an option chain built from the files of dataDir. It changes whenever the contents of any file change, a file is added or removed, or params change. The contents of a file are identified by the checksums the manifest m recorded for the sources imported into it, and files not in the manifest are hashed. Sizes and modification times are part of the key too, so files changed without importing them are noticed. Params hold anything else that changes what is built, such as a symbol filter. The key starts with a hash of the params, so that caches of older data for the same params can be found.
+func ChainCacheKey(m *Manifest, dataDir string, files []string, params ...string) (string, error) {
+	p := sha256.New()
+	fmt.Fprintf(p, "version:%d\n", chainCacheVersion)
+	for _, param := range params {
+		fmt.Fprintf(p, "param:%s\n", param)
+	}
+	sources := make(map[string][]string)
+	if m != nil {
+		for _, e := range m.Entries {
+			for _, o := range e.Outputs {
+				o = filepath.ToSlash(o)
+				sources[o] = append(sources[o], e.Checksum)
+			}
+		}
+	}
+	h := sha256.New()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "Error reading %s", file)
+		}
+		checksum := ""
+		if rel, err := filepath.Rel(dataDir, file); err == nil {
+			checksum = strings.Join(sources[filepath.ToSlash(rel)], ",")
+		}
+		if checksum == "" {
+			if checksum, _, err = FileChecksum(file); err != nil {
+				return "", errors.Wrapf(err, "Error computing checksum for %s", file)
+			}
+		}
+		fmt.Fprintf(h, "file:%s:%d:%d:%s\n", filepath.ToSlash(file), info.Size(), info.ModTime().UnixNano(), checksum)
+	}
+	return hex.EncodeToString(p.Sum(nil))[:16] + "-" + hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// chainCachePath returns the cache file for a key
+func chainCachePath(dataDir, key string) string {
+	return filepath.Join(dataDir, ChainCacheDirName, "chain-"+key+".gob")
+}
+
+// removeStaleChainCaches removes the caches of the same params as key that were built from other data
+func removeStaleChainCaches(dataDir, key string) error {
+	params := strings.SplitN(key, "-", 2)[0]
+	stale, err := filepath.Glob(filepath.Join(dataDir, ChainCacheDirName, "chain-"+params+"-*.gob"))
+	if err != nil {
+		return errors.Wrapf(err, "Error listing caches of %s", dataDir)
+	}
+	current := chainCachePath(dataDir, key)
+	for _, path := range stale {
+		if path == current {
+			continue
+		}
+		if err := removeIfExists(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadChainCache reads the cached option chain for key. It returns false if there is no cache for key.
+func LoadChainCache(dataDir, key string) (*model.OptChainList, bool, error) {
+	path := chainCachePath(dataDir, key)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error opening %s", path)
+	}
+	defer f.Close()
+	chain, err := model.DecodeOptionChain(bufio.NewReader(f))
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	return chain, true, nil
+}
+
+// SaveChainCache writes the option chain to the cache for key, and removes the caches of the same params built from older data
+func SaveChainCache(dataDir, key string, chain *model.OptChainList) error {
+	path := chainCachePath(dataDir, key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "Error making dir %s", filepath.Dir(path))
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := chain.Encode(w); err != nil {
+		return errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "Error closing %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrapf(err, "Error renaming %s", tmp)
+	}
+	return removeStaleChainCaches(dataDir, key)
+}
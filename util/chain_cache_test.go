@@ -0,0 +1,145 @@
+package util
+
+import (
+	"backtest-options/model"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestChainCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "data.csv")
+	if err := ioutil.WriteFile(file, []byte("a"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing file"))
+	}
+	key, err := ChainCacheKey(nil, dir, []string{file}, "symbol:SPY")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error computing key"))
+	}
+
+	if _, ok, err := LoadChainCache(dir, key); ok || err != nil {
+		t.Errorf("Expected no cache but got %+v %+v", ok, err)
+	}
+
+	june1, _ := time.Parse(model.DateLayout, "2016-06-01")
+	july2, _ := time.Parse(model.DateLayout, "2016-07-02")
+	ohlcv, _ := model.NewOHLCV(june1, "SPY", july2, "116", model.Call, "1", "1", "1", "1", "623", "1", "1", "115.5", "116.5")
+	chain, _ := model.NewOptionChain([]model.OHLCV{ohlcv})
+	if err := SaveChainCache(dir, key, chain); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving cache"))
+	}
+	cached, ok, err := LoadChainCache(dir, key)
+	if err != nil || !ok {
+		t.Fatalf("Expected cache but got %+v %+v", ok, err)
+	}
+	if oc := cached.GetOptionChainForQuoteDate(june1, true); oc == nil {
+		t.Errorf("Expected cached chain to have quote date %+v", june1)
+	}
+
+	// the key changes with params and the contents, size and modification time of files
+	other, _ := ChainCacheKey(nil, dir, []string{file}, "symbol:QQQ")
+	if other == key {
+		t.Errorf("Expected key to change with params")
+	}
+	if err := ioutil.WriteFile(file, []byte("bb"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing file"))
+	}
+	changed, _ := ChainCacheKey(nil, dir, []string{file}, "symbol:SPY")
+	if changed == key {
+		t.Errorf("Expected key to change with file size")
+	}
+	if _, ok, _ := LoadChainCache(dir, changed); ok {
+		t.Errorf("Expected no cache for changed data")
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(errors.Wrap(err, "Error touching file"))
+	}
+	touched, _ := ChainCacheKey(nil, dir, []string{file}, "symbol:SPY")
+	if touched == changed {
+		t.Errorf("Expected key to change with file modification time")
+	}
+
+	// saving the chain of the changed data replaces the cache of the old data, but not of other params
+	if err := SaveChainCache(dir, other, chain); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving cache"))
+	}
+	if err := SaveChainCache(dir, touched, chain); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving cache"))
+	}
+	tt := []struct {
+		key      string
+		expected bool
+	}{
+		{key: key, expected: false},
+		{key: other, expected: true},
+		{key: touched, expected: true},
+	}
+	for idx, tab := range tt {
+		if _, ok, _ := LoadChainCache(dir, tab.key); ok != tab.expected {
+			t.Errorf("Expected cache %+v but got %+v at idx: %d", tab.expected, ok, idx)
+		}
+	}
+}
+
+func TestChainCacheKeyContents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "SPY", "2016", "data.csv")
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		t.Fatal(errors.Wrap(err, "Error making dir"))
+	}
+	mtime := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+	// rewrite the file with the same size and modification time, as a restore from a backup does
+	rewrite := func(contents string) {
+		if err := ioutil.WriteFile(file, []byte(contents), 0666); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing file"))
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(errors.Wrap(err, "Error touching file"))
+		}
+	}
+	m := &Manifest{Entries: []ManifestEntry{{Source: "a.csv", Checksum: "aaa", Outputs: []string{"SPY/2016/data.csv"}}}}
+
+	tt := []struct {
+		manifest *Manifest
+		checksum string
+	}{
+		// without a manifest the contents are hashed
+		{manifest: nil},
+		// with a manifest the checksum of the source imported into the file is used
+		{manifest: m, checksum: "bbb"},
+	}
+	for idx, tab := range tt {
+		rewrite("a")
+		key, err := ChainCacheKey(tab.manifest, dir, []string{file})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error computing key"))
+		}
+		rewrite("b")
+		if tab.manifest != nil {
+			tab.manifest.Entries[0].Checksum = tab.checksum
+		}
+		changed, err := ChainCacheKey(tab.manifest, dir, []string{file})
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error computing key"))
+		}
+		if changed == key {
+			t.Errorf("Expected key to change with the contents at idx: %d", idx)
+		}
+	}
+}
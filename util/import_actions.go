@@ -0,0 +1,201 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// CorporateActionsFileName is the name of the dividend and split calendar kept in a symbol's data directory
+const CorporateActionsFileName = "actions.csv"
+
+var corporateActionsHeader = []string{"date", "type", "value"}
+
+// CorporateActionsPath returns the normalized dividend and split calendar file of the symbol
+func CorporateActionsPath(dataDir, symbol string) string {
+	return filepath.Join(dataDir, partitionSymbol(symbol), CorporateActionsFileName)
+}
+
+// ReadCorporateActionsCSV reads dividends and splits from a csv with a header. It reads the normalized date, type and value columns, as well as date and dividends, or date and stock splits columns such as those exported by Yahoo Finance. Splits are written as a ratio (2:1 or 2/1) or as the number of new shares per old share. Rows with a zero value are skipped.
+func ReadCorporateActionsCSV(r *csv.Reader) ([]model.CorporateAction, error) {
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading corporate actions header")
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(trimBOM(name)))] = i
+	}
+	column := func(names ...string) (int, bool) {
+		for _, name := range names {
+			if idx, ok := cols[name]; ok {
+				return idx, true
+			}
+		}
+		return -1, false
+	}
+	dateCol, ok := column("date", "ex_date", "ex-date")
+	if !ok {
+		return nil, errors.Errorf("Expected a date column in corporate actions header %+v", header)
+	}
+	typ := model.CorporateActionType("")
+	typeCol, hasType := column("type")
+	valueCol, ok := column("value")
+	if !hasType || !ok {
+		if valueCol, ok = column("dividends", "dividend"); ok {
+			typ = model.ActionDividend
+		} else if valueCol, ok = column("stock splits", "split", "splits"); ok {
+			typ = model.ActionSplit
+		} else {
+			return nil, errors.Errorf("Expected type and value, dividends or stock splits columns in corporate actions header %+v", header)
+		}
+	}
+
+	actions := make([]model.CorporateAction, 0)
+	for row := 2; ; row++ {
+		field, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading corporate actions row %d", row)
+		}
+		if dateCol >= len(field) || valueCol >= len(field) {
+			return nil, errors.Errorf("Expected %d columns at corporate actions row %d", len(header), row)
+		}
+		d, err := parseUnderlyingDate(strings.TrimSpace(field[dateCol]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing corporate actions row %d", row)
+		}
+		rowType := typ
+		if typ == "" {
+			rowType = model.CorporateActionType(strings.ToLower(strings.TrimSpace(field[typeCol])))
+		}
+		s := strings.TrimSpace(field[valueCol])
+		var value decimal.Decimal
+		if rowType == model.ActionSplit {
+			value, err = parseSplitRatio(s)
+		} else {
+			value, err = decimalOrZero(s)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing value %+v at corporate actions row %d", s, row)
+		}
+		if value.IsZero() {
+			continue
+		}
+		actions = append(actions, model.CorporateAction{
+			ExDate: d,
+			Type:   rowType,
+			Value:  value,
+		})
+	}
+	return actions, nil
+}
+
+// parseSplitRatio parses 2:1, 2/1 or 2 into the number of new shares per old share
+func parseSplitRatio(s string) (decimal.Decimal, error) {
+	for _, sep := range []string{":", "/"} {
+		parts := strings.Split(s, sep)
+		if len(parts) != 2 {
+			continue
+		}
+		n, err := decimal.NewFromString(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		d, err := decimal.NewFromString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		if d.IsZero() {
+			return decimal.Decimal{}, errors.Errorf("Invalid split ratio %+v", s)
+		}
+		return n.Div(d), nil
+	}
+	return decimalOrZero(s)
+}
+
+// ImportCorporateActions merges the dividends and splits of a csv file into the symbol's calendar in dataDir. Actions with the same ex-date and type are replaced. It returns the number of actions read from source.
+func ImportCorporateActions(source, dataDir, symbol string) (int, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %s", source)
+	}
+	defer f.Close()
+	actions, err := ReadCorporateActionsCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error reading %s", source)
+	}
+
+	existing, ok, err := LoadCorporateActions(dataDir, symbol)
+	if err != nil {
+		return 0, err
+	}
+	merged := actions
+	if ok {
+		merged = append(append([]model.CorporateAction{}, existing.Actions()...), actions...)
+	}
+	calendar, err := model.NewCorporateActions(symbol, merged)
+	if err != nil {
+		return 0, err
+	}
+
+	path := CorporateActionsPath(dataDir, symbol)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return 0, errors.Wrapf(err, "Error making dir %s", filepath.Dir(path))
+	}
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write(corporateActionsHeader)
+	for _, a := range calendar.Actions() {
+		w.Write([]string{a.ExDate.Format(model.DateLayout), string(a.Type), a.Value.String()})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := out.Close(); err != nil {
+		return 0, errors.Wrapf(err, "Error closing %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, errors.Wrapf(err, "Error renaming %s", tmp)
+	}
+	return len(actions), nil
+}
+
+// LoadCorporateActions reads the symbol's dividend and split calendar from dataDir. It returns false if none was imported.
+func LoadCorporateActions(dataDir, symbol string) (*model.CorporateActions, bool, error) {
+	path := CorporateActionsPath(dataDir, symbol)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error opening %s", path)
+	}
+	defer f.Close()
+	actions, err := ReadCorporateActionsCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	calendar, err := model.NewCorporateActions(symbol, actions)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	return calendar, true, nil
+}
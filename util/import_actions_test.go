@@ -0,0 +1,62 @@
+package util
+
+import (
+	"backtest-options/model"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestImportCorporateActions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "actions")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"dividends.csv": "Date,Dividends\n2020-08-07,0.82\n2020-11-06,0.205\n",
+		"splits.csv":    "Date,Stock Splits\n2020-08-31,4:1\n2014-06-09,7/1\n",
+		"custom.csv":    "date,type,value\n2020-11-06,dividend,0.21\n",
+	}
+	dataDir := filepath.Join(dir, "data")
+	for _, name := range []string{"dividends.csv", "splits.csv", "custom.csv"} {
+		source := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(source, []byte(sources[name]), 0666); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing source"))
+		}
+		if _, err := ImportCorporateActions(source, dataDir, "AAPL"); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error importing %s", name))
+		}
+	}
+
+	c, ok, err := LoadCorporateActions(dataDir, "AAPL")
+	if err != nil || !ok {
+		t.Fatalf("Expected corporate actions but got %+v %+v", ok, err)
+	}
+	expected := []struct {
+		date  string
+		typ   model.CorporateActionType
+		value string
+	}{
+		{"2014-06-09", model.ActionSplit, "7"},
+		{"2020-08-07", model.ActionDividend, "0.82"},
+		{"2020-08-31", model.ActionSplit, "4"},
+		// the later import replaces the dividend of the same ex-date
+		{"2020-11-06", model.ActionDividend, "0.21"},
+	}
+	actions := c.Actions()
+	if len(actions) != len(expected) {
+		t.Fatalf("Expected %d actions but got %+v", len(expected), actions)
+	}
+	for idx, exp := range expected {
+		a := actions[idx]
+		if a.ExDate.Format(model.DateLayout) != exp.date || a.Type != exp.typ || !a.Value.Equal(decimal.RequireFromString(exp.value)) {
+			t.Errorf("Expected %+v but got %+v at idx: %d", exp, a, idx)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"encoding/csv"
+
+	"github.com/pkg/errors"
+)
+
+// cboeCalcsColumns are the CBOE DataShop column names that differ from the normalized column names. DataShop calculates implied volatility and greeks at 15:45, so they are only used at model.Snapshot1545
+var cboeCalcsColumns = map[int]string{
+	csvStdIV:    "implied_volatility_1545",
+	csvStdDelta: "delta_1545",
+	csvStdGamma: "gamma_1545",
+	csvStdTheta: "theta_1545",
+	csvStdVega:  "vega_1545",
+	csvStdRho:   "rho_1545",
+}
+
+// cboeCalcsMapper maps CBOE DataShop option EOD summary rows. Columns are resolved by name from the header, so both the summary and the summary with calcs layouts can be imported.
+type cboeCalcsMapper struct {
+	cols     csvColumns
+	required int
+}
+
+// NewCBOECalcsImporter is an importer for CBOE DataShop option EOD summary files, including implied volatility and greeks when present
+func NewCBOECalcsImporter(w *csv.Writer) Importer {
+	return &csvImporter{
+		writer: w,
+		mapper: &cboeCalcsMapper{},
+	}
+}
+
+func (m *cboeCalcsMapper) setHeader(header []string) error {
+	byName := make(map[string]int)
+	for i, name := range header {
+		byName[trimBOM(name)] = i
+	}
+	m.cols = make(csvColumns, len(normalizedHeader))
+	for i, name := range normalizedHeader {
+		if vendor, ok := cboeCalcsColumns[i]; ok {
+			name = vendor
+		}
+		idx, ok := byName[name]
+		if !ok {
+			idx = -1
+		}
+		m.cols[i] = idx
+	}
+	for col := csvStdUndSym; col <= csvStdUndAsk; col++ {
+		if m.cols[col] < 0 {
+			return errors.Errorf("Expected column %+v in CBOE header", normalizedHeader[col])
+		}
+	}
+	m.required = m.cols.required()
+	return nil
+}
+
+func (m *cboeCalcsMapper) mapRow(field []string) ([]string, bool) {
+	if len(field) < m.required {
+		return nil, false
+	}
+	values := make([]string, len(normalizedHeader))
+	for i := range values {
+		values[i] = m.cols.get(field, i)
+	}
+	return values, true
+}
@@ -1,16 +1,13 @@
 package util
 
 import (
-	"archive/zip"
 	"encoding/csv"
-	"io/ioutil"
-
-	"github.com/pkg/errors"
 )
 
 const (
 	csvLivevolUndSym       = 0
 	csvLivevolQuoteDate    = 1
+	csvLivevolRoot         = 2
 	csvLivevolExp          = 3
 	csvLivevolStrike       = 4
 	csvLivevolOptType      = 5
@@ -19,6 +16,12 @@ const (
 	csvLivevolLow          = 8
 	csvLivevolClose        = 9
 	csvLivevolVol          = 10
+	csvLivevolBidSize1545  = 11
+	csvLivevolBid1545      = 12
+	csvLivevolAskSize1545  = 13
+	csvLivevolAsk1545      = 14
+	csvLivevolUndBid1545   = 15
+	csvLivevolUndAsk1545   = 16
 	csvLivevolBidSize      = 17
 	csvLivevolBid          = 18
 	csvLivevolAskSize      = 19
@@ -30,103 +33,88 @@ const (
 	csvLivevolDelivCode    = 25
 )
 
-type liveVolImporter struct {
-	writer *csv.Writer
+var normalizedHeader = []string{
+	"underlying_symbol",
+	"quote_date",
+	"expiration",
+	"strike",
+	"option_type",
+	"open",
+	"high",
+	"low",
+	"close",
+	"trade_volume",
+	"bid_size_eod",
+	"bid_eod",
+	"ask_size_eod",
+	"ask_eod",
+	"underlying_bid_eod",
+	"underlying_ask_eod",
+	"vwap",
+	"open_interest",
+	"delivery_code",
+	"root",
+	"bid_size_1545",
+	"bid_1545",
+	"ask_size_1545",
+	"ask_1545",
+	"underlying_bid_1545",
+	"underlying_ask_1545",
+	"implied_volatility",
+	"delta",
+	"gamma",
+	"theta",
+	"vega",
+	"rho",
+	"multiplier",
 }
 
+// liveVolMapper maps LiveVol option EOD quote rows, which have fixed column positions
+type liveVolMapper struct{}
+
 // NewLiveVolImporter is a live vol importer
 func NewLiveVolImporter(w *csv.Writer) Importer {
-	return &liveVolImporter{
+	return &csvImporter{
 		writer: w,
+		mapper: &liveVolMapper{},
 	}
 }
 
-// ImportFolder imports a folder and outputs to specified data directory
-func (livevol *liveVolImporter) ImportFolder(folder string) error {
-	files, err := ioutil.ReadDir(folder)
-	if err != nil {
-		return errors.Wrap(err, "Error importing folder")
-	}
-
-	header := []string{
-		"underlying_symbol",
-		"quote_date",
-		"expiration",
-		"strike",
-		"option_type",
-		"open",
-		"high",
-		"low",
-		"close",
-		"trade_volume",
-		"bid_size_eod",
-		"bid_eod",
-		"ask_size_eod",
-		"ask_eod",
-		"underlying_bid_eod",
-		"underlying_ask_eod",
-		"vwap",
-		"open_interest",
-		"delivery_code",
-	}
-	livevol.writer.Write(header)
-
-	for _, file := range files {
-		f, err := zip.OpenReader(folder + "/" + file.Name())
-		if err != nil {
-			return errors.Wrapf(err, "Error opening file %s", folder+"/"+file.Name())
-		}
-		defer f.Close()
-
-		for _, file := range f.File {
-			fopen, err := file.Open()
-			if err != nil {
-				return errors.Wrapf(err, "Error opening file %+v", file.Name)
-			}
-
-			reader := csv.NewReader(fopen)
-			fields, err := reader.ReadAll()
-			if err != nil {
-				return errors.Wrapf(err, "Error reading all %+v", file.Name)
-			}
-
-			for row, field := range fields {
-				if row == 0 {
-					continue
-				}
-				if len(field) < csvLivevolDelivCode {
-					continue
-				}
-
-				values := []string{
-					field[csvLivevolUndSym],
-					field[csvLivevolQuoteDate],
-					field[csvLivevolExp],
-					field[csvLivevolStrike],
-					field[csvLivevolOptType],
-					field[csvLivevolOpen],
-					field[csvLivevolHigh],
-					field[csvLivevolLow],
-					field[csvLivevolClose],
-					field[csvLivevolVol],
-					field[csvLivevolBidSize],
-					field[csvLivevolBid],
-					field[csvLivevolAskSize],
-					field[csvLivevolAsk],
-					field[csvLivevolUndBid],
-					field[csvLivevolUndAsk],
-					field[csvLivevolVwap],
-					field[csvLivevolOpenInterest],
-					field[csvLivevolDelivCode],
-				}
-				livevol.writer.Write(values)
-			}
-		}
-	}
+func (m *liveVolMapper) setHeader(header []string) error {
 	return nil
 }
 
-// ImportFile imports a file and outputs to specified data directory
-func (livevol *liveVolImporter) ImportFile(file string, output string) error {
-	return nil
+func (m *liveVolMapper) mapRow(field []string) ([]string, bool) {
+	if len(field) < csvLivevolDelivCode+1 {
+		return nil, false
+	}
+
+	values := make([]string, len(normalizedHeader))
+	values[csvStdUndSym] = field[csvLivevolUndSym]
+	values[csvStdQuoteDate] = field[csvLivevolQuoteDate]
+	values[csvStdExp] = field[csvLivevolExp]
+	values[csvStdStrike] = field[csvLivevolStrike]
+	values[csvStdOptType] = field[csvLivevolOptType]
+	values[csvStdOpen] = field[csvLivevolOpen]
+	values[csvStdHigh] = field[csvLivevolHigh]
+	values[csvStdLow] = field[csvLivevolLow]
+	values[csvStdClose] = field[csvLivevolClose]
+	values[csvStdVol] = field[csvLivevolVol]
+	values[csvStdBidSize] = field[csvLivevolBidSize]
+	values[csvStdBid] = field[csvLivevolBid]
+	values[csvStdAskSize] = field[csvLivevolAskSize]
+	values[csvStdAsk] = field[csvLivevolAsk]
+	values[csvStdUndBid] = field[csvLivevolUndBid]
+	values[csvStdUndAsk] = field[csvLivevolUndAsk]
+	values[csvStdVwap] = field[csvLivevolVwap]
+	values[csvStdOpenInterest] = field[csvLivevolOpenInterest]
+	values[csvStdDelivCode] = field[csvLivevolDelivCode]
+	values[csvStdRoot] = field[csvLivevolRoot]
+	values[csvStdBidSize1545] = field[csvLivevolBidSize1545]
+	values[csvStdBid1545] = field[csvLivevolBid1545]
+	values[csvStdAskSize1545] = field[csvLivevolAskSize1545]
+	values[csvStdAsk1545] = field[csvLivevolAsk1545]
+	values[csvStdUndBid1545] = field[csvLivevolUndBid1545]
+	values[csvStdUndAsk1545] = field[csvLivevolUndAsk1545]
+	return values, true
 }
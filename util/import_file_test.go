@@ -1,10 +1,15 @@
 package util
 
 import (
+	"backtest-options/model"
 	"bytes"
+	"compress/gzip"
 	"encoding/csv"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -25,12 +30,12 @@ func TestImportFile(t *testing.T) {
 	}
 
 	expectedRows := []string{
-		"underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code",
-		"^VIX,2016-06-03,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,10,0.25,271,0.3,14.2,14.2,0.2455,7302,",
-		"^VIX,2016-06-01,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,10,0.25,271,0.3,14.2,14.2,0.2455,7302,",
-		"^VIX,2016-06-01,2016-06-08,14.5,C,1,1.1,0.65,0.65,55,3499,0.55,4249,0.75,14.2,14.2,0.7764,303,",
-		"^VIX,2016-06-01,2016-06-08,14.5,P,0.3,0.55,0.3,0.55,67,4331,0.4,2690,0.6,14.2,14.2,0.4485,152,",
-		"^VIX,2016-06-01,2016-06-08,15,C,0.9,0.95,0.5,0.5,82,6722,0.35,3932,0.55,14.2,14.2,0.6689,1208,",
+		"underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code,root,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,implied_volatility,delta,gamma,theta,vega,rho,multiplier",
+		"^VIX,2016-06-03,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,10,0.25,271,0.3,14.2,14.2,0.2455,7302,,VIX,2845,0.2,1,0.35,14.24,14.24,,,,,,,",
+		"^VIX,2016-06-01,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,10,0.25,271,0.3,14.2,14.2,0.2455,7302,,VIX,2845,0.2,1,0.35,14.24,14.24,,,,,,,",
+		"^VIX,2016-06-01,2016-06-08,14.5,C,1,1.1,0.65,0.65,55,3499,0.55,4249,0.75,14.2,14.2,0.7764,303,,VIX,265,0.55,4233,0.7,14.24,14.24,,,,,,,",
+		"^VIX,2016-06-01,2016-06-08,14.5,P,0.3,0.55,0.3,0.55,67,4331,0.4,2690,0.6,14.2,14.2,0.4485,152,,VIX,1939,0.45,1893,0.65,14.24,14.24,,,,,,,",
+		"^VIX,2016-06-01,2016-06-08,15,C,0.9,0.95,0.5,0.5,82,6722,0.35,3932,0.55,14.2,14.2,0.6689,1208,,VIX,3698,0.35,221,0.5,14.24,14.24,,,,,,,",
 		"",
 	}
 	expected := strings.Join(expectedRows, "\n")
@@ -43,3 +48,268 @@ func TestImportFile(t *testing.T) {
 			len(expected))
 	}
 }
+
+func TestImportFileFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	raw := "underlying_symbol,quote_date,root,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code\n" +
+		"^VIX,2016-06-01,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n" +
+		"^VIX,2016-06-01,VIX,2016-06-08\n"
+	if err := ioutil.WriteFile(dir+"/plain.csv", []byte(raw), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing csv"))
+	}
+	var gzbuf bytes.Buffer
+	gz := gzip.NewWriter(&gzbuf)
+	gz.Write([]byte(raw))
+	gz.Close()
+	if err := ioutil.WriteFile(dir+"/plain.csv.gz", gzbuf.Bytes(), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing csv.gz"))
+	}
+
+	expected := strings.Join([]string{
+		"underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code,root,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,implied_volatility,delta,gamma,theta,vega,rho,multiplier",
+		"^VIX,2016-06-01,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,10,0.25,271,0.3,14.2,14.2,0.2455,7302,,VIX,2845,0.2,1,0.35,14.24,14.24,,,,,,,",
+		"",
+	}, "\n")
+
+	tt := []struct {
+		input   string
+		rows    int
+		skipped int
+	}{
+		{input: dir + "/plain.csv", rows: 1, skipped: 1},
+		{input: dir + "/plain.csv.gz", rows: 1, skipped: 1},
+		{input: "./../testdata/quote_sample2.zip", rows: 1, skipped: 0},
+	}
+
+	for idx, tab := range tt {
+		output := dir + "/out.csv"
+		imp := NewLiveVolImporter(nil)
+		if err := imp.ImportFile(tab.input, output); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error importing file at idx: %d", idx))
+		}
+		stats := imp.Stats()
+		if stats.Rows != tab.rows {
+			t.Errorf("Expected %d rows but got %d at idx: %d", tab.rows, stats.Rows, idx)
+		}
+		if stats.Skipped != tab.skipped {
+			t.Errorf("Expected %d skipped rows but got %d at idx: %d", tab.skipped, stats.Skipped, idx)
+		}
+		b, err := ioutil.ReadFile(output)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error reading output"))
+		}
+		data := string(b)
+		if idx < 2 && data != expected {
+			t.Errorf("Expected output \n%+v but got \n%+v at idx: %d", expected, data, idx)
+		}
+	}
+}
+
+func TestImportCBOECalcs(t *testing.T) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+
+	imp, err := NewImporter("cboe", w)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating importer"))
+	}
+	if err := imp.ImportFile("./../testdata/cboe/option_eod_calcs_sample.csv", ""); err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing cboe file"))
+	}
+	w.Flush()
+
+	stats := imp.Stats()
+	if stats.Rows != 2 || stats.Skipped != 1 {
+		t.Errorf("Expected 2 rows and 1 skipped row but got %+v", stats)
+	}
+
+	data, err := NewFileReader().ReadNormalizedCSVFile(csv.NewReader(&b))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading normalized file"))
+	}
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 rows but got %d", len(data))
+	}
+	call := data[0]
+	if !call.HasGreeks {
+		t.Error("Expected the call to have greeks")
+	}
+	if call.IV.String() != "0.1102" || call.Delta.String() != "0.5183" || call.Gamma.String() != "0.0934" {
+		t.Errorf("Expected iv, delta and gamma to be 0.1102, 0.5183, 0.0934 but got %+v, %+v, %+v", call.IV, call.Delta, call.Gamma)
+	}
+	if call.Theta.String() != "-0.0712" || call.Vega.String() != "0.1478" || call.Rho.String() != "0.0461" {
+		t.Errorf("Expected theta, vega and rho to be -0.0712, 0.1478, 0.0461 but got %+v, %+v, %+v", call.Theta, call.Vega, call.Rho)
+	}
+	if call.Bid.String() != "1.61" || call.Bid1545.String() != "1.6" {
+		t.Errorf("Expected eod bid 1.61 and 15:45 bid 1.6 but got %+v and %+v", call.Bid, call.Bid1545)
+	}
+	if call.OpenInterest.String() != "84213" || call.Root != "SPY" {
+		t.Errorf("Expected open interest 84213 and root SPY but got %+v and %+v", call.OpenInterest, call.Root)
+	}
+	if data[1].Delta.String() != "-0.4821" {
+		t.Errorf("Expected put delta to be -0.4821 but got %+v", data[1].Delta)
+	}
+
+	if _, err := NewImporter("unknown", w); err == nil {
+		t.Error("Expected an error for an unsupported vendor")
+	}
+}
+
+func TestImportJPX(t *testing.T) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+
+	imp, err := NewImporter("jpx", w)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating importer"))
+	}
+	if err := imp.ImportFile("./../testdata/jpx/n225_options_sample.csv", ""); err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing jpx file"))
+	}
+	w.Flush()
+
+	stats := imp.Stats()
+	// the emergency margin settlement and the row without put or call are skipped
+	if stats.Rows != 3 || stats.Skipped != 2 {
+		t.Errorf("Expected 3 rows and 2 skipped rows but got %+v", stats)
+	}
+
+	data, err := NewFileReader().ReadNormalizedCSVFile(csv.NewReader(&b))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading normalized file"))
+	}
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 rows but got %d", len(data))
+	}
+
+	tt := []struct {
+		typ    model.OptType
+		exp    string
+		strike string
+		bid    string
+		ask    string
+		vol    string
+	}{
+		{typ: model.Call, exp: "2021-06-11", strike: "29000", bid: "405", ask: "405", vol: "1250"},
+		{typ: model.Put, exp: "2021-06-11", strike: "28500", bid: "160", ask: "160", vol: "2310"},
+		// no SQ date, so it is the SQ date of the contract month
+		{typ: model.Put, exp: "2021-07-09", strike: "27000", bid: "210", ask: "210", vol: "0"},
+	}
+	for idx, tab := range tt {
+		d := data[idx]
+		if d.UndSym != "N225" {
+			t.Errorf("Expected underlying symbol to be N225 but got %+v at idx: %d", d.UndSym, idx)
+		}
+		if d.QuoteDate.Format(model.DateLayout) != "2021-06-01" {
+			t.Errorf("Expected quote date to be 2021-06-01 but got %+v at idx: %d", d.QuoteDate, idx)
+		}
+		if d.Type != tab.typ {
+			t.Errorf("Expected type to be %+v but got %+v at idx: %d", tab.typ, d.Type, idx)
+		}
+		if d.Expiration.Format(model.DateLayout) != tab.exp {
+			t.Errorf("Expected expiration to be %+v but got %+v at idx: %d", tab.exp, d.Expiration, idx)
+		}
+		if d.Strike.String() != tab.strike {
+			t.Errorf("Expected strike to be %+v but got %+v at idx: %d", tab.strike, d.Strike, idx)
+		}
+		if d.Bid.String() != tab.bid || d.Ask.String() != tab.ask {
+			t.Errorf("Expected bid and ask to be %+v and %+v but got %+v and %+v at idx: %d", tab.bid, tab.ask, d.Bid, d.Ask, idx)
+		}
+		if d.Volume.String() != tab.vol {
+			t.Errorf("Expected volume to be %+v but got %+v at idx: %d", tab.vol, d.Volume, idx)
+		}
+		if d.Multiplier.String() != "1000" {
+			t.Errorf("Expected multiplier to be 1000 but got %+v at idx: %d", d.Multiplier, idx)
+		}
+		if d.UndBid.String() != "28814.34" {
+			t.Errorf("Expected underlying price to be 28814.34 but got %+v at idx: %d", d.UndBid, idx)
+		}
+	}
+}
+
+func TestJPXSQDate(t *testing.T) {
+	tt := []struct {
+		year     int
+		month    time.Month
+		expected string
+	}{
+		{year: 2021, month: time.June, expected: "2021-06-11"},
+		{year: 2021, month: time.July, expected: "2021-07-09"},
+		// the second Friday is Mountain Day, so SQ is the Thursday before
+		{year: 2023, month: time.August, expected: "2023-08-10"},
+		// the second Friday is National Foundation Day
+		{year: 2022, month: time.February, expected: "2022-02-10"},
+	}
+	for idx, tab := range tt {
+		if d := jpxSQDate(tab.year, tab.month).Format(model.DateLayout); d != tab.expected {
+			t.Errorf("Expected SQ date to be %+v but got %+v at idx: %d", tab.expected, d, idx)
+		}
+	}
+}
+
+func TestNormalizedRoundTrip(t *testing.T) {
+	// normalize the livevol test data, read it back and write it again
+	var first bytes.Buffer
+	w := csv.NewWriter(&first)
+	if err := NewLiveVolImporter(w).ImportFolder("./../testdata"); err != nil {
+		t.Fatal(errors.Wrap(err, "Error from import folder func"))
+	}
+	w.Flush()
+	rows, err := NewFileReader().ReadNormalizedCSVFile(csv.NewReader(bytes.NewReader(first.Bytes())))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading normalized rows"))
+	}
+
+	var second bytes.Buffer
+	nw := NewNormalizedWriter(&second)
+	for _, o := range rows {
+		if err := nw.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := nw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewFileReader().ReadNormalizedCSVFile(csv.NewReader(bytes.NewReader(second.Bytes())))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading written rows"))
+	}
+	if len(again) != len(rows) {
+		t.Fatalf("Expected %d rows but got %d", len(rows), len(again))
+	}
+	var fourth bytes.Buffer
+	nw = NewNormalizedWriter(&fourth)
+	for _, o := range again {
+		if err := nw.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := nw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Split(second.String(), "\n")
+	got := strings.Split(fourth.String(), "\n")
+	for idx := range want {
+		if idx >= len(got) || want[idx] != got[idx] {
+			t.Errorf("Expected %+v but got %+v at idx: %d", want[idx], got, idx)
+			break
+		}
+	}
+
+	// the normalized importer passes normalized rows through
+	var third bytes.Buffer
+	iw := csv.NewWriter(&third)
+	imp := NewNormalizedImporter(iw)
+	if err := imp.(*csvImporter).importCSV(iw, bytes.NewReader(second.Bytes()), "normalized"); err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing normalized rows"))
+	}
+	iw.Flush()
+	if imp.Stats().Rows != len(rows) {
+		t.Errorf("Expected %d rows to be imported but got %d", len(rows), imp.Stats().Rows)
+	}
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"backtest-options/calendar"
+	"backtest-options/model"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// JPX Nikkei 225 option daily price file column names. The files are Shift-JIS encoded, and hold the whole day, night session and day session prices of every contract.
+const (
+	jpxDate            = "日付"
+	jpxOpen            = "日通し始値"
+	jpxHigh            = "日通し高値"
+	jpxLow             = "日通し安値"
+	jpxClose           = "日通し終値"
+	jpxVolume          = "取引高"
+	jpxOpenInterest    = "建玉"
+	jpxContractMonth   = "限月"
+	jpxStrike          = "権利行使価格"
+	jpxEmergencyMargin = "緊急取引証拠金発動区分"
+	jpxPutCall         = "プットコール区分"
+	jpxSQDay           = "SQ日"
+	jpxSettle          = "清算値段"
+	jpxUndPx           = "原資産価格"
+)
+
+const (
+	// jpxN225Symbol is the normalized underlying symbol for the Nikkei 225
+	jpxN225Symbol = "N225"
+	// jpxN225Multiplier is the contract multiplier of Nikkei 225 options
+	jpxN225Multiplier = "1000"
+	// jpxEmergencyMarginTriggered marks the extra row of a contract settled for an emergency margin call during the day
+	jpxEmergencyMarginTriggered = "002"
+)
+
+// jpxMapper maps JPX Nikkei 225 option daily price rows. Prices are in JPY. The files have no quotes, so the settlement price is used as both bid and ask. The expiration is the special quotation (SQ) date of the row, or if it is missing the SQ date of the contract month.
+type jpxMapper struct {
+	cols map[string]int
+}
+
+// NewJPXImporter is an importer for JPX Nikkei 225 option daily price files
+func NewJPXImporter(w *csv.Writer) Importer {
+	return &csvImporter{
+		writer: w,
+		mapper: &jpxMapper{},
+		decode: func(r io.Reader) io.Reader {
+			return japanese.ShiftJIS.NewDecoder().Reader(r)
+		},
+	}
+}
+
+func (m *jpxMapper) setHeader(header []string) error {
+	m.cols = make(map[string]int)
+	for i, name := range header {
+		m.cols[strings.TrimSpace(trimBOM(name))] = i
+	}
+	for _, name := range []string{jpxDate, jpxContractMonth, jpxStrike, jpxPutCall, jpxSettle} {
+		if _, ok := m.cols[name]; !ok {
+			return errors.Errorf("Expected column %+v in JPX header", name)
+		}
+	}
+	return nil
+}
+
+// get returns the trimmed value of a column, or an empty string if the file does not have it
+func (m *jpxMapper) get(field []string, name string) string {
+	idx, ok := m.cols[name]
+	if !ok || idx >= len(field) {
+		return ""
+	}
+	return strings.TrimSpace(field[idx])
+}
+
+// price returns a numeric column without thousands separators. Missing values, which JPX writes as "-", are returned as an empty string
+func (m *jpxMapper) price(field []string, name string) string {
+	v := strings.Replace(m.get(field, name), ",", "", -1)
+	if v == "-" {
+		return ""
+	}
+	return v
+}
+
+// priceOrZero returns a numeric column, or zero if it is missing
+func (m *jpxMapper) priceOrZero(field []string, name string) string {
+	v := m.price(field, name)
+	if v == "" {
+		return "0"
+	}
+	return v
+}
+
+func (m *jpxMapper) mapRow(field []string) ([]string, bool) {
+	// the emergency margin settlement duplicates the contract of the day
+	if m.get(field, jpxEmergencyMargin) == jpxEmergencyMarginTriggered {
+		return nil, false
+	}
+	quoteDate, err := parseJPXDate(m.get(field, jpxDate))
+	if err != nil {
+		return nil, false
+	}
+	exp, err := parseJPXDate(m.get(field, jpxSQDay))
+	if err != nil {
+		exp, err = parseJPXContractMonth(m.get(field, jpxContractMonth))
+		if err != nil {
+			return nil, false
+		}
+	}
+	var typ string
+	switch m.get(field, jpxPutCall) {
+	case "1":
+		typ = "P"
+	case "2":
+		typ = "C"
+	default:
+		return nil, false
+	}
+	strike := m.price(field, jpxStrike)
+	if strike == "" {
+		return nil, false
+	}
+	settle := m.price(field, jpxSettle)
+	if settle == "" {
+		return nil, false
+	}
+	undpx := m.priceOrZero(field, jpxUndPx)
+
+	values := make([]string, len(normalizedHeader))
+	values[csvStdUndSym] = jpxN225Symbol
+	values[csvStdQuoteDate] = quoteDate.Format(model.DateLayout)
+	values[csvStdExp] = exp.Format(model.DateLayout)
+	values[csvStdStrike] = strike
+	values[csvStdOptType] = typ
+	values[csvStdOpen] = m.priceOrZero(field, jpxOpen)
+	values[csvStdHigh] = m.priceOrZero(field, jpxHigh)
+	values[csvStdLow] = m.priceOrZero(field, jpxLow)
+	values[csvStdClose] = m.priceOrZero(field, jpxClose)
+	values[csvStdVol] = m.priceOrZero(field, jpxVolume)
+	values[csvStdBid] = settle
+	values[csvStdAsk] = settle
+	values[csvStdUndBid] = undpx
+	values[csvStdUndAsk] = undpx
+	values[csvStdOpenInterest] = m.price(field, jpxOpenInterest)
+	values[csvStdMultiplier] = jpxN225Multiplier
+	return values, true
+}
+
+// parseJPXDate parses dates written as 20210611 or 2021/06/11
+func parseJPXDate(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "2006/01/02", model.DateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("Unsupported JPX date %+v", s)
+}
+
+// parseJPXContractMonth parses a contract month written as 202106 or 2021/06 and returns its SQ date
+func parseJPXContractMonth(s string) (time.Time, error) {
+	for _, layout := range []string{"200601", "2006/01", "2006-01"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return jpxSQDate(t.Year(), t.Month()), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("Unsupported JPX contract month %+v", s)
+}
+
+// jpxSQDate returns the special quotation date of monthly Nikkei 225 options, the second Friday of the month or the trading day before it if it is a holiday
+func jpxSQDate(year int, month time.Month) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(time.Friday) - int(first.Weekday()) + 7) % 7
+	return calendar.JPX().LastTradingDay(first.AddDate(0, 0, offset+7))
+}
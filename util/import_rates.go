@@ -0,0 +1,215 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// RatesDir is the directory in the data dir the risk-free rate curve is kept in
+const RatesDir = "rates"
+
+// RatesFileName is the name of the normalized risk-free rate curve
+const RatesFileName = "curve.csv"
+
+var ratesHeader = []string{"date", "tenor", "rate"}
+
+var tenorLabel = regexp.MustCompile(`^(\d+)\s*(d|day|days|w|wk|wks|week|weeks|m|mo|mos|month|months|y|yr|yrs|year|years)$`)
+
+// RatesPath returns the normalized risk-free rate curve file
+func RatesPath(dataDir string) string {
+	return filepath.Join(dataDir, RatesDir, RatesFileName)
+}
+
+// parseTenor parses labels such as 1 Mo, 13 Wk or 10 Yr into calendar days
+func parseTenor(s string) (int, bool) {
+	m := tenorLabel.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	switch m[2][0] {
+	case 'd':
+		return n, true
+	case 'w':
+		return n * 7, true
+	case 'm':
+		return int(math.Round(float64(n) * 365 / 12)), true
+	default:
+		return n * 365, true
+	}
+}
+
+// ReadRatesCSV reads risk-free rates from a csv with a header. It reads the normalized date, tenor (calendar days) and rate (a fraction) columns, or a date column followed by one column per tenor in percent, such as the Treasury daily yield curve (Date,1 Mo,2 Mo,3 Mo,6 Mo,1 Yr,...). Empty rates are skipped.
+func ReadRatesCSV(r *csv.Reader) ([]model.RatePoint, error) {
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading rates header")
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(trimBOM(name)))] = i
+	}
+	dateCol, ok := cols["date"]
+	if !ok {
+		return nil, errors.Errorf("Expected a date column in rates header %+v", header)
+	}
+	tenorCol, hasTenor := cols["tenor"]
+	rateCol, hasRate := cols["rate"]
+	long := hasTenor && hasRate
+	tenors := make(map[int]int)
+	if !long {
+		for i, name := range header {
+			if days, ok := parseTenor(name); ok {
+				tenors[i] = days
+			}
+		}
+		if len(tenors) == 0 {
+			return nil, errors.Errorf("Expected tenor and rate, or tenor columns such as 3 Mo in rates header %+v", header)
+		}
+	}
+	hundred := decimal.NewFromInt(100)
+
+	points := make([]model.RatePoint, 0)
+	for row := 2; ; row++ {
+		field, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading rates row %d", row)
+		}
+		if dateCol >= len(field) {
+			return nil, errors.Errorf("Expected %d columns at rates row %d", len(header), row)
+		}
+		d, err := parseUnderlyingDate(strings.TrimSpace(field[dateCol]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing rates row %d", row)
+		}
+		if long {
+			if tenorCol >= len(field) || rateCol >= len(field) {
+				return nil, errors.Errorf("Expected %d columns at rates row %d", len(header), row)
+			}
+			tenor, err := strconv.Atoi(strings.TrimSpace(field[tenorCol]))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error parsing tenor at rates row %d", row)
+			}
+			s := strings.TrimSpace(field[rateCol])
+			if s == "" {
+				continue
+			}
+			rate, err := decimal.NewFromString(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error parsing rate %+v at rates row %d", s, row)
+			}
+			points = append(points, model.RatePoint{Date: d, Rate: rate, Tenor: tenor})
+			continue
+		}
+		for i, tenor := range tenors {
+			if i >= len(field) {
+				continue
+			}
+			s := strings.TrimSpace(field[i])
+			// Treasury files leave tenors that were not issued empty or as N/A
+			if s == "" || strings.EqualFold(s, "N/A") {
+				continue
+			}
+			rate, err := decimal.NewFromString(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error parsing %s: %+v at rates row %d", header[i], s, row)
+			}
+			points = append(points, model.RatePoint{Date: d, Rate: rate.Div(hundred), Tenor: tenor})
+		}
+	}
+	return points, nil
+}
+
+// ImportRates merges the rates of a csv file into the risk-free rate curve in dataDir. Rates of the same date and tenor are replaced. It returns the number of rates read from source.
+func ImportRates(source, dataDir string) (int, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %s", source)
+	}
+	defer f.Close()
+	points, err := ReadRatesCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error reading %s", source)
+	}
+
+	existing, ok, err := LoadRates(dataDir, decimal.Zero)
+	if err != nil {
+		return 0, err
+	}
+	merged := points
+	if ok {
+		merged = append(existing.Points(), points...)
+	}
+	curve, err := model.NewRateCurve(merged, decimal.Zero)
+	if err != nil {
+		return 0, err
+	}
+
+	path := RatesPath(dataDir)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return 0, errors.Wrapf(err, "Error making dir %s", filepath.Dir(path))
+	}
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write(ratesHeader)
+	for _, p := range curve.Points() {
+		w.Write([]string{p.Date.Format(model.DateLayout), strconv.Itoa(p.Tenor), p.Rate.String()})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := out.Close(); err != nil {
+		return 0, errors.Wrapf(err, "Error closing %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, errors.Wrapf(err, "Error renaming %s", tmp)
+	}
+	return len(points), nil
+}
+
+// LoadRates reads the risk-free rate curve from dataDir with fallback as the rate before its first date. It returns false if none was imported.
+func LoadRates(dataDir string, fallback decimal.Decimal) (*model.RateCurve, bool, error) {
+	path := RatesPath(dataDir)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error opening %s", path)
+	}
+	defer f.Close()
+	points, err := ReadRatesCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	curve, err := model.NewRateCurve(points, fallback)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	return curve, true, nil
+}
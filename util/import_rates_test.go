@@ -0,0 +1,72 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestImportRates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rates")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"treasury.csv": "Date,1 Mo,2 Mo,3 Mo,6 Mo,1 Yr\n01/02/2020,1.53,1.55,1.54,N/A,1.56\n01/03/2020,1.52,1.55,1.52,1.55,1.54\n",
+		"custom.csv":   "date,tenor,rate\n2020-01-03,30,0.0151\n",
+	}
+	dataDir := filepath.Join(dir, "data")
+	for _, name := range []string{"treasury.csv", "custom.csv"} {
+		source := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(source, []byte(sources[name]), 0666); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing source"))
+		}
+		if _, err := ImportRates(source, dataDir); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error importing %s", name))
+		}
+	}
+
+	c, ok, err := LoadRates(dataDir, decimal.NewFromFloat(0.02))
+	if err != nil || !ok {
+		t.Fatalf("Expected rates but got %+v %+v", ok, err)
+	}
+	if len(c.Points()) != 9 {
+		t.Errorf("Expected 9 points but got %+v", c.Points())
+	}
+	d1 := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)
+	tt := []struct {
+		date time.Time
+		days int
+		rate string
+	}{
+		{date: d1.AddDate(0, 0, -1), days: 30, rate: "0.02"},
+		{date: d1, days: 91, rate: "0.0154"},
+		// the missing 6 Mo tenor is interpolated between 3 Mo and 1 Yr
+		{date: d1, days: 228, rate: "0.0155"},
+		// the later import replaces the 1 Mo rate
+		{date: d2, days: 30, rate: "0.0151"},
+		{date: d2, days: 365, rate: "0.0154"},
+	}
+	for idx, tab := range tt {
+		rate := c.Rate(tab.date, tab.days)
+		if !rate.Equal(decimal.RequireFromString(tab.rate)) {
+			t.Errorf("Expected rate to be %+v but got %+v at idx: %d", tab.rate, rate, idx)
+		}
+	}
+
+	files, err := PartitionFiles(dataDir, "", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error listing partitions"))
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected the rate curve not to be read as option data but got %+v", files)
+	}
+}
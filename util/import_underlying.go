@@ -0,0 +1,199 @@
+package util
+
+import (
+	"backtest-options/model"
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// UnderlyingFileName is the name of the underlying price history kept in a symbol's data directory
+const UnderlyingFileName = "underlying.csv"
+
+var underlyingHeader = []string{"date", "open", "high", "low", "close", "volume"}
+
+// UnderlyingPath returns the normalized underlying price history file of the symbol
+func UnderlyingPath(dataDir, symbol string) string {
+	return filepath.Join(dataDir, partitionSymbol(symbol), UnderlyingFileName)
+}
+
+// ReadUnderlyingCSV reads daily bars from a csv with a header. Columns are found by name (date, open, high, low, close and an optional volume, in any case), so exports such as Yahoo Finance's can be read as is.
+func ReadUnderlyingCSV(r *csv.Reader) ([]model.UnderlyingBar, error) {
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading underlying header")
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(trimBOM(name)))] = i
+	}
+	for _, name := range underlyingHeader[:5] {
+		if _, ok := cols[name]; !ok {
+			return nil, errors.Errorf("Expected column %+v in underlying header %+v", name, header)
+		}
+	}
+	get := func(field []string, name string) string {
+		idx, ok := cols[name]
+		if !ok || idx >= len(field) {
+			return ""
+		}
+		return strings.TrimSpace(field[idx])
+	}
+
+	bars := make([]model.UnderlyingBar, 0)
+	for row := 2; ; row++ {
+		field, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading underlying row %d", row)
+		}
+		d, err := parseUnderlyingDate(get(field, "date"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error parsing underlying row %d", row)
+		}
+		bar := model.UnderlyingBar{Date: d}
+		values := []struct {
+			name string
+			dst  *decimal.Decimal
+		}{
+			{"open", &bar.Open},
+			{"high", &bar.High},
+			{"low", &bar.Low},
+			{"close", &bar.Close},
+			{"volume", &bar.Volume},
+		}
+		for _, v := range values {
+			s := get(field, v.name)
+			dec, err := decimalOrZero(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error parsing %s: %+v at underlying row %d", v.name, s, row)
+			}
+			*v.dst = dec
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// decimalOrZero parses s as a decimal and returns zero if s is empty
+func decimalOrZero(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Decimal{}, nil
+	}
+	return decimal.NewFromString(s)
+}
+
+// parseUnderlyingDate parses dates written as 2006-01-02, 01/02/2006 or 20060102
+func parseUnderlyingDate(s string) (time.Time, error) {
+	for _, layout := range []string{model.DateLayout, "01/02/2006", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("Unsupported date %+v", s)
+}
+
+// ImportUnderlying merges the daily bars of a csv file into the symbol's underlying price history in dataDir. Bars of dates that already exist are replaced. It returns the number of bars read from source.
+func ImportUnderlying(source, dataDir, symbol string) (int, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error opening %s", source)
+	}
+	defer f.Close()
+	bars, err := ReadUnderlyingCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error reading %s", source)
+	}
+
+	existing, ok, err := LoadUnderlying(dataDir, symbol)
+	if err != nil {
+		return 0, err
+	}
+	merged := bars
+	if ok {
+		merged = append(append([]model.UnderlyingBar{}, existing.Bars()...), bars...)
+	}
+	series, err := model.NewUnderlyingSeries(symbol, merged)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeUnderlying(UnderlyingPath(dataDir, symbol), series); err != nil {
+		return 0, err
+	}
+	return len(bars), nil
+}
+
+// SaveUnderlying replaces the underlying price history of the series' symbol in dataDir
+func SaveUnderlying(dataDir string, series *model.UnderlyingSeries) error {
+	return writeUnderlying(UnderlyingPath(dataDir, series.Symbol), series)
+}
+
+// writeUnderlying writes the series in the normalized underlying format
+func writeUnderlying(path string, series *model.UnderlyingSeries) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "Error making dir %s", filepath.Dir(path))
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(underlyingHeader)
+	for _, b := range series.Bars() {
+		w.Write([]string{
+			b.Date.Format(model.DateLayout),
+			b.Open.String(),
+			b.High.String(),
+			b.Low.String(),
+			b.Close.String(),
+			b.Volume.String(),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.Wrapf(err, "Error writing %s", tmp)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "Error closing %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrapf(err, "Error renaming %s", tmp)
+	}
+	return nil
+}
+
+// LoadUnderlying reads the symbol's underlying price history from dataDir. It returns false if none was imported.
+func LoadUnderlying(dataDir, symbol string) (*model.UnderlyingSeries, bool, error) {
+	path := UnderlyingPath(dataDir, symbol)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error opening %s", path)
+	}
+	defer f.Close()
+	bars, err := ReadUnderlyingCSV(csv.NewReader(bufio.NewReader(f)))
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Error reading %s", path)
+	}
+	series, err := model.NewUnderlyingSeries(symbol, bars)
+	if err != nil {
+		return nil, false, err
+	}
+	return series, true, nil
+}
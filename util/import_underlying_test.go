@@ -0,0 +1,66 @@
+package util
+
+import (
+	"backtest-options/model"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestImportUnderlying(t *testing.T) {
+	dir, err := ioutil.TempDir("", "underlying")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	first := filepath.Join(dir, "spy1.csv")
+	second := filepath.Join(dir, "spy2.csv")
+	if err := ioutil.WriteFile(first, []byte("Date,Open,High,Low,Close,Adj Close,Volume\n2016-06-01,209.12,210.48,208.89,210.27,190.1,82545400\n2016-06-02,209.87,210.93,209.24,210.91,190.7,68954300\n"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	if err := ioutil.WriteFile(second, []byte("date,open,high,low,close\n06/02/2016,209.87,210.93,209.24,211,\n06/03/2016,210.27,210.37,208.35,210.28\n"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	dataDir := filepath.Join(dir, "data")
+	for _, source := range []string{first, second} {
+		if _, err := ImportUnderlying(source, dataDir, "SPY"); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error importing %s", source))
+		}
+	}
+
+	s, ok, err := LoadUnderlying(dataDir, "SPY")
+	if err != nil || !ok {
+		t.Fatalf("Expected underlying history but got %+v %+v", ok, err)
+	}
+	expected := []struct {
+		date  string
+		close string
+	}{
+		{"2016-06-01", "210.27"},
+		{"2016-06-02", "211"},
+		{"2016-06-03", "210.28"},
+	}
+	bars := s.Bars()
+	if len(bars) != len(expected) {
+		t.Fatalf("Expected %d bars but got %+v", len(expected), bars)
+	}
+	for idx, exp := range expected {
+		d, _ := time.Parse(model.DateLayout, exp.date)
+		if !bars[idx].Date.Equal(d) || !bars[idx].Close.Equal(decimal.RequireFromString(exp.close)) {
+			t.Errorf("Expected %+v but got %+v at idx: %d", exp, bars[idx], idx)
+		}
+	}
+	if !bars[0].Volume.Equal(decimal.NewFromInt(82545400)) {
+		t.Errorf("Expected volume to be kept but got %+v", bars[0].Volume)
+	}
+
+	if _, ok, _ := LoadUnderlying(dataDir, "QQQ"); ok {
+		t.Errorf("Expected no underlying history for QQQ")
+	}
+}
@@ -1,7 +1,253 @@
 package util
 
+import (
+	"archive/zip"
+	"compress/gzip"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
 // Importer is a data import interface
 type Importer interface {
 	ImportFolder(folder string) error
 	ImportFile(file, output string) error
+	Stats() ImportStats
+}
+
+// NewImporter returns an importer for a data vendor. Supported vendors are livevol, cboe, jpx and normalized, for files that are already in the normalized format.
+func NewImporter(vendor string, w *csv.Writer) (Importer, error) {
+	switch vendor {
+	case "", "livevol":
+		return NewLiveVolImporter(w), nil
+	case "cboe":
+		return NewCBOECalcsImporter(w), nil
+	case "jpx":
+		return NewJPXImporter(w), nil
+	case "normalized":
+		return NewNormalizedImporter(w), nil
+	default:
+		return nil, errors.Errorf("Unsupported vendor %+v", vendor)
+	}
+}
+
+// ImportStats is a summary of what an importer has processed
+type ImportStats struct {
+	// Files is the number of csv files read, including files inside archives
+	Files int
+	// Rows is the number of rows written to the normalized output
+	Rows int
+	// Skipped is the number of rows that were dropped because they were malformed
+	Skipped int
+	// FirstQuoteDate is the earliest quote date written, formatted as model.DateLayout
+	FirstQuoteDate string
+	// LastQuoteDate is the latest quote date written, formatted as model.DateLayout
+	LastQuoteDate string
+	// Symbols are the underlying symbols written in ascending order
+	Symbols []string
+}
+
+// addQuoteDate widens the quote date range with d
+func (s *ImportStats) addQuoteDate(d string) {
+	if s.FirstQuoteDate == "" || d < s.FirstQuoteDate {
+		s.FirstQuoteDate = d
+	}
+	if d > s.LastQuoteDate {
+		s.LastQuoteDate = d
+	}
+}
+
+// addSymbol adds sym to the symbols written
+func (s *ImportStats) addSymbol(sym string) {
+	i := sort.SearchStrings(s.Symbols, sym)
+	if i < len(s.Symbols) && s.Symbols[i] == sym {
+		return
+	}
+	s.Symbols = append(s.Symbols, "")
+	copy(s.Symbols[i+1:], s.Symbols[i:])
+	s.Symbols[i] = sym
+}
+
+// rowMapper converts rows of a vendor file into normalized rows
+type rowMapper interface {
+	// setHeader is called with the first row of every csv file
+	setHeader(header []string) error
+	// mapRow converts a vendor row into a normalized row indexed by the csvStd constants. It returns false if the row should be skipped
+	mapRow(field []string) ([]string, bool)
+}
+
+// csvImporter reads zip, csv and csv.gz files and writes normalized rows using a vendor specific rowMapper
+type csvImporter struct {
+	writer        *csv.Writer
+	headerWritten bool
+	mapper        rowMapper
+	stats         ImportStats
+	// decode converts the source encoding into utf-8. It is nil for sources that are already utf-8
+	decode func(io.Reader) io.Reader
+}
+
+// Stats returns the number of files and rows imported so far
+func (imp *csvImporter) Stats() ImportStats {
+	return imp.stats
+}
+
+// ImportFolder imports a folder and outputs to specified data directory
+func (imp *csvImporter) ImportFolder(folder string) error {
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return errors.Wrap(err, "Error importing folder")
+	}
+
+	imp.writeHeader(imp.writer)
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if err := imp.importPath(imp.writer, folder+"/"+file.Name()); err != nil {
+			return errors.Wrapf(err, "Error importing file %s", folder+"/"+file.Name())
+		}
+	}
+	return nil
+}
+
+// ImportFile imports a single .zip, .csv or .csv.gz file. If output is empty, rows are written to the importer's writer, otherwise output is created (or truncated) and rows are written there.
+func (imp *csvImporter) ImportFile(file string, output string) error {
+	if output == "" {
+		imp.writeHeader(imp.writer)
+		return imp.importPath(imp.writer, file)
+	}
+
+	out, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening output file %s", output)
+	}
+	defer out.Close()
+
+	w := csv.NewWriter(out)
+	w.Write(normalizedHeader)
+	if err := imp.importPath(w, file); err != nil {
+		return errors.Wrapf(err, "Error importing file %s", file)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.Wrapf(err, "Error writing output file %s", output)
+	}
+	return nil
+}
+
+// writeHeader writes the normalized header once to the importer's writer
+func (imp *csvImporter) writeHeader(w *csv.Writer) {
+	if imp.headerWritten {
+		return
+	}
+	w.Write(normalizedHeader)
+	imp.headerWritten = true
+}
+
+// importPath dispatches on the file extension and imports every row in the file
+func (imp *csvImporter) importPath(w *csv.Writer, path string) error {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return imp.importZip(w, path)
+	case strings.HasSuffix(lower, ".csv.gz"):
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening file %s", path)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening gzip file %s", path)
+		}
+		defer gz.Close()
+		imp.stats.Files++
+		return imp.importCSV(w, gz, path)
+	case strings.HasSuffix(lower, ".csv"):
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening file %s", path)
+		}
+		defer f.Close()
+		imp.stats.Files++
+		return imp.importCSV(w, f, path)
+	default:
+		log.Warnf("Skipping unsupported file %s", path)
+		return nil
+	}
+}
+
+// importZip imports every csv file inside a zip archive
+func (imp *csvImporter) importZip(w *csv.Writer, path string) error {
+	f, err := zip.OpenReader(path)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening file %s", path)
+	}
+	defer f.Close()
+
+	for _, file := range f.File {
+		if file.FileInfo().IsDir() || isResourceFork(file.Name) {
+			continue
+		}
+		fopen, err := file.Open()
+		if err != nil {
+			return errors.Wrapf(err, "Error opening file %+v", file.Name)
+		}
+		imp.stats.Files++
+		err = imp.importCSV(w, fopen, file.Name)
+		fopen.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importCSV converts vendor rows into normalized rows. The first row is treated as a header.
+func (imp *csvImporter) importCSV(w *csv.Writer, r io.Reader, name string) error {
+	if imp.decode != nil {
+		r = imp.decode(r)
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	for row := 0; ; row++ {
+		field, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Error reading row %d of %+v", row+1, name)
+		}
+		if row == 0 {
+			if err := imp.mapper.setHeader(field); err != nil {
+				return errors.Wrapf(err, "Error reading header of %+v", name)
+			}
+			continue
+		}
+		values, ok := imp.mapper.mapRow(field)
+		if !ok {
+			imp.stats.Skipped++
+			continue
+		}
+		w.Write(values)
+		imp.stats.Rows++
+		imp.stats.addQuoteDate(values[csvStdQuoteDate])
+		imp.stats.addSymbol(values[csvStdUndSym])
+	}
+	return nil
+}
+
+// isResourceFork reports whether the zip entry is macOS metadata rather than data
+func isResourceFork(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(filepath.Base(name), "._")
 }
@@ -0,0 +1,311 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ManifestFileName is the name of the manifest file kept in the data directory
+const ManifestFileName = "manifest.json"
+
+// ImportAction describes what an incremental import did with a source file
+type ImportAction string
+
+const (
+	// ImportAdded means the source was not imported before and has been imported
+	ImportAdded ImportAction = "added"
+	// ImportReplaced means the source was imported before with different contents and has been re-imported
+	ImportReplaced ImportAction = "replaced"
+	// ImportSkipped means the source was already imported with the same contents
+	ImportSkipped ImportAction = "skipped"
+)
+
+// ManifestEntry is a record of one imported source file
+type ManifestEntry struct {
+	// Source is the base name of the imported source file
+	Source string `json:"source"`
+	// Size is the size of the source file in bytes
+	Size int64 `json:"size"`
+	// Checksum is the hex encoded sha256 of the source file
+	Checksum string `json:"checksum"`
+	// FirstQuoteDate is the earliest quote date in the source file
+	FirstQuoteDate string `json:"first_quote_date"`
+	// LastQuoteDate is the latest quote date in the source file
+	LastQuoteDate string `json:"last_quote_date"`
+	// Outputs are the partitioned normalized files, relative to the data directory
+	Outputs []string `json:"outputs"`
+	// Rows is the number of normalized rows written
+	Rows int `json:"rows"`
+	// ImportedAt is the time this source was imported
+	ImportedAt time.Time `json:"imported_at"`
+}
+
+// UnmarshalJSON decodes an entry. Entries written before imports were partitioned have a single top-level output, which is read as the first of Outputs so that re-importing the source removes it
+func (e *ManifestEntry) UnmarshalJSON(b []byte) error {
+	type entry ManifestEntry
+	v := struct {
+		*entry
+		Output string `json:"output"`
+	}{entry: (*entry)(e)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.Output == "" {
+		return nil
+	}
+	for _, o := range e.Outputs {
+		if o == v.Output {
+			return nil
+		}
+	}
+	e.Outputs = append([]string{v.Output}, e.Outputs...)
+	return nil
+}
+
+// Manifest is a list of source files that have been imported into a data directory
+type Manifest struct {
+	path    string
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// LoadManifest reads a manifest from path. A missing file results in an empty manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{
+		path:    path,
+		Entries: make([]ManifestEntry, 0),
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading manifest %s", path)
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing manifest %s", path)
+	}
+	return m, nil
+}
+
+// Save writes the manifest back to the path it was loaded from
+func (m *Manifest) Save() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error encoding manifest")
+	}
+	tmp := m.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrapf(err, "Error writing manifest %s", tmp)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return errors.Wrapf(err, "Error renaming manifest %s", tmp)
+	}
+	return nil
+}
+
+// Find returns the entry for the source name, or nil if it has not been imported
+func (m *Manifest) Find(source string) *ManifestEntry {
+	for i := range m.Entries {
+		if m.Entries[i].Source == source {
+			return &m.Entries[i]
+		}
+	}
+	return nil
+}
+
+// FindChecksum returns the entry with the checksum, or nil if no source has this content
+func (m *Manifest) FindChecksum(checksum string) *ManifestEntry {
+	for i := range m.Entries {
+		if m.Entries[i].Checksum == checksum {
+			return &m.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Overlaps returns entries other than source with partitions of the symbols whose quote date range overlaps first and last. Entries written before imports were partitioned have unknown symbols and overlap any symbol
+func (m *Manifest) Overlaps(source string, symbols []string, first, last string) []ManifestEntry {
+	partitions := make(map[string]bool)
+	for _, sym := range symbols {
+		partitions[partitionSymbol(sym)] = true
+	}
+	overlaps := make([]ManifestEntry, 0)
+	for _, e := range m.Entries {
+		if e.Source == source || e.FirstQuoteDate == "" {
+			continue
+		}
+		if e.FirstQuoteDate > last || first > e.LastQuoteDate {
+			continue
+		}
+		for _, o := range e.Outputs {
+			// outputs are symbol/year/name, or name for top-level files
+			parts := strings.Split(filepath.ToSlash(o), "/")
+			if len(parts) < 3 || partitions[parts[0]] {
+				overlaps = append(overlaps, e)
+				break
+			}
+		}
+	}
+	return overlaps
+}
+
+// put adds or replaces the entry with the same source
+func (m *Manifest) put(entry ManifestEntry) {
+	if e := m.Find(entry.Source); e != nil {
+		*e = entry
+		return
+	}
+	m.Entries = append(m.Entries, entry)
+}
+
+// OverlapError is the error of a source with quotes on dates that imported sources cover for the same symbol
+type OverlapError struct {
+	// Source is the base name of the source that was not imported
+	Source         string
+	FirstQuoteDate string
+	LastQuoteDate  string
+	// Overlaps are the imported sources it overlaps with
+	Overlaps []ManifestEntry
+}
+
+func (e *OverlapError) Error() string {
+	sources := make([]string, len(e.Overlaps))
+	for i, o := range e.Overlaps {
+		sources[i] = fmt.Sprintf("%s (%s to %s)", o.Source, o.FirstQuoteDate, o.LastQuoteDate)
+	}
+	return fmt.Sprintf("Expected quote dates %s to %s of %s not to overlap with imported sources but got %s, use force to import it anyway",
+		e.FirstQuoteDate, e.LastQuoteDate, e.Source, strings.Join(sources, ", "))
+}
+
+// FileChecksum returns the hex encoded sha256 and the size of a file
+func FileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "Error opening file %s", path)
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "Error hashing file %s", path)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// ImportIncremental imports source into its own normalized file in every symbol and year partition of outputDir and records it in the manifest. A source whose contents were already imported is skipped unless force is true, and a source that was imported before with different contents replaces its previous output. A source with quotes of a symbol on dates another source covers fails with an OverlapError unless force is true, since both would be loaded.
+func ImportIncremental(m *Manifest, newImporter func() Importer, source, outputDir string, force bool) (ImportAction, ImportStats, error) {
+	name := filepath.Base(source)
+	checksum, size, err := FileChecksum(source)
+	if err != nil {
+		return "", ImportStats{}, errors.Wrapf(err, "Error computing checksum for %s", source)
+	}
+
+	action := ImportAdded
+	prev := m.Find(name)
+	if prev != nil {
+		if prev.Checksum == checksum && !force {
+			return ImportSkipped, ImportStats{}, nil
+		}
+		action = ImportReplaced
+	} else if dup := m.FindChecksum(checksum); dup != nil && !force {
+		log.Warnf("Skipping %s since the same contents were imported from %s", name, dup.Source)
+		return ImportSkipped, ImportStats{}, nil
+	}
+
+	output := normalizedFileName(name)
+	tmp := filepath.Join(outputDir, output+".tmp")
+	defer os.Remove(tmp)
+	imp := newImporter()
+	if err := imp.ImportFile(source, tmp); err != nil {
+		return "", ImportStats{}, errors.Wrapf(err, "Error importing %s", source)
+	}
+
+	// quotes of the same symbol and date from two sources would be loaded twice
+	stats := imp.Stats()
+	if overlaps := m.Overlaps(name, stats.Symbols, stats.FirstQuoteDate, stats.LastQuoteDate); len(overlaps) > 0 {
+		err := &OverlapError{Source: name, FirstQuoteDate: stats.FirstQuoteDate, LastQuoteDate: stats.LastQuoteDate, Overlaps: overlaps}
+		if !force {
+			return "", ImportStats{}, err
+		}
+		log.Warn(errors.Wrapf(err, "Importing %s anyway", name))
+	}
+
+	outputs, err := partitionFile(tmp, outputDir, output)
+	if err != nil {
+		return "", ImportStats{}, errors.Wrapf(err, "Error partitioning %s", source)
+	}
+	if prev != nil {
+		if err := removeStaleOutputs(outputDir, prev.Outputs, outputs); err != nil {
+			return "", ImportStats{}, err
+		}
+	}
+	// binary copies of rewritten partitions no longer match them
+	for _, o := range outputs {
+		if err := removeIfExists(BinaryPath(filepath.Join(outputDir, o))); err != nil {
+			return "", ImportStats{}, err
+		}
+	}
+
+	m.put(ManifestEntry{
+		Source:         name,
+		Size:           size,
+		Checksum:       checksum,
+		FirstQuoteDate: stats.FirstQuoteDate,
+		LastQuoteDate:  stats.LastQuoteDate,
+		Outputs:        outputs,
+		Rows:           stats.Rows,
+		ImportedAt:     time.Now().UTC(),
+	})
+	return action, stats, nil
+}
+
+// removeStaleOutputs removes previously written partitions, and their binary copies, that were not written again
+func removeStaleOutputs(outputDir string, prev, current []string) error {
+	keep := make(map[string]bool)
+	for _, o := range current {
+		keep[o] = true
+	}
+	for _, o := range prev {
+		if keep[o] {
+			continue
+		}
+		path := filepath.Join(outputDir, o)
+		if err := removeIfExists(path); err != nil {
+			return err
+		}
+		if err := removeIfExists(BinaryPath(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeIfExists removes a file and ignores files that do not exist
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Error removing %s", path)
+	}
+	return nil
+}
+
+// normalizedFileName derives the normalized csv file name from a source file name
+func normalizedFileName(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".csv.gz", ".zip", ".csv"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)] + ".csv"
+		}
+	}
+	return name + ".csv"
+}
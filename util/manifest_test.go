@@ -0,0 +1,233 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestImportIncremental(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	header := "underlying_symbol,quote_date,root,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code\n"
+	row1 := "^VIX,2016-06-01,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	row2 := "^VIX,2016-06-02,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+
+	source := filepath.Join(dir, "UnderlyingOptionsEODQuotes_2016-06-01.csv")
+	if err := ioutil.WriteFile(source, []byte(header+row1), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	outputDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating data dir"))
+	}
+	newImporter := func() Importer {
+		return NewLiveVolImporter(nil)
+	}
+	manifestPath := filepath.Join(outputDir, ManifestFileName)
+
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading missing manifest"))
+	}
+
+	// the first import adds the source
+	action, stats, err := ImportIncremental(m, newImporter, source, outputDir, false)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	if action != ImportAdded {
+		t.Errorf("Expected action to be %+v but got %+v", ImportAdded, action)
+	}
+	if stats.Rows != 1 {
+		t.Errorf("Expected 1 row but got %d", stats.Rows)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving manifest"))
+	}
+
+	// importing the same contents again is a no-op
+	m, err = LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading manifest"))
+	}
+	action, _, err = ImportIncremental(m, newImporter, source, outputDir, false)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	if action != ImportSkipped {
+		t.Errorf("Expected action to be %+v but got %+v", ImportSkipped, action)
+	}
+
+	// a changed source replaces the previous output
+	if err := ioutil.WriteFile(source, []byte(header+row1+row2), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	action, stats, err = ImportIncremental(m, newImporter, source, outputDir, false)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	if action != ImportReplaced {
+		t.Errorf("Expected action to be %+v but got %+v", ImportReplaced, action)
+	}
+	if len(m.Entries) != 1 {
+		t.Fatalf("Expected 1 manifest entry but got %d", len(m.Entries))
+	}
+	e := m.Entries[0]
+	if e.Rows != 2 || e.FirstQuoteDate != "2016-06-01" || e.LastQuoteDate != "2016-06-02" {
+		t.Errorf("Expected 2 rows from 2016-06-01 to 2016-06-02 but got %+v", e)
+	}
+	expected := filepath.Join("^VIX", "2016", "UnderlyingOptionsEODQuotes_2016-06-01.csv")
+	if len(e.Outputs) != 1 || e.Outputs[0] != expected {
+		t.Errorf("Expected output %+v but got %+v", expected, e.Outputs)
+	}
+
+	files, err := ioutil.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading data dir"))
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected the symbol partition and the manifest but got %d files", len(files))
+	}
+}
+
+func TestImportIncrementalLegacyOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	header := "underlying_symbol,quote_date,root,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code\n"
+	row1 := "^VIX,2016-06-01,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	row2 := "^VIX,2016-06-02,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	source := filepath.Join(dir, "UnderlyingOptionsEODQuotes_2016-06-01.csv")
+	if err := ioutil.WriteFile(source, []byte(header+row1+row2), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	outputDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating data dir"))
+	}
+
+	// a manifest and output written before imports were partitioned
+	legacy := "UnderlyingOptionsEODQuotes_2016-06-01.csv"
+	if err := ioutil.WriteFile(filepath.Join(outputDir, legacy), []byte(header+row1), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing legacy output"))
+	}
+	manifestPath := filepath.Join(outputDir, ManifestFileName)
+	manifest := `{"entries": [{"source": "UnderlyingOptionsEODQuotes_2016-06-01.csv", "size": 1, "checksum": "old", "first_quote_date": "2016-06-01", "last_quote_date": "2016-06-01", "output": "` + legacy + `", "rows": 1}]}`
+	if err := ioutil.WriteFile(manifestPath, []byte(manifest), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing legacy manifest"))
+	}
+
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading legacy manifest"))
+	}
+	if len(m.Entries) != 1 || len(m.Entries[0].Outputs) != 1 || m.Entries[0].Outputs[0] != legacy {
+		t.Fatalf("Expected the legacy output %+v but got %+v", legacy, m.Entries)
+	}
+	action, _, err := ImportIncremental(m, func() Importer { return NewLiveVolImporter(nil) }, source, outputDir, false)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error importing"))
+	}
+	if action != ImportReplaced {
+		t.Errorf("Expected action to be %+v but got %+v", ImportReplaced, action)
+	}
+
+	// only the partitioned output is left to load
+	files, err := PartitionFiles(outputDir, "", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error listing partition files"))
+	}
+	expected := filepath.Join(outputDir, "^VIX", "2016", legacy)
+	if len(files) != 1 || files[0] != expected {
+		t.Errorf("Expected files %+v but got %+v", []string{expected}, files)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(errors.Wrap(err, "Error saving manifest"))
+	}
+	b, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading manifest"))
+	}
+	if strings.Contains(string(b), `"output"`) {
+		t.Errorf("Expected the saved manifest to only have outputs but got %s", b)
+	}
+}
+
+func TestImportIncrementalOverlap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	header := "underlying_symbol,quote_date,root,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_1545,bid_1545,ask_size_1545,ask_1545,underlying_bid_1545,underlying_ask_1545,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code\n"
+	vix1 := "^VIX,2016-06-01,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	vix2 := "^VIX,2016-06-02,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	vix3 := "^VIX,2016-06-03,VIX,2016-06-08,14,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,14.24,14.24,10,0.25,271,0.3,14.2,14.2,0.2455,7302,\n"
+	spy2 := "SPY,2016-06-02,SPY,2016-06-08,210,P,0.1,0.25,0.1,0.25,623,2845,0.2,1,0.35,210,210,10,0.25,271,0.3,210,210,0.2455,7302,\n"
+	outputDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating data dir"))
+	}
+	newImporter := func() Importer {
+		return NewLiveVolImporter(nil)
+	}
+	m, err := LoadManifest(filepath.Join(outputDir, ManifestFileName))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error loading missing manifest"))
+	}
+
+	tt := []struct {
+		name   string
+		rows   string
+		force  bool
+		action ImportAction
+		err    bool
+	}{
+		{name: "vix_a.csv", rows: vix1 + vix2, action: ImportAdded},
+		// the same symbol on a date already imported
+		{name: "vix_b.csv", rows: vix2 + vix3, err: true},
+		{name: "vix_c.csv", rows: vix3, action: ImportAdded},
+		// another symbol on the same dates
+		{name: "spy.csv", rows: spy2, action: ImportAdded},
+		{name: "vix_b.csv", rows: vix2 + vix3, force: true, action: ImportAdded},
+	}
+	for idx, tab := range tt {
+		source := filepath.Join(dir, tab.name)
+		if err := ioutil.WriteFile(source, []byte(header+tab.rows), 0666); err != nil {
+			t.Fatal(errors.Wrap(err, "Error writing source"))
+		}
+		action, _, err := ImportIncremental(m, newImporter, source, outputDir, tab.force)
+		if (err != nil) != tab.err {
+			t.Fatalf("Expected error %+v but got %+v at idx: %d", tab.err, err, idx)
+		}
+		if tab.err {
+			if overlap, ok := errors.Cause(err).(*OverlapError); !ok || overlap.Source != tab.name || len(overlap.Overlaps) == 0 {
+				t.Errorf("Expected an overlap error of %+v but got %+v at idx: %d", tab.name, err, idx)
+			}
+			if _, err := os.Stat(filepath.Join(outputDir, "^VIX", "2016", tab.name)); !os.IsNotExist(err) {
+				t.Errorf("Expected no output for a refused import but got %+v at idx: %d", err, idx)
+			}
+			continue
+		}
+		if action != tab.action {
+			t.Errorf("Expected action to be %+v but got %+v at idx: %d", tab.action, action, idx)
+		}
+	}
+	if len(m.Entries) != 4 {
+		t.Errorf("Expected 4 manifest entries but got %d", len(m.Entries))
+	}
+}
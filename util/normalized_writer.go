@@ -0,0 +1,121 @@
+package util
+
+import (
+	"backtest-options/model"
+	"encoding/csv"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// NormalizedWriter writes OHLCVs as rows of a normalized csv file
+type NormalizedWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewNormalizedWriter creates a writer of normalized rows
+func NewNormalizedWriter(w io.Writer) *NormalizedWriter {
+	return &NormalizedWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes a row, and the header before the first row
+func (n *NormalizedWriter) Write(o model.OHLCV) error {
+	if !n.headerWritten {
+		if err := n.w.Write(normalizedHeader); err != nil {
+			return errors.Wrap(err, "Error writing normalized header")
+		}
+		n.headerWritten = true
+	}
+	values := make([]string, len(normalizedHeader))
+	values[csvStdUndSym] = o.UndSym
+	values[csvStdQuoteDate] = o.QuoteDate.Format(model.DateLayout)
+	values[csvStdExp] = o.Expiration.Format(model.DateLayout)
+	values[csvStdStrike] = o.Strike.String()
+	values[csvStdOptType] = "C"
+	if o.Type == model.Put {
+		values[csvStdOptType] = "P"
+	}
+	values[csvStdDelivCode] = o.DeliveryCode
+	values[csvStdRoot] = o.Root
+	decimals := []struct {
+		col int
+		s   string
+	}{
+		{csvStdOpen, o.Open.String()},
+		{csvStdHigh, o.High.String()},
+		{csvStdLow, o.Low.String()},
+		{csvStdClose, o.Close.String()},
+		{csvStdVol, o.Volume.String()},
+		{csvStdBidSize, o.BidSize.String()},
+		{csvStdBid, o.Bid.String()},
+		{csvStdAskSize, o.AskSize.String()},
+		{csvStdAsk, o.Ask.String()},
+		{csvStdUndBid, o.UndBid.String()},
+		{csvStdUndAsk, o.UndAsk.String()},
+		{csvStdVwap, o.Vwap.String()},
+		{csvStdOpenInterest, o.OpenInterest.String()},
+		{csvStdBidSize1545, o.BidSize1545.String()},
+		{csvStdBid1545, o.Bid1545.String()},
+		{csvStdAskSize1545, o.AskSize1545.String()},
+		{csvStdAsk1545, o.Ask1545.String()},
+		{csvStdUndBid1545, o.UndBid1545.String()},
+		{csvStdUndAsk1545, o.UndAsk1545.String()},
+		{csvStdMultiplier, o.Multiplier.String()},
+	}
+	for _, d := range decimals {
+		values[d.col] = d.s
+	}
+	if o.HasGreeks {
+		values[csvStdIV] = o.IV.String()
+		values[csvStdDelta] = o.Delta.String()
+		values[csvStdGamma] = o.Gamma.String()
+		values[csvStdTheta] = o.Theta.String()
+		values[csvStdVega] = o.Vega.String()
+		values[csvStdRho] = o.Rho.String()
+	}
+	if err := n.w.Write(values); err != nil {
+		return errors.Wrap(err, "Error writing normalized row")
+	}
+	return nil
+}
+
+// Flush writes buffered rows to the underlying writer
+func (n *NormalizedWriter) Flush() error {
+	n.w.Flush()
+	if err := n.w.Error(); err != nil {
+		return errors.Wrap(err, "Error flushing normalized rows")
+	}
+	return nil
+}
+
+// normalizedMapper maps rows of normalized files, e.g. generated data, by column name
+type normalizedMapper struct {
+	cols     csvColumns
+	required int
+}
+
+// NewNormalizedImporter is an importer of files that are already normalized
+func NewNormalizedImporter(w *csv.Writer) Importer {
+	return &csvImporter{
+		writer: w,
+		mapper: &normalizedMapper{},
+	}
+}
+
+func (m *normalizedMapper) setHeader(header []string) error {
+	m.cols = newCSVColumns(header)
+	m.required = m.cols.required()
+	return nil
+}
+
+func (m *normalizedMapper) mapRow(field []string) ([]string, bool) {
+	if len(field) < m.required {
+		return nil, false
+	}
+	values := make([]string, len(normalizedHeader))
+	for col := range values {
+		values[col] = m.cols.get(field, col)
+	}
+	return values, true
+}
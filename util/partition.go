@@ -0,0 +1,163 @@
+package util
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PartitionDir returns the directory holding normalized rows of the symbol quoted in the year. Each directory holds one file per imported source.
+func PartitionDir(dataDir, symbol string, year int) string {
+	return filepath.Join(dataDir, partitionSymbol(symbol), strconv.Itoa(year))
+}
+
+// partitionSymbol makes a symbol safe to use as a directory name
+func partitionSymbol(symbol string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(symbol)
+}
+
+// partitionFile splits a normalized file into symbol and year partitions under dataDir. Every partition gets a file called name. It returns the written files relative to dataDir.
+func partitionFile(src, dataDir, name string) ([]string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error opening %s", src)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading header of %s", src)
+	}
+	cols := newCSVColumns(header)
+
+	type partition struct {
+		file   *os.File
+		writer *csv.Writer
+		path   string
+	}
+	partitions := make(map[string]*partition)
+	defer func() {
+		for _, p := range partitions {
+			p.file.Close()
+		}
+	}()
+
+	for row := 1; ; row++ {
+		field, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading row %d of %s", row+1, src)
+		}
+		quoteDate := cols.get(field, csvStdQuoteDate)
+		if len(quoteDate) < 4 {
+			return nil, errors.Errorf("Invalid quote date %+v at row %d of %s", quoteDate, row+1, src)
+		}
+		year, err := strconv.Atoi(quoteDate[:4])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid quote date %+v at row %d of %s", quoteDate, row+1, src)
+		}
+		dir := PartitionDir(dataDir, cols.get(field, csvStdUndSym), year)
+		p, ok := partitions[dir]
+		if !ok {
+			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+				return nil, errors.Wrapf(err, "Error making dir %s", dir)
+			}
+			path := filepath.Join(dir, name)
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error opening %s", path)
+			}
+			p = &partition{
+				file:   file,
+				writer: csv.NewWriter(file),
+				path:   path,
+			}
+			p.writer.Write(header)
+			partitions[dir] = p
+		}
+		p.writer.Write(field)
+	}
+
+	outputs := make([]string, 0, len(partitions))
+	for _, p := range partitions {
+		p.writer.Flush()
+		if err := p.writer.Error(); err != nil {
+			return nil, errors.Wrapf(err, "Error writing %s", p.path)
+		}
+		rel, err := filepath.Rel(dataDir, p.path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error resolving %s", p.path)
+		}
+		outputs = append(outputs, rel)
+	}
+	sort.Strings(outputs)
+	return outputs, nil
+}
+
+// PartitionFiles returns the normalized files in dataDir needed for the symbol between start and end. An empty symbol selects every symbol, and a zero start or end leaves that side of the range open. Files directly in dataDir, written before data was partitioned, are always included.
+func PartitionFiles(dataDir, symbol string, start, end time.Time) ([]string, error) {
+	files := make([]string, 0)
+	entries, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading dir: %+v", dataDir)
+	}
+
+	symbols := make([]string, 0)
+	for _, e := range entries {
+		if !e.IsDir() {
+			if filepath.Ext(e.Name()) == ".csv" {
+				files = append(files, filepath.Join(dataDir, e.Name()))
+			}
+			continue
+		}
+		if symbol == "" || e.Name() == partitionSymbol(symbol) {
+			symbols = append(symbols, e.Name())
+		}
+	}
+
+	for _, sym := range symbols {
+		years, err := ioutil.ReadDir(filepath.Join(dataDir, sym))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading dir: %+v", sym)
+		}
+		for _, y := range years {
+			year, err := strconv.Atoi(y.Name())
+			if !y.IsDir() || err != nil {
+				continue
+			}
+			if !start.IsZero() && year < start.Year() {
+				continue
+			}
+			if !end.IsZero() && year > end.Year() {
+				continue
+			}
+			dir := filepath.Join(dataDir, sym, y.Name())
+			parts, err := ioutil.ReadDir(dir)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error reading dir: %+v", dir)
+			}
+			for _, p := range parts {
+				if !p.IsDir() && filepath.Ext(p.Name()) == ".csv" {
+					files = append(files, filepath.Join(dir, p.Name()))
+				}
+			}
+		}
+	}
+	return files, nil
+}
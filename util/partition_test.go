@@ -0,0 +1,96 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backtest-options/model"
+
+	"github.com/pkg/errors"
+)
+
+func TestPartitionFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "partition")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error creating temp dir"))
+	}
+	defer os.RemoveAll(dir)
+
+	header := strings.Join(normalizedHeader, ",")
+	s := header + "\n" +
+		"SPX,2019-12-31,2020-01-17,3200,C,1,1,1,1,1,1,1,1,1,3230,3230\n" +
+		"SPX,2020-01-02,2020-01-17,3200,C,1,1,1,1,1,1,1,1,1,3250,3250\n" +
+		"^VIX,2020-01-02,2020-01-22,14,P,1,1,1,1,1,1,1,1,1,12.5,12.5\n"
+	src := filepath.Join(dir, "src.csv")
+	if err := ioutil.WriteFile(src, []byte(s), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing source"))
+	}
+	dataDir := filepath.Join(dir, "data")
+	if err := ioutil.WriteFile(filepath.Join(dir, "legacy.csv"), []byte(header+"\n"), 0666); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing legacy file"))
+	}
+
+	outputs, err := partitionFile(src, dataDir, "out.csv")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error partitioning"))
+	}
+	expOutputs := []string{
+		filepath.Join("SPX", "2019", "out.csv"),
+		filepath.Join("SPX", "2020", "out.csv"),
+		filepath.Join("^VIX", "2020", "out.csv"),
+	}
+	if len(outputs) != len(expOutputs) {
+		t.Fatalf("Expected %d outputs but got %+v", len(expOutputs), outputs)
+	}
+	for idx, o := range outputs {
+		if o != expOutputs[idx] {
+			t.Errorf("Expected output to be %+v but got %+v at idx: %d", expOutputs[idx], o, idx)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dataDir, expOutputs[1]))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading partition"))
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || lines[0] != header {
+		t.Errorf("Expected the header and 1 row but got %+v", lines)
+	}
+
+	// legacy files directly in the data dir are always included
+	if err := os.Rename(filepath.Join(dir, "legacy.csv"), filepath.Join(dataDir, "legacy.csv")); err != nil {
+		t.Fatal(errors.Wrap(err, "Error moving legacy file"))
+	}
+	jan2020, _ := time.Parse(model.DateLayout, "2020-01-01")
+	tests := []struct {
+		symbol string
+		start  time.Time
+		end    time.Time
+		files  []string
+	}{
+		{"", time.Time{}, time.Time{}, []string{"legacy.csv", expOutputs[0], expOutputs[1], expOutputs[2]}},
+		{"SPX", time.Time{}, time.Time{}, []string{"legacy.csv", expOutputs[0], expOutputs[1]}},
+		{"SPX", jan2020, time.Time{}, []string{"legacy.csv", expOutputs[1]}},
+		{"", time.Time{}, jan2020.AddDate(0, 0, -1), []string{"legacy.csv", expOutputs[0]}},
+		{"NDX", time.Time{}, time.Time{}, []string{"legacy.csv"}},
+	}
+	for idx, test := range tests {
+		files, err := PartitionFiles(dataDir, test.symbol, test.start, test.end)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "Error listing partitions"))
+		}
+		if len(files) != len(test.files) {
+			t.Errorf("Expected files to be %+v but got %+v at idx: %d", test.files, files, idx)
+			continue
+		}
+		for i, f := range files {
+			if f != filepath.Join(dataDir, test.files[i]) {
+				t.Errorf("Expected file to be %+v but got %+v at idx: %d", test.files[i], f, idx)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"backtest-options/model"
 	"encoding/csv"
+	"io"
 	"time"
 
 	"github.com/pkg/errors"
@@ -28,76 +29,218 @@ const (
 	csvStdVwap         = 16
 	csvStdOpenInterest = 17
 	csvStdDelivCode    = 18
+	csvStdRoot         = 19
+	csvStdBidSize1545  = 20
+	csvStdBid1545      = 21
+	csvStdAskSize1545  = 22
+	csvStdAsk1545      = 23
+	csvStdUndBid1545   = 24
+	csvStdUndAsk1545   = 25
+	csvStdIV           = 26
+	csvStdDelta        = 27
+	csvStdGamma        = 28
+	csvStdTheta        = 29
+	csvStdVega         = 30
+	csvStdRho          = 31
+	csvStdMultiplier   = 32
 )
 
 // MyReader is a reader interface
 type MyReader interface {
 	ReadNormalizedCSVFile(r *csv.Reader) ([]model.OHLCV, error)
+	StreamNormalizedCSVFile(r *csv.Reader, fn func(model.OHLCV) error) error
 }
 
 type fr struct{}
 
-func (fr *fr) ReadNormalizedCSVFile(r *csv.Reader) ([]model.OHLCV, error) {
+// csvColumns maps a normalized column (one of the csvStd constants) to its position in a file. Columns missing from the file map to -1.
+type csvColumns []int
+
+// newCSVColumns resolves column positions from the header row. Files written before a column was added to the normalized schema simply lack it, so optional columns resolve to -1. A header that does not look like a normalized header falls back to the standard positions.
+func newCSVColumns(header []string) csvColumns {
+	cols := make(csvColumns, len(normalizedHeader))
+	byName := make(map[string]int)
+	for i, name := range header {
+		byName[trimBOM(name)] = i
+	}
+	if _, ok := byName[normalizedHeader[csvStdQuoteDate]]; !ok {
+		for i := range cols {
+			cols[i] = i
+		}
+		return cols
+	}
+	for i, name := range normalizedHeader {
+		idx, ok := byName[name]
+		if !ok {
+			idx = -1
+		}
+		cols[i] = idx
+	}
+	return cols
+}
+
+// get returns the value of a normalized column, or an empty string if the file does not have it
+func (c csvColumns) get(field []string, col int) string {
+	idx := c[col]
+	if idx < 0 || idx >= len(field) {
+		return ""
+	}
+	return field[idx]
+}
 
+// required returns the number of fields a row needs to hold every required column
+func (c csvColumns) required() int {
+	n := 0
+	for col := csvStdUndSym; col <= csvStdUndAsk; col++ {
+		if c[col]+1 > n {
+			n = c[col] + 1
+		}
+	}
+	return n
+}
+
+// ReadNormalizedCSVFile reads every row of a normalized file into memory
+func (fr *fr) ReadNormalizedCSVFile(r *csv.Reader) ([]model.OHLCV, error) {
 	ohlcvs := make([]model.OHLCV, 0)
+	err := fr.StreamNormalizedCSVFile(r, func(ohlcv model.OHLCV) error {
+		ohlcvs = append(ohlcvs, ohlcv)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ohlcvs, nil
+}
 
-	fields, err := r.ReadAll()
+// StreamNormalizedCSVFile reads a normalized file one row at a time and calls fn with each row. Reading stops at the first error returned by fn
+func (fr *fr) StreamNormalizedCSVFile(r *csv.Reader, fn func(model.OHLCV) error) error {
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, "Error reading all file values")
+		return errors.Wrap(err, "Error reading header")
 	}
-	for row, field := range fields {
-		if row == 0 {
-			continue
-		}
-		if len(field) < csvStdUndAsk+1 {
-			return nil, errors.Errorf("Expected at least %+v rows but got %+v on row: %d",
-				csvStdUndAsk+1,
-				len(field),
-				row+1)
+	cols := newCSVColumns(header)
+	for col := csvStdUndSym; col <= csvStdUndAsk; col++ {
+		if cols[col] < 0 {
+			return errors.Errorf("Expected column %+v in header", normalizedHeader[col])
 		}
+	}
+	required := cols.required()
 
-		optType := field[csvStdOptType]
-		var typ model.OptType
-		if optType == "C" {
-			typ = model.Call
-		} else if optType == "P" {
-			typ = model.Put
+	for row := 1; ; row++ {
+		field, err := r.Read()
+		if err == io.EOF {
+			break
 		}
-		quoteDate := field[csvStdQuoteDate]
-		quoteTime, err := time.Parse(model.DateLayout, quoteDate)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Error parsing quote date %+v at row: %d", quoteDate, row+1)
+			return errors.Wrapf(err, "Error reading row: %d", row+1)
 		}
-		expDate := field[csvStdExp]
-		expTime, err := time.Parse(model.DateLayout, expDate)
+		ohlcv, err := cols.parse(field, required, row)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Error parsing exp date %+v at row: %d", expDate, row+1)
+			return err
 		}
-		ohlcv, err := model.NewOHLCV(
-			quoteTime,
-			field[csvStdUndSym],
-			expTime,
-			field[csvStdStrike],
-			typ,
-			field[csvStdOpen],
-			field[csvStdHigh],
-			field[csvStdLow],
-			field[csvStdClose],
-			field[csvStdVol],
-			field[csvStdAsk],
-			field[csvStdBid],
-			field[csvStdUndAsk],
-			field[csvStdUndBid],
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "Error converting into OHLCV")
+		if err := fn(ohlcv); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		ohlcvs = append(ohlcvs, ohlcv)
+// parse converts a normalized row into an OHLCV
+func (cols csvColumns) parse(field []string, required int, row int) (model.OHLCV, error) {
+	if len(field) < required {
+		return model.OHLCV{}, errors.Errorf("Expected at least %+v rows but got %+v on row: %d",
+			required,
+			len(field),
+			row+1)
+	}
 
+	optType := cols.get(field, csvStdOptType)
+	var typ model.OptType
+	if optType == "C" {
+		typ = model.Call
+	} else if optType == "P" {
+		typ = model.Put
+	}
+	quoteDate := cols.get(field, csvStdQuoteDate)
+	quoteTime, err := time.Parse(model.DateLayout, quoteDate)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error parsing quote date %+v at row: %d", quoteDate, row+1)
+	}
+	expDate := cols.get(field, csvStdExp)
+	expTime, err := time.Parse(model.DateLayout, expDate)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error parsing exp date %+v at row: %d", expDate, row+1)
+	}
+	ohlcv, err := model.NewOHLCV(
+		quoteTime,
+		cols.get(field, csvStdUndSym),
+		expTime,
+		cols.get(field, csvStdStrike),
+		typ,
+		cols.get(field, csvStdOpen),
+		cols.get(field, csvStdHigh),
+		cols.get(field, csvStdLow),
+		cols.get(field, csvStdClose),
+		cols.get(field, csvStdVol),
+		cols.get(field, csvStdAsk),
+		cols.get(field, csvStdBid),
+		cols.get(field, csvStdUndAsk),
+		cols.get(field, csvStdUndBid),
+	)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrap(err, "Error converting into OHLCV")
 	}
+	ohlcv, err = ohlcv.WithDetail(
+		cols.get(field, csvStdRoot),
+		cols.get(field, csvStdBidSize),
+		cols.get(field, csvStdAskSize),
+		cols.get(field, csvStdVwap),
+		cols.get(field, csvStdOpenInterest),
+		cols.get(field, csvStdDelivCode),
+	)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error converting details at row: %d", row+1)
+	}
+	ohlcv, err = ohlcv.WithSnapshot1545(
+		cols.get(field, csvStdBidSize1545),
+		cols.get(field, csvStdBid1545),
+		cols.get(field, csvStdAskSize1545),
+		cols.get(field, csvStdAsk1545),
+		cols.get(field, csvStdUndBid1545),
+		cols.get(field, csvStdUndAsk1545),
+	)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error converting 15:45 snapshot at row: %d", row+1)
+	}
+	ohlcv, err = ohlcv.WithGreeks(
+		cols.get(field, csvStdIV),
+		cols.get(field, csvStdDelta),
+		cols.get(field, csvStdGamma),
+		cols.get(field, csvStdTheta),
+		cols.get(field, csvStdVega),
+		cols.get(field, csvStdRho),
+	)
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error converting greeks at row: %d", row+1)
+	}
+	ohlcv, err = ohlcv.WithMultiplier(cols.get(field, csvStdMultiplier))
+	if err != nil {
+		return model.OHLCV{}, errors.Wrapf(err, "Error converting multiplier at row: %d", row+1)
+	}
+	return ohlcv, nil
+}
 
-	return ohlcvs, nil
+// trimBOM removes a leading utf-8 byte order mark, which LiveVol files start with
+func trimBOM(s string) string {
+	if len(s) >= 3 && s[0] == 0xEF && s[1] == 0xBB && s[2] == 0xBF {
+		return s[3:]
+	}
+	return s
 }
 
 // NewFileReader generates MyReader
@@ -73,3 +73,117 @@ SPY,2005-01-10,2005-01-22,130.000,C,1.0000,2.0000,0.0000,1.0000,1200,15,0.900,19
 		}
 	}
 }
+
+func TestReadFileDetail(t *testing.T) {
+	tt := []struct {
+		csv  string
+		root string
+		oi   string
+	}{
+		{
+			// files written before root was part of the normalized schema
+			csv: `underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code
+^SPX,2016-06-01,2016-06-03,2100,C,1,1,1,1,10,15,0.9,195,1.1,2099,2100,1.05,3500,`,
+			root: "",
+			oi:   "3500",
+		},
+		{
+			csv: `underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code,root
+^SPX,2016-06-01,2016-06-03,2100,C,1,1,1,1,10,15,0.9,195,1.1,2099,2100,1.05,3500,,SPXW`,
+			root: "SPXW",
+			oi:   "3500",
+		},
+	}
+
+	for idx, tab := range tt {
+		r := csv.NewReader(strings.NewReader(tab.csv))
+		data, err := NewFileReader().ReadNormalizedCSVFile(r)
+		if err != nil {
+			t.Fatal(errors.Wrapf(err, "Error reading file at idx: %d", idx))
+		}
+		if len(data) != 1 {
+			t.Fatalf("Expected 1 row but got %d at idx: %d", len(data), idx)
+		}
+		d := data[0]
+		if d.Root != tab.root {
+			t.Errorf("Expected root to be %+v but got %+v at idx: %d", tab.root, d.Root, idx)
+		}
+		if d.OpenInterest.String() != tab.oi {
+			t.Errorf("Expected open interest to be %+v but got %+v at idx: %d", tab.oi, d.OpenInterest, idx)
+		}
+		if d.BidSize.String() != "15" || d.AskSize.String() != "195" {
+			t.Errorf("Expected sizes to be 15 x 195 but got %+v x %+v at idx: %d", d.BidSize, d.AskSize, idx)
+		}
+		if d.Vwap.String() != "1.05" {
+			t.Errorf("Expected vwap to be 1.05 but got %+v at idx: %d", d.Vwap, idx)
+		}
+		if d.DeliveryCode != "" {
+			t.Errorf("Expected empty delivery code but got %+v at idx: %d", d.DeliveryCode, idx)
+		}
+	}
+}
+
+func TestStreamFile(t *testing.T) {
+	s := `underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod
+SPY,2005-01-10,2005-01-22,130,C,1,2,0,1,1200,15,0.9,195,1.1,118.94,118.95
+SPY,2005-01-10,2005-01-22,131,C,1,2,0,1,1200,15,0.9,195,1.1,118.94,118.95
+SPY,2005-01-10,2005-01-22,132,C,1,2,0,1,1200,15,0.9,195,1.1,118.94,118.95`
+
+	strikes := make([]string, 0)
+	err := NewFileReader().StreamNormalizedCSVFile(csv.NewReader(strings.NewReader(s)), func(o model.OHLCV) error {
+		strikes = append(strikes, o.Strike.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error streaming file"))
+	}
+	if strings.Join(strikes, ",") != "130,131,132" {
+		t.Errorf("Expected strikes 130,131,132 but got %+v", strikes)
+	}
+
+	// an error from the callback stops reading
+	calls := 0
+	err = NewFileReader().StreamNormalizedCSVFile(csv.NewReader(strings.NewReader(s)), func(o model.OHLCV) error {
+		calls++
+		return errors.New("stop")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected streaming to stop after the first row but got %d calls and error %+v", calls, err)
+	}
+}
+
+func TestReadFileLegacyAndRootedChain(t *testing.T) {
+	legacy := `underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod
+SPY,2016-06-01,2016-07-15,210,C,1,1,1,1,10,15,0.9,195,1.1,209.5,210.5`
+	rooted := `underlying_symbol,quote_date,expiration,strike,option_type,open,high,low,close,trade_volume,bid_size_eod,bid_eod,ask_size_eod,ask_eod,underlying_bid_eod,underlying_ask_eod,vwap,open_interest,delivery_code,root
+SPY,2016-06-02,2016-07-15,210,C,1,1,1,1,10,15,1.9,195,2.1,209.5,210.5,1.05,3500,,SPY`
+
+	b := model.NewOptionChainBuilder()
+	for idx, s := range []string{legacy, rooted} {
+		if err := NewFileReader().StreamNormalizedCSVFile(csv.NewReader(strings.NewReader(s)), func(o model.OHLCV) error {
+			b.Add(o)
+			return nil
+		}); err != nil {
+			t.Fatal(errors.Wrapf(err, "Error streaming file at idx: %d", idx))
+		}
+	}
+	set, err := b.BuildSet()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Error building option chain set"))
+	}
+	if keys := set.Keys(); len(keys) != 1 || keys[0] != model.NewChainKey("SPY", "SPY") {
+		t.Fatalf("Expected the single chain SPY but got %+v", keys)
+	}
+	for _, root := range []string{"", "SPY"} {
+		chain, err := set.Select("SPY", root)
+		if err != nil {
+			t.Fatal(errors.Wrapf(err, "Error selecting SPY with root %+v", root))
+		}
+		for _, d := range []string{"2016-06-01", "2016-06-02"} {
+			quote, _ := time.Parse(model.DateLayout, d)
+			if chain.GetOptionChainForQuoteDate(quote, true) == nil {
+				t.Errorf("Expected quotes on %+v with root %+v", d, root)
+			}
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package util
+
+import (
+	"backtest-options/calendar"
+	"backtest-options/model"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// IssueKind is a kind of data quality problem
+type IssueKind string
+
+const (
+	// IssueCrossedQuote is a row whose bid is higher than its ask
+	IssueCrossedQuote IssueKind = "crossed_quote"
+	// IssueZeroQuote is a row whose bid and ask are both zero
+	IssueZeroQuote IssueKind = "zero_quote"
+	// IssueDuplicateRow is a contract quoted more than once on the same day
+	IssueDuplicateRow IssueKind = "duplicate_row"
+	// IssueOneSidedStrike is a strike that has only a call or only a put
+	IssueOneSidedStrike IssueKind = "one_sided_strike"
+	// IssueVanishedExpiry is an expiration that disappears before it expires
+	IssueVanishedExpiry IssueKind = "vanished_expiry"
+	// IssueMissingDay is a trading day without any quotes between two quoted days. Exchange holidays are not reported
+	IssueMissingDay IssueKind = "missing_day"
+	// IssueUnderlyingJump is an underlying price change between two quoted days larger than the allowed jump
+	IssueUnderlyingJump IssueKind = "underlying_jump"
+)
+
+// issueKinds lists every kind in the order they are reported
+var issueKinds = []IssueKind{
+	IssueCrossedQuote,
+	IssueZeroQuote,
+	IssueDuplicateRow,
+	IssueOneSidedStrike,
+	IssueVanishedExpiry,
+	IssueMissingDay,
+	IssueUnderlyingJump,
+}
+
+// Issue is a data quality problem found by the Validator
+type Issue struct {
+	Kind       IssueKind `json:"kind"`
+	Symbol     string    `json:"symbol"`
+	Root       string    `json:"root,omitempty"`
+	QuoteDate  string    `json:"quote_date"`
+	Expiration string    `json:"expiration,omitempty"`
+	Strike     string    `json:"strike,omitempty"`
+	Type       string    `json:"type,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+}
+
+// ValidationReport is the result of validating option data
+type ValidationReport struct {
+	Rows   int               `json:"rows"`
+	Counts map[IssueKind]int `json:"counts"`
+	Issues []Issue           `json:"issues"`
+}
+
+// RowIssue returns the problem of a single row, if any. These are the problems that the loader can quarantine without looking at other rows.
+func RowIssue(o model.OHLCV) (IssueKind, bool) {
+	if o.Bid.IsZero() && o.Ask.IsZero() {
+		return IssueZeroQuote, true
+	}
+	if o.Bid.GreaterThan(o.Ask) {
+		return IssueCrossedQuote, true
+	}
+	return "", false
+}
+
+// contractKey identifies a contract on a quote date within a chain
+type contractKey struct {
+	exp    time.Time
+	strike string
+	typ    model.OptType
+}
+
+// validatorDay holds what the validator saw on one quote date of one chain
+type validatorDay struct {
+	undPx     decimal.Decimal
+	contracts map[contractKey]bool
+}
+
+// Validator checks option rows for data quality problems. Rows may be added in any order.
+type Validator struct {
+	cal     *calendar.Calendar
+	maxJump decimal.Decimal
+	rows    int
+	issues  []Issue
+	days    map[model.ChainKey]map[time.Time]*validatorDay
+}
+
+// NewValidator creates a validator. maxJump is the largest day over day underlying price change, as a fraction, that is not reported. cal is the trading calendar used to tell missing days from holidays. If it is nil, every weekday is a trading day.
+func NewValidator(maxJump decimal.Decimal, cal *calendar.Calendar) *Validator {
+	return &Validator{
+		cal:     cal,
+		maxJump: maxJump,
+		issues:  make([]Issue, 0),
+		days:    make(map[model.ChainKey]map[time.Time]*validatorDay),
+	}
+}
+
+// isTradingDay returns true if quotes are expected on t
+func (v *Validator) isTradingDay(t time.Time) bool {
+	if v.cal != nil {
+		return v.cal.IsTradingDay(t)
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// Add checks a row
+func (v *Validator) Add(o model.OHLCV) {
+	v.rows++
+	key := model.NewChainKey(o.UndSym, o.Root)
+	if kind, ok := RowIssue(o); ok {
+		v.issues = append(v.issues, newIssue(kind, key, o.QuoteDate, o.Expiration, o.Strike.String(), string(o.Type),
+			fmt.Sprintf("bid %s ask %s", o.Bid, o.Ask)))
+	}
+
+	days, ok := v.days[key]
+	if !ok {
+		days = make(map[time.Time]*validatorDay)
+		v.days[key] = days
+	}
+	day, ok := days[o.QuoteDate]
+	if !ok {
+		day = &validatorDay{
+			contracts: make(map[contractKey]bool),
+		}
+		days[o.QuoteDate] = day
+	}
+	if day.undPx.IsZero() && !o.UndBid.IsZero() && !o.UndAsk.IsZero() {
+		day.undPx = o.UndBid.Add(o.UndAsk).Div(decimal.NewFromInt(2))
+	}
+	ck := contractKey{exp: o.Expiration, strike: o.Strike.String(), typ: o.Type}
+	if day.contracts[ck] {
+		v.issues = append(v.issues, newIssue(IssueDuplicateRow, key, o.QuoteDate, o.Expiration, ck.strike, string(o.Type), ""))
+	}
+	day.contracts[ck] = true
+}
+
+// Report checks what needs more than one row and returns every problem found
+func (v *Validator) Report() *ValidationReport {
+	issues := append([]Issue{}, v.issues...)
+	for key, days := range v.days {
+		dates := make([]time.Time, 0, len(days))
+		for d := range days {
+			dates = append(dates, d)
+		}
+		sort.Slice(dates, func(i, j int) bool {
+			return dates[i].Before(dates[j])
+		})
+		for i, d := range dates {
+			day := days[d]
+			issues = append(issues, oneSidedStrikes(key, d, day)...)
+			if i == 0 {
+				continue
+			}
+			prevDate := dates[i-1]
+			prev := days[prevDate]
+			for missing := prevDate.AddDate(0, 0, 1); missing.Before(d); missing = missing.AddDate(0, 0, 1) {
+				if v.isTradingDay(missing) {
+					issues = append(issues, newIssue(IssueMissingDay, key, missing, time.Time{}, "", "", ""))
+				}
+			}
+			issues = append(issues, vanishedExpiries(key, d, prev, day)...)
+			if !prev.undPx.IsZero() && !day.undPx.IsZero() {
+				change := day.undPx.Div(prev.undPx).Sub(decimal.NewFromInt(1))
+				if change.Abs().GreaterThan(v.maxJump) {
+					issues = append(issues, newIssue(IssueUnderlyingJump, key, d, time.Time{}, "", "",
+						fmt.Sprintf("%s to %s (%s%%)", prev.undPx, day.undPx, change.Mul(decimal.NewFromInt(100)).StringFixed(2))))
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Root != b.Root {
+			return a.Root < b.Root
+		}
+		if a.QuoteDate != b.QuoteDate {
+			return a.QuoteDate < b.QuoteDate
+		}
+		return kindOrder(a.Kind) < kindOrder(b.Kind)
+	})
+	counts := make(map[IssueKind]int)
+	for _, k := range issueKinds {
+		counts[k] = 0
+	}
+	for _, i := range issues {
+		counts[i.Kind]++
+	}
+	return &ValidationReport{
+		Rows:   v.rows,
+		Counts: counts,
+		Issues: issues,
+	}
+}
+
+// oneSidedStrikes reports strikes of a quote date with only a call or only a put
+func oneSidedStrikes(key model.ChainKey, d time.Time, day *validatorDay) []Issue {
+	issues := make([]Issue, 0)
+	for ck := range day.contracts {
+		other := contractKey{exp: ck.exp, strike: ck.strike, typ: model.Put}
+		if ck.typ == model.Put {
+			other.typ = model.Call
+		}
+		if !day.contracts[other] {
+			issues = append(issues, newIssue(IssueOneSidedStrike, key, d, ck.exp, ck.strike, string(ck.typ), "missing "+string(other.typ)))
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Expiration != issues[j].Expiration {
+			return issues[i].Expiration < issues[j].Expiration
+		}
+		return decimal.RequireFromString(issues[i].Strike).LessThan(decimal.RequireFromString(issues[j].Strike))
+	})
+	return issues
+}
+
+// vanishedExpiries reports expirations quoted on the previous day but not on d although they expire after d
+func vanishedExpiries(key model.ChainKey, d time.Time, prev, day *validatorDay) []Issue {
+	prevExps := make(map[time.Time]bool)
+	for ck := range prev.contracts {
+		prevExps[ck.exp] = true
+	}
+	for ck := range day.contracts {
+		delete(prevExps, ck.exp)
+	}
+	exps := make([]time.Time, 0)
+	for exp := range prevExps {
+		if exp.After(d) {
+			exps = append(exps, exp)
+		}
+	}
+	sort.Slice(exps, func(i, j int) bool {
+		return exps[i].Before(exps[j])
+	})
+	issues := make([]Issue, 0, len(exps))
+	for _, exp := range exps {
+		issues = append(issues, newIssue(IssueVanishedExpiry, key, d, exp, "", "", ""))
+	}
+	return issues
+}
+
+func newIssue(kind IssueKind, key model.ChainKey, d, exp time.Time, strike, typ, detail string) Issue {
+	i := Issue{
+		Kind:      kind,
+		Symbol:    key.UndSym,
+		Root:      key.Root,
+		QuoteDate: d.Format(model.DateLayout),
+		Strike:    strike,
+		Type:      typ,
+		Detail:    detail,
+	}
+	if !exp.IsZero() {
+		i.Expiration = exp.Format(model.DateLayout)
+	}
+	return i
+}
+
+func kindOrder(k IssueKind) int {
+	for i, kind := range issueKinds {
+		if kind == k {
+			return i
+		}
+	}
+	return len(issueKinds)
+}
+
+// OutputTable writes a summary of issue counts followed by at most limit issues. A limit below zero writes every issue.
+func (r *ValidationReport) OutputTable(w io.Writer, limit int) {
+	summary := tablewriter.NewWriter(w)
+	summary.SetHeader([]string{"Issue", "Count"})
+	for _, k := range issueKinds {
+		summary.Append([]string{string(k), fmt.Sprintf("%d", r.Counts[k])})
+	}
+	summary.SetFooter([]string{fmt.Sprintf("%d rows", r.Rows), fmt.Sprintf("%d", len(r.Issues))})
+	summary.Render()
+
+	if len(r.Issues) == 0 {
+		return
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{
+		"Issue",
+		"Symbol",
+		"Root",
+		"Quote Date",
+		"Expiration",
+		"Strike",
+		"Type",
+		"Detail",
+	})
+	for idx, i := range r.Issues {
+		if limit >= 0 && idx >= limit {
+			break
+		}
+		table.Append([]string{
+			string(i.Kind),
+			i.Symbol,
+			i.Root,
+			i.QuoteDate,
+			i.Expiration,
+			i.Strike,
+			i.Type,
+			i.Detail,
+		})
+	}
+	table.Render()
+}
+
+// OutputJSON writes the whole report as JSON
+func (r *ValidationReport) OutputJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return errors.Wrap(err, "Error encoding validation report")
+	}
+	return nil
+}
+
+// Quarantine drops rows with problems that can be detected while loading: zero or crossed quotes and contracts already loaded for the same day
+type Quarantine struct {
+	seen    map[model.ChainKey]map[time.Time]map[contractKey]bool
+	Dropped map[IssueKind]int
+}
+
+// NewQuarantine creates an empty quarantine
+func NewQuarantine() *Quarantine {
+	return &Quarantine{
+		seen:    make(map[model.ChainKey]map[time.Time]map[contractKey]bool),
+		Dropped: make(map[IssueKind]int),
+	}
+}
+
+// Allow returns false if the row should be dropped
+func (q *Quarantine) Allow(o model.OHLCV) bool {
+	if kind, ok := RowIssue(o); ok {
+		q.Dropped[kind]++
+		return false
+	}
+	key := model.NewChainKey(o.UndSym, o.Root)
+	days, ok := q.seen[key]
+	if !ok {
+		days = make(map[time.Time]map[contractKey]bool)
+		q.seen[key] = days
+	}
+	contracts, ok := days[o.QuoteDate]
+	if !ok {
+		contracts = make(map[contractKey]bool)
+		days[o.QuoteDate] = contracts
+	}
+	ck := contractKey{exp: o.Expiration, strike: o.Strike.String(), typ: o.Type}
+	if contracts[ck] {
+		q.Dropped[IssueDuplicateRow]++
+		return false
+	}
+	contracts[ck] = true
+	return true
+}
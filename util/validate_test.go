@@ -0,0 +1,120 @@
+package util
+
+import (
+	"backtest-options/calendar"
+	"backtest-options/model"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestValidator(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	row := func(quote, exp, strike string, typ model.OptType, bid, ask, und string) model.OHLCV {
+		o, _ := model.NewOHLCV(parse(quote), "SPY", parse(exp), strike, typ, "1", "1", "1", "1", "10", ask, bid, und, und)
+		return o
+	}
+
+	v := NewValidator(decimal.NewFromFloat(0.1), nil)
+	rows := []model.OHLCV{
+		// friday 2016-06-03: a clean strike and a one sided strike
+		row("2016-06-03", "2016-07-15", "100", model.Call, "1", "1.1", "100"),
+		row("2016-06-03", "2016-07-15", "100", model.Put, "1", "1.1", "100"),
+		row("2016-06-03", "2016-08-19", "100", model.Call, "2", "2.1", "100"),
+		row("2016-06-03", "2016-08-19", "100", model.Put, "2", "2.1", "100"),
+		row("2016-06-03", "2016-07-15", "105", model.Call, "0.5", "0.6", "100"),
+		// tuesday 2016-06-07: monday is missing, the august expiry vanished, the underlying jumped
+		row("2016-06-07", "2016-07-15", "100", model.Call, "1.2", "1.1", "120"),
+		row("2016-06-07", "2016-07-15", "100", model.Put, "0", "0", "120"),
+		row("2016-06-07", "2016-07-15", "100", model.Put, "0", "0", "120"),
+	}
+	for _, r := range rows {
+		v.Add(r)
+	}
+	report := v.Report()
+
+	expected := []struct {
+		kind  IssueKind
+		quote string
+		exp   string
+	}{
+		{IssueOneSidedStrike, "2016-06-03", "2016-07-15"},
+		{IssueMissingDay, "2016-06-06", ""},
+		{IssueCrossedQuote, "2016-06-07", "2016-07-15"},
+		{IssueZeroQuote, "2016-06-07", "2016-07-15"},
+		{IssueZeroQuote, "2016-06-07", "2016-07-15"},
+		{IssueDuplicateRow, "2016-06-07", "2016-07-15"},
+		{IssueVanishedExpiry, "2016-06-07", "2016-08-19"},
+		{IssueUnderlyingJump, "2016-06-07", ""},
+	}
+	if report.Rows != len(rows) {
+		t.Errorf("Expected %d rows but got %d", len(rows), report.Rows)
+	}
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("Expected %d issues but got %+v", len(expected), report.Issues)
+	}
+	for idx, exp := range expected {
+		i := report.Issues[idx]
+		if i.Kind != exp.kind || i.QuoteDate != exp.quote || i.Expiration != exp.exp {
+			t.Errorf("Expected %+v but got %+v at idx: %d", exp, i, idx)
+		}
+	}
+	if report.Counts[IssueZeroQuote] != 2 {
+		t.Errorf("Expected 2 zero quotes but got %d", report.Counts[IssueZeroQuote])
+	}
+
+	var buf bytes.Buffer
+	if err := report.OutputJSON(&buf); err != nil {
+		t.Fatal(errors.Wrap(err, "Error writing json"))
+	}
+	var decoded ValidationReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(errors.Wrap(err, "Error reading json"))
+	}
+	if len(decoded.Issues) != len(expected) {
+		t.Errorf("Expected %d issues in json but got %d", len(expected), len(decoded.Issues))
+	}
+
+	q := NewQuarantine()
+	allowed := 0
+	for _, r := range rows {
+		if q.Allow(r) {
+			allowed++
+		}
+	}
+	if allowed != 5 || q.Dropped[IssueCrossedQuote] != 1 || q.Dropped[IssueZeroQuote] != 2 {
+		t.Errorf("Expected 5 allowed rows but got %d with dropped %+v", allowed, q.Dropped)
+	}
+}
+
+func TestValidatorCalendar(t *testing.T) {
+	parse := func(s string) time.Time {
+		d, _ := time.Parse(model.DateLayout, s)
+		return d
+	}
+	tt := []struct {
+		cal     *calendar.Calendar
+		missing int
+	}{
+		// memorial day is only a missing weekday without a calendar
+		{cal: nil, missing: 1},
+		{cal: calendar.NYSE(), missing: 0},
+	}
+	for idx, tab := range tt {
+		v := NewValidator(decimal.NewFromFloat(0.1), tab.cal)
+		for _, quote := range []string{"2016-05-27", "2016-05-31"} {
+			o, _ := model.NewOHLCV(parse(quote), "SPY", parse("2016-07-15"), "100", model.Call, "1", "1", "1", "1", "10", "1.1", "1", "100", "100")
+			v.Add(o)
+		}
+		if n := v.Report().Counts[IssueMissingDay]; n != tab.missing {
+			t.Errorf("Expected %d missing days but got %d at idx: %d", tab.missing, n, idx)
+		}
+	}
+}